@@ -0,0 +1,115 @@
+package audit
+
+import (
+	"fmt"
+	"github.com/Geepr/game/auth"
+	"github.com/Geepr/game/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gofrs/uuid"
+	"net/http"
+	"time"
+)
+
+// ActorFromContext describes the caller of a request, to be passed to the repository functions making changes.
+func ActorFromContext(c *gin.Context) Actor {
+	actor := Actor{Kind: "anonymous", RequestId: utils.GetNilIfDefault(utils.GetRequestId(c))}
+	if principal := auth.GetPrincipal(c); principal != nil {
+		actor.Kind = string(principal.Kind)
+		actor.Subject = &principal.Subject
+	}
+	return actor
+}
+
+type revision struct {
+	Revision int64 `uri:"rev" binding:"required,min=1"`
+}
+
+// ParseRevisionFromParam reads the revision number from the rev uri parameter.
+func ParseRevisionFromParam(c *gin.Context) (int64, error) {
+	var rev revision
+	if err := c.ShouldBindUri(&rev); err != nil {
+		utils.Logger(c).Infof("Failed to parse revision: %s", err.Error())
+		return 0, err
+	}
+	return rev.Revision, nil
+}
+
+type pageQuery struct {
+	PageIndex int `form:"page"`
+	PageSize  int `form:"size"`
+}
+
+func getRoute(c *gin.Context) {
+	var query struct {
+		pageQuery
+		EntityType EntityType `form:"entityType" binding:"omitempty,oneof=game platform release"`
+		EntityId   string     `form:"entityId" binding:"omitempty,uuid"`
+		Action     Action     `form:"action" binding:"omitempty,oneof=insert update delete"`
+		Subject    string     `form:"actor"`
+		From       time.Time  `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+		To         time.Time  `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	}
+	if err := c.ShouldBindWith(&query, binding.Query); err != nil {
+		utils.Logger(c).Infof("Failed to bind audit log query: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+
+	filter := Filter{
+		EntityType: query.EntityType,
+		EntityId:   uuid.FromStringOrNil(query.EntityId),
+		Action:     query.Action,
+		Subject:    query.Subject,
+		From:       query.From,
+		To:         query.To,
+	}
+	respondWithEntries(c, filter, query.pageQuery)
+}
+
+// HistoryRoute lists changes of a single entity, identified by the id uri parameter.
+// Packages owning the entities register it under their own urls.
+func HistoryRoute(entityType EntityType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := utils.ParseUuidFromParam(c)
+		if err != nil {
+			utils.AbortWithBindingError(err, c)
+			return
+		}
+		var query pageQuery
+		if err := c.ShouldBindWith(&query, binding.Query); err != nil {
+			utils.Logger(c).Infof("Failed to bind entity history query: %s", err.Error())
+			utils.AbortWithBindingError(err, c)
+			return
+		}
+
+		respondWithEntries(c, Filter{EntityType: entityType, EntityId: id}, query)
+	}
+}
+
+func respondWithEntries(c *gin.Context, filter Filter, page pageQuery) {
+	entries, totalItems, err := getEntries(c, filter, page.PageIndex, page.PageSize)
+	if err != nil {
+		utils.AbortWithProblem(http.StatusInternalServerError, "", c)
+		return
+	}
+
+	response := struct {
+		Entries    []*Entry `json:"entries"`
+		Page       int      `json:"page"`
+		PageSize   int      `json:"pageSize"`
+		TotalPages int      `json:"totalPages"`
+	}{
+		Entries:    entries,
+		Page:       page.PageIndex,
+		PageSize:   page.PageSize,
+		TotalPages: utils.GetPagesFromItems(totalItems, page.PageSize),
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func SetupRoutes(engine *gin.Engine, basePath string) {
+	baseUrl := fmt.Sprintf("%s/api/v0/audit", basePath)
+
+	engine.GET(baseUrl, auth.Require(auth.ResourceAudit, auth.ActionRead), getRoute)
+}
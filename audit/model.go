@@ -0,0 +1,48 @@
+package audit
+
+import (
+	"encoding/json"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+type EntityType string
+
+const (
+	EntityGame     EntityType = "game"
+	EntityPlatform EntityType = "platform"
+	EntityRelease  EntityType = "release"
+)
+
+type Action string
+
+const (
+	ActionInsert Action = "insert"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Actor describes who made a change, it's stored with each Entry.
+type Actor struct {
+	// Kind is the kind of the authenticated principal, or "system" for changes made outside of http requests.
+	Kind    string  `json:"kind"`
+	Subject *string `json:"subject"`
+	// RequestId links the change with the request logs.
+	RequestId *string `json:"requestId"`
+}
+
+// System is the actor used for changes made by the service itself or its tooling.
+var System = Actor{Kind: "system"}
+
+// Entry is a single recorded change of an entity.
+// Before is nil for inserts and After is nil for deletes.
+type Entry struct {
+	Id         int64           `json:"id"`
+	EntityType EntityType      `json:"entityType"`
+	EntityId   uuid.UUID       `json:"entityId"`
+	Action     Action          `json:"action"`
+	Actor      Actor           `json:"actor"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+}
@@ -0,0 +1,214 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Geepr/game/metrics"
+	"github.com/Geepr/game/utils"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/gofrs/uuid"
+	"strings"
+	"time"
+)
+
+// snapshotQueries return the stored state of an entity as json, including its relationships and the rows it owns.
+// Generated columns are skipped, as they can't be written back, and so are the keys linking owned rows to the entity.
+var snapshotQueries = map[EntityType]string{
+	EntityGame:     "select to_jsonb(g) - 'title_normalised' || jsonb_build_object('videos', " + videosSnapshot("game_id", "g") + ") from games g where g.id = $1",
+	EntityPlatform: "select to_jsonb(p) - 'name_normalised' - 'short_name_normalised' from platforms p where p.id = $1",
+	EntityRelease: "select to_jsonb(gr) - 'title_override_normalised' || jsonb_build_object('platform_ids', game_release_platform_ids(gr.id), " +
+		"'languages', (select coalesce(jsonb_agg(to_jsonb(l) - 'game_release_id' order by l.language), '[]') from game_release_languages l where l.game_release_id = gr.id), " +
+		"'system_requirements', (select coalesce(jsonb_agg(to_jsonb(s) - 'game_release_id' order by s.level), '[]') from game_release_system_requirements s where s.game_release_id = gr.id), " +
+		"'videos', " + videosSnapshot("game_release_id", "gr") + ") from game_releases gr where gr.id = $1",
+}
+
+// entityTables are the tables storing the rows of each entity type.
+var entityTables = map[EntityType]string{
+	EntityGame:     "games",
+	EntityPlatform: "platforms",
+	EntityRelease:  "game_releases",
+}
+
+// videosSnapshot returns a subquery of videos owned by the entity, without their ids, as they are generated again on every change.
+func videosSnapshot(ownerColumn string, alias string) string {
+	return fmt.Sprintf("(select coalesce(jsonb_agg(to_jsonb(v) - 'id' - 'game_id' - 'game_release_id' order by v.published_at nulls last, v.id), '[]') from videos v where v.%s = %s.id)", ownerColumn, alias)
+}
+
+// Filter groups optional conditions of the audit feed, default values are not applied.
+type Filter struct {
+	EntityType EntityType
+	EntityId   uuid.UUID
+	Action     Action
+	Subject    string
+	From       time.Time
+	To         time.Time
+}
+
+// Snapshot returns the current state of an entity, or nil if it doesn't exist.
+// It should be called with the same transaction the change is made in.
+func Snapshot(ctx context.Context, connector gotabase.Connector, entityType EntityType, id uuid.UUID) (json.RawMessage, error) {
+	query, ok := snapshotQueries[entityType]
+	if !ok {
+		return nil, fmt.Errorf("entity type %s can't be audited", entityType)
+	}
+	result, err := connector.QueryRow(query, id)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to take %s snapshot: %s", entityType, err.Error())
+		return nil, err
+	}
+	var snapshot []byte
+	if err := result.Scan(&snapshot); err != nil {
+		if utils.ConvertIfNotFoundErr(err) == utils.DataNotFoundErr {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Record stores a change of an entity, before should be taken with Snapshot prior to making the change.
+// The state after the change is read from the database, so this must be called after the change, with the same transaction.
+func Record(ctx context.Context, connector gotabase.Connector, entityType EntityType, id uuid.UUID, action Action, actor Actor, before json.RawMessage) error {
+	var after json.RawMessage
+	if action != ActionDelete {
+		var err error
+		if after, err = Snapshot(ctx, connector, entityType, id); err != nil {
+			return err
+		}
+	}
+	query := "insert into audit_log (entity_type, entity_id, action, actor_kind, actor_subject, request_id, before, after) values ($1, $2, $3, $4, $5, $6, $7, $8)"
+	if _, err := connector.Exec(query, entityType, id, action, actor.Kind, actor.Subject, actor.RequestId, nullableJson(before), nullableJson(after)); err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute insert query on audit log: %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// RestoreOwned replaces rows owned by an entity with those stored under the key of a revision state, linking them to the entity with the owner column.
+// Revisions recorded before the key was part of snapshots leave the current rows untouched.
+// It should be called with the same transaction the entity is reverted in.
+func RestoreOwned(ctx context.Context, connector gotabase.Connector, state json.RawMessage, key string, table string, ownerColumn string, id uuid.UUID, columns ...string) error {
+	query := fmt.Sprintf("delete from %s where %s = $1 and $2::jsonb ? $3::text", table, ownerColumn)
+	if _, err := connector.Exec(query, id, string(state), key); err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute delete query on %s: %s", table, err.Error())
+		return err
+	}
+	query = fmt.Sprintf("insert into %[1]s (%[2]s, %[3]s) select $1, %[3]s from jsonb_populate_recordset(null::%[1]s, $2::jsonb -> $3::text)", table, ownerColumn, strings.Join(columns, ", "))
+	if _, err := connector.Exec(query, id, string(state), key); err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute insert query on %s: %s", table, err.Error())
+		return err
+	}
+	return nil
+}
+
+// Lock locks the row of an entity until the end of the transaction of the connector, so that it can't be changed by anyone else in the meantime.
+// Entities that don't exist are not locked, without returning an error.
+func Lock(ctx context.Context, connector gotabase.Connector, entityType EntityType, id uuid.UUID) error {
+	table, ok := entityTables[entityType]
+	if !ok {
+		return fmt.Errorf("entity type %s can't be audited", entityType)
+	}
+	if _, err := connector.Exec(fmt.Sprintf("select 1 from %s where id = $1 for update", table), id); err != nil {
+		utils.Logger(ctx).Warnf("Failed to lock %s: %s", entityType, err.Error())
+		return err
+	}
+	return nil
+}
+
+// GetRevision returns the state of an entity recorded by an audit entry, the entry id being the revision number.
+// Entries of deletions hold no state, so they can't be used as revisions.
+func GetRevision(ctx context.Context, connector gotabase.Connector, entityType EntityType, id uuid.UUID, revision int64) (json.RawMessage, error) {
+	query := "select after from audit_log where id = $1 and entity_type = $2 and entity_id = $3"
+	result, err := connector.QueryRow(query, revision, entityType, id)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute select query on audit log: %s", err.Error())
+		return nil, err
+	}
+	var state []byte
+	if err := result.Scan(&state); err != nil {
+		return nil, utils.ConvertIfNotFoundErr(err)
+	}
+	if state == nil {
+		return nil, utils.InvalidDataErr
+	}
+	return state, nil
+}
+
+// LatestRevision returns the id of the newest audit entry of an entity, or 0 if it has never been changed through the service.
+func LatestRevision(ctx context.Context, connector gotabase.Connector, entityType EntityType, id uuid.UUID) (int64, error) {
+	query := "select coalesce(max(id), 0) from audit_log where entity_type = $1 and entity_id = $2"
+	result, err := connector.QueryRow(query, entityType, id)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute select query on audit log: %s", err.Error())
+		return 0, err
+	}
+	var revision int64
+	if err := result.Scan(&revision); err != nil {
+		return 0, err
+	}
+	return revision, nil
+}
+
+func getEntries(ctx context.Context, filter Filter, pageIndex int, pageSize int) (_ []*Entry, _ int, err error) {
+	defer metrics.ObserveQuery("getEntries", time.Now(), &err)
+	query := "select id, entity_type, entity_id, action, actor_kind, actor_subject, request_id, occurred_at, before, after from audit_log"
+	query, args := utils.AppendWhereClause(query, "entity_type", "=", filter.EntityType, func(t EntityType) bool { return t != "" }, []any{})
+	query, args = utils.AppendWhereClause(query, "entity_id", "=", filter.EntityId, utils.IsUuidNotEmpty, args)
+	query, args = utils.AppendWhereClause(query, "action", "=", filter.Action, func(a Action) bool { return a != "" }, args)
+	query, args = utils.AppendWhereClause(query, "actor_subject", "=", filter.Subject, utils.IsStringNotEmpty, args)
+	query, args = utils.AppendWhereClause(query, "occurred_at", ">=", filter.From, isTimeSet, args)
+	query, args = utils.AppendWhereClause(query, "occurred_at", "<", filter.To, isTimeSet, args)
+	query += " order by id desc"
+	query, countQuery, err := utils.Paginate(query, pageIndex, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	countResults, err := utils.ScanCountQuery(ctx, getConnector(ctx), countQuery, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	entries, err := scanEntries(ctx, query, args...)
+	return entries, countResults, err
+}
+
+func scanEntries(ctx context.Context, sql string, args ...interface{}) ([]*Entry, error) {
+	result, err := getConnector(ctx).QueryRows(sql, args...)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to run query on audit log: %s", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	entries := make([]*Entry, 0)
+	for result.Next() {
+		entry, err := scanRow(result)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func scanRow(row gotabase.Row) (*Entry, error) {
+	entry := Entry{}
+	var before, after []byte
+	if err := row.Scan(&entry.Id, &entry.EntityType, &entry.EntityId, &entry.Action, &entry.Actor.Kind, &entry.Actor.Subject, &entry.Actor.RequestId, &entry.OccurredAt, &before, &after); err != nil {
+		return nil, utils.ConvertIfNotFoundErr(err)
+	}
+	entry.Before = before
+	entry.After = after
+	return &entry, nil
+}
+
+func nullableJson(value json.RawMessage) any {
+	if value == nil {
+		return nil
+	}
+	return []byte(value)
+}
+
+func isTimeSet(value time.Time) bool {
+	return !value.IsZero()
+}
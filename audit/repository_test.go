@@ -0,0 +1,139 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Geepr/game/mocks"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/gofrs/uuid"
+	"testing"
+	"time"
+)
+
+type auditRepoTest struct {
+	connection gotabase.Connector
+	gameId     uuid.UUID
+	platformId uuid.UUID
+	dbName     string
+}
+
+func newAuditRepoTest(t *testing.T) *auditRepoTest {
+	db, name := mocks.GetDatabase()
+	test := &auditRepoTest{
+		connection: db,
+		dbName:     name,
+	}
+	getConnector = func(context.Context) gotabase.Connector { return db }
+	t.Cleanup(test.cleanup)
+	return test
+}
+
+func (test *auditRepoTest) cleanup() {
+	mocks.DropDatabase(test.dbName)
+}
+
+// insertMockData creates a single game and platform, and records their insertion by the system and a user respectively.
+func (test *auditRepoTest) insertMockData() {
+	test.gameId, _ = uuid.NewV4()
+	test.platformId, _ = uuid.NewV4()
+	_, err := test.connection.Exec("insert into games (id, title, archived) values ($1, 'aaa', false)", test.gameId)
+	mocks.PanicOnErr(err)
+	_, err = test.connection.Exec("insert into platforms (id, name, short_name) values ($1, 'aaa', 'aa')", test.platformId)
+	mocks.PanicOnErr(err)
+	mocks.PanicOnErr(Record(context.Background(), test.connection, EntityGame, test.gameId, ActionInsert, System, nil))
+	subject := "user"
+	mocks.PanicOnErr(Record(context.Background(), test.connection, EntityPlatform, test.platformId, ActionInsert, Actor{Kind: "user", Subject: &subject}, nil))
+}
+
+func TestAuditRepository_Snapshot_EntityExists_ReturnsState(t *testing.T) {
+	test := newAuditRepoTest(t)
+	test.insertMockData()
+
+	snapshot, err := Snapshot(context.Background(), test.connection, EntityGame, test.gameId)
+
+	mocks.AssertDefault(t, err)
+	var state map[string]any
+	mocks.PanicOnErr(json.Unmarshal(snapshot, &state))
+	mocks.AssertEquals(t, state["title"], "aaa")
+	_, hasGenerated := state["title_normalised"]
+	mocks.AssertEquals(t, hasGenerated, false)
+}
+
+func TestAuditRepository_Snapshot_EntityMissing_ReturnsNil(t *testing.T) {
+	test := newAuditRepoTest(t)
+	test.insertMockData()
+	fakeId, _ := uuid.NewV4()
+
+	snapshot, err := Snapshot(context.Background(), test.connection, EntityGame, fakeId)
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, snapshot == nil, true)
+}
+
+func TestAuditRepository_Record_Update_StoresBeforeAndAfter(t *testing.T) {
+	test := newAuditRepoTest(t)
+	test.insertMockData()
+	before, _ := Snapshot(context.Background(), test.connection, EntityGame, test.gameId)
+	_, err := test.connection.Exec("update games set title = 'bbb' where id = $1", test.gameId)
+	mocks.PanicOnErr(err)
+
+	err = Record(context.Background(), test.connection, EntityGame, test.gameId, ActionUpdate, System, before)
+
+	mocks.AssertDefault(t, err)
+	entries, _, _ := getEntries(context.Background(), Filter{EntityId: test.gameId, Action: ActionUpdate}, 0, 10)
+	mocks.AssertCountEqual(t, entries, 1)
+	var beforeState, afterState map[string]any
+	mocks.PanicOnErr(json.Unmarshal(entries[0].Before, &beforeState))
+	mocks.PanicOnErr(json.Unmarshal(entries[0].After, &afterState))
+	mocks.AssertEquals(t, beforeState["title"], "aaa")
+	mocks.AssertEquals(t, afterState["title"], "bbb")
+}
+
+func TestAuditRepository_Record_Delete_AfterEmpty(t *testing.T) {
+	test := newAuditRepoTest(t)
+	test.insertMockData()
+	before, _ := Snapshot(context.Background(), test.connection, EntityPlatform, test.platformId)
+
+	err := Record(context.Background(), test.connection, EntityPlatform, test.platformId, ActionDelete, System, before)
+
+	mocks.AssertDefault(t, err)
+	entries, _, _ := getEntries(context.Background(), Filter{EntityId: test.platformId, Action: ActionDelete}, 0, 10)
+	mocks.AssertCountEqual(t, entries, 1)
+	mocks.AssertEquals(t, entries[0].After == nil, true)
+}
+
+func TestAuditRepository_GetEntries_NoFilter_ReturnsAllNewestFirst(t *testing.T) {
+	test := newAuditRepoTest(t)
+	test.insertMockData()
+
+	entries, items, err := getEntries(context.Background(), Filter{}, 0, 10)
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertCountEqual(t, entries, 2)
+	mocks.AssertEquals(t, items, 2)
+	mocks.AssertEquals(t, entries[0].EntityType, EntityPlatform)
+	mocks.AssertEquals(t, entries[1].EntityType, EntityGame)
+}
+
+func TestAuditRepository_GetEntries_SubjectSet_ReturnsMatching(t *testing.T) {
+	test := newAuditRepoTest(t)
+	test.insertMockData()
+
+	entries, items, err := getEntries(context.Background(), Filter{Subject: "user"}, 0, 10)
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertCountEqual(t, entries, 1)
+	mocks.AssertEquals(t, items, 1)
+	mocks.AssertEquals(t, entries[0].EntityId, test.platformId)
+}
+
+func TestAuditRepository_GetEntries_FromInFuture_ReturnsEmpty(t *testing.T) {
+	test := newAuditRepoTest(t)
+	test.insertMockData()
+
+	entries, items, err := getEntries(context.Background(), Filter{From: time.Now().Add(time.Hour)}, 0, 10)
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertCountEqual(t, entries, 0)
+	mocks.AssertEquals(t, items, 0)
+}
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// apiKeyPrefix makes api keys recognisable, both for secret scanners and for telling them apart from bearer tokens.
+const apiKeyPrefix = "gk_"
+
+func generateApiKey() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func hashApiKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+func isApiKey(credential string) bool {
+	return strings.HasPrefix(credential, apiKeyPrefix)
+}
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"fmt"
+	"github.com/Geepr/game/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"net/http"
+)
+
+func getApiKeysRoute(c *gin.Context) {
+	keys, err := getApiKeys(c)
+	if err != nil {
+		utils.AbortWithProblem(http.StatusInternalServerError, "", c)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func createApiKeyRoute(c *gin.Context) {
+	var createModel struct {
+		Name string `json:"name" binding:"required,max=200"`
+	}
+	if err := c.ShouldBindWith(&createModel, binding.JSON); err != nil {
+		utils.Logger(c).Infof("Failed to parse api key creation model: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+
+	key, err := generateApiKey()
+	if err != nil {
+		utils.AbortWithProblem(http.StatusInternalServerError, "", c)
+		return
+	}
+	apiKey := ApiKey{Name: createModel.Name}
+	if err := addApiKey(c, &apiKey, hashApiKey(key)); err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+
+	// this is the only time the key is available, it can't be recovered later
+	c.JSON(http.StatusCreated, struct {
+		ApiKey
+		Key string `json:"key"`
+	}{apiKey, key})
+}
+
+func revokeApiKeyRoute(c *gin.Context) {
+	id, err := utils.ParseUuidFromParam(c)
+	if err != nil {
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+
+	if err := revokeApiKey(c, id); err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func getRoleAssignmentsRoute(c *gin.Context) {
+	assignments, err := getRoleAssignments(c)
+	if err != nil {
+		utils.AbortWithProblem(http.StatusInternalServerError, "", c)
+		return
+	}
+
+	c.JSON(http.StatusOK, assignments)
+}
+
+type roleAssignmentUri struct {
+	PrincipalKind PrincipalKind `uri:"kind" binding:"required,oneof=user apikey"`
+	Subject       string        `uri:"subject" binding:"required,max=200"`
+}
+
+func setRoleAssignmentRoute(c *gin.Context) {
+	var uri roleAssignmentUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		utils.Logger(c).Infof("Failed to parse role assignment uri: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	var updateModel struct {
+		Role Role `json:"role" binding:"required,oneof=viewer editor moderator admin"`
+	}
+	if err := c.ShouldBindWith(&updateModel, binding.JSON); err != nil {
+		utils.Logger(c).Infof("Failed to parse role assignment model: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+
+	assignment := RoleAssignment{
+		PrincipalKind: uri.PrincipalKind,
+		Subject:       uri.Subject,
+		Role:          updateModel.Role,
+	}
+	if err := setRoleAssignment(c, &assignment); err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, &assignment)
+}
+
+func deleteRoleAssignmentRoute(c *gin.Context) {
+	var uri roleAssignmentUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		utils.Logger(c).Infof("Failed to parse role assignment uri: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+
+	if err := deleteRoleAssignment(c, uri.PrincipalKind, uri.Subject); err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// SetupRoutes registers administrative routes, listing api keys and roles requires the same permissions as changing them.
+func SetupRoutes(engine *gin.Engine, basePath string) {
+	apiKeysUrl := fmt.Sprintf("%s/api/v0/api-keys", basePath)
+	engine.GET(apiKeysUrl, Require(ResourceApiKey, ActionUpdate), getApiKeysRoute)
+	engine.POST(apiKeysUrl, Require(ResourceApiKey, ActionCreate), createApiKeyRoute)
+	engine.DELETE(apiKeysUrl+"/:id", Require(ResourceApiKey, ActionDelete), revokeApiKeyRoute)
+
+	rolesUrl := fmt.Sprintf("%s/api/v0/roles", basePath)
+	engine.GET(rolesUrl, Require(ResourceRole, ActionUpdate), getRoleAssignmentsRoute)
+	engine.PUT(rolesUrl+"/:kind/:subject", Require(ResourceRole, ActionUpdate), setRoleAssignmentRoute)
+	engine.DELETE(rolesUrl+"/:kind/:subject", Require(ResourceRole, ActionDelete), deleteRoleAssignmentRoute)
+}
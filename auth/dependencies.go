@@ -0,0 +1,13 @@
+package auth
+
+import (
+	"context"
+	"github.com/Geepr/game/tracing"
+	"github.com/KowalskiPiotr98/gotabase"
+)
+
+var (
+	getConnector = func(ctx context.Context) gotabase.Connector {
+		return tracing.WrapConnector(ctx, gotabase.GetConnection())
+	}
+)
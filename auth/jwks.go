@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+var (
+	UnknownKeyErr = errors.New("token was not signed with any of the known keys")
+)
+
+// keySet holds verification keys loaded from a JSON Web Key Set, indexed by key id.
+// Values are either *rsa.PublicKey or []byte for symmetric keys.
+type keySet map[string]any
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+func loadKeySet(path string) (keySet, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwks file: %w", err)
+	}
+	return parseKeySet(contents)
+}
+
+func parseKeySet(contents []byte) (keySet, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(contents, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse jwks: %w", err)
+	}
+
+	keys := make(keySet, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		var parsed any
+		var err error
+		switch key.Kty {
+		case "RSA":
+			parsed, err = parseRsaKey(key)
+		case "oct":
+			parsed, err = base64.RawURLEncoding.DecodeString(key.K)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key %q: %w", key.Kid, err)
+		}
+		keys[key.Kid] = parsed
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks does not contain any usable signing keys")
+	}
+	return keys, nil
+}
+
+func parseRsaKey(key jsonWebKey) (*rsa.PublicKey, error) {
+	modulus, err := base64.RawURLEncoding.DecodeString(key.N)
+	if err != nil {
+		return nil, err
+	}
+	exponent, err := base64.RawURLEncoding.DecodeString(key.E)
+	if err != nil {
+		return nil, err
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(modulus),
+		E: int(new(big.Int).SetBytes(exponent).Int64()),
+	}, nil
+}
+
+// find returns the key with the given id, or the only key in the set when the token doesn't specify one.
+func (s keySet) find(kid string) (any, error) {
+	if kid == "" && len(s) == 1 {
+		for _, key := range s {
+			return key, nil
+		}
+	}
+	key, ok := s[kid]
+	if !ok {
+		return nil, UnknownKeyErr
+	}
+	return key, nil
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/Geepr/game/config"
+	"github.com/Geepr/game/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+const principalContextKey = "auth.principal"
+
+var (
+	InvalidCredentialsErr = errors.New("provided credentials are not valid")
+)
+
+// Authenticator resolves credentials sent with requests to principals.
+type Authenticator struct {
+	tokens        *tokenVerifier
+	adminSubjects map[string]bool
+	// findApiKey and findRole are fields so that tests can run without a database.
+	findApiKey func(ctx context.Context, hash string) (*ApiKey, error)
+	findRole   func(ctx context.Context, kind PrincipalKind, subject string) (Role, error)
+}
+
+func NewAuthenticator(authConfig config.Auth) (*Authenticator, error) {
+	tokens, err := newTokenVerifier(authConfig)
+	if err != nil {
+		return nil, err
+	}
+	adminSubjects := make(map[string]bool, len(authConfig.AdminSubjects))
+	for _, subject := range authConfig.AdminSubjects {
+		adminSubjects[subject] = true
+	}
+	return &Authenticator{tokens: tokens, adminSubjects: adminSubjects, findApiKey: getActiveApiKeyByHash, findRole: getAssignedRole}, nil
+}
+
+// Middleware authenticates every request that carries credentials, either as an "Authorization: Bearer" header or an "X-API-Key" header.
+// Invalid credentials are always rejected, while requests without any credentials are only allowed to read data.
+func (a *Authenticator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := a.authenticate(c, c.Request)
+		if err != nil {
+			utils.Logger(c).Infof("Failed to authenticate request: %s", err.Error())
+			abortUnauthorized(c)
+			return
+		}
+		if principal == nil && !isReadOnlyMethod(c.Request.Method) {
+			abortUnauthorized(c)
+			return
+		}
+		if principal != nil {
+			if principal.Role, err = a.resolveRole(c, principal); err != nil {
+				utils.AbortWithProblem(http.StatusInternalServerError, "", c)
+				return
+			}
+			c.Set(principalContextKey, principal)
+		}
+		c.Next()
+	}
+}
+
+// Require only lets through principals whose role allows performing the action on the resource type.
+// It must be registered after the Middleware.
+func Require(resource Resource, action Action) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := GetPrincipal(c)
+		if principal == nil {
+			abortUnauthorized(c)
+			return
+		}
+		if !principal.Role.Can(resource, action) {
+			utils.Logger(c).Infof("Principal %s with role %s is not allowed to %s %s", principal.Subject, principal.Role, action, resource)
+			utils.AbortWithProblem(http.StatusForbidden, "", c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetPrincipal returns the caller authenticated by the Middleware, or nil for anonymous requests.
+func GetPrincipal(c *gin.Context) *Principal {
+	value, ok := c.Get(principalContextKey)
+	if !ok {
+		return nil
+	}
+	return value.(*Principal)
+}
+
+func (a *Authenticator) authenticate(ctx context.Context, request *http.Request) (*Principal, error) {
+	if apiKey := request.Header.Get("X-API-Key"); apiKey != "" {
+		return a.authenticateApiKey(ctx, apiKey)
+	}
+	header := request.Header.Get("Authorization")
+	if header == "" {
+		return nil, nil
+	}
+	scheme, credential, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || credential == "" {
+		return nil, InvalidCredentialsErr
+	}
+	if isApiKey(credential) {
+		return a.authenticateApiKey(ctx, credential)
+	}
+	if a.tokens == nil {
+		return nil, errors.New("bearer tokens are not accepted, as no jwks is configured")
+	}
+	return a.tokens.verify(credential)
+}
+
+func (a *Authenticator) authenticateApiKey(ctx context.Context, key string) (*Principal, error) {
+	if !isApiKey(key) {
+		return nil, InvalidCredentialsErr
+	}
+	apiKey, err := a.findApiKey(ctx, hashApiKey(key))
+	if errors.Is(err, utils.DataNotFoundErr) {
+		return nil, InvalidCredentialsErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{Kind: PrincipalApiKey, Subject: apiKey.Id.String(), Name: apiKey.Name}, nil
+}
+
+func (a *Authenticator) resolveRole(ctx context.Context, principal *Principal) (Role, error) {
+	if principal.Kind == PrincipalUser && a.adminSubjects[principal.Subject] {
+		return RoleAdmin, nil
+	}
+	role, err := a.findRole(ctx, principal.Kind, principal.Subject)
+	if errors.Is(err, utils.DataNotFoundErr) {
+		return RoleViewer, nil
+	}
+	return role, err
+}
+
+func isReadOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func abortUnauthorized(c *gin.Context) {
+	c.Header("WWW-Authenticate", "Bearer")
+	utils.AbortWithProblem(http.StatusUnauthorized, "", c)
+}
@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"github.com/Geepr/game/config"
+	"github.com/Geepr/game/mocks"
+	"github.com/Geepr/game/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type authTest struct {
+	rsaKey        *rsa.PrivateKey
+	hmacKey       []byte
+	validApiKey   string
+	authenticator *Authenticator
+	engine        *gin.Engine
+}
+
+func newAuthTest(t *testing.T) *authTest {
+	gin.SetMode(gin.TestMode)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	mocks.PanicOnErr(err)
+	test := &authTest{rsaKey: rsaKey, hmacKey: []byte("a very secret symmetric key value")}
+	test.validApiKey, err = generateApiKey()
+	mocks.PanicOnErr(err)
+
+	jwks := fmt.Sprintf(`{"keys": [{"kty": "RSA", "kid": "rsa", "use": "sig", "n": "%s", "e": "%s"}, {"kty": "oct", "kid": "hmac", "k": "%s"}]}`,
+		base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+		base64.RawURLEncoding.EncodeToString(test.hmacKey))
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	mocks.PanicOnErr(os.WriteFile(path, []byte(jwks), 0600))
+
+	test.authenticator, err = NewAuthenticator(config.Auth{JwksFile: path, Issuer: "geepr", AdminSubjects: []string{"root"}})
+	mocks.PanicOnErr(err)
+	test.authenticator.findApiKey = func(_ context.Context, hash string) (*ApiKey, error) {
+		if hash != hashApiKey(test.validApiKey) {
+			return nil, utils.DataNotFoundErr
+		}
+		id, _ := uuid.NewV4()
+		return &ApiKey{Id: id, Name: "test key"}, nil
+	}
+	test.authenticator.findRole = func(_ context.Context, kind PrincipalKind, subject string) (Role, error) {
+		if subject == "editor" {
+			return RoleEditor, nil
+		}
+		return "", utils.DataNotFoundErr
+	}
+
+	test.engine = gin.New()
+	test.engine.Use(test.authenticator.Middleware())
+	handler := func(c *gin.Context) {
+		principal := GetPrincipal(c)
+		if principal == nil {
+			c.String(http.StatusOK, "anonymous")
+			return
+		}
+		c.String(http.StatusOK, principal.Subject)
+	}
+	test.engine.GET("/resource", handler)
+	test.engine.POST("/resource", handler)
+	test.engine.POST("/releases", Require(ResourceRelease, ActionCreate), handler)
+	test.engine.DELETE("/platforms", Require(ResourcePlatform, ActionDelete), handler)
+	return test
+}
+
+func (test *authTest) signToken(method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	mocks.PanicOnErr(err)
+	return signed
+}
+
+func (test *authTest) validClaims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "user-1", "iss": "geepr", "exp": time.Now().Add(time.Hour).Unix()}
+}
+
+func (test *authTest) send(method string, headers map[string]string) *httptest.ResponseRecorder {
+	return test.sendTo(method, "/resource", headers)
+}
+
+func (test *authTest) sendTo(method string, path string, headers map[string]string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, nil)
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+	recorder := httptest.NewRecorder()
+	test.engine.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestMiddleware_NoCredentials_OnlyReadsAllowed(t *testing.T) {
+	test := newAuthTest(t)
+
+	readResponse := test.send(http.MethodGet, nil)
+	writeResponse := test.send(http.MethodPost, nil)
+
+	mocks.AssertEquals(t, readResponse.Code, http.StatusOK)
+	mocks.AssertEquals(t, readResponse.Body.String(), "anonymous")
+	mocks.AssertEquals(t, writeResponse.Code, http.StatusUnauthorized)
+}
+
+func TestMiddleware_ValidTokens_PrincipalSet(t *testing.T) {
+	test := newAuthTest(t)
+	testData := map[string]string{
+		"RS256": test.signToken(jwt.SigningMethodRS256, "rsa", test.rsaKey, test.validClaims()),
+		"HS256": test.signToken(jwt.SigningMethodHS256, "hmac", test.hmacKey, test.validClaims()),
+	}
+
+	for name, data := range testData {
+		token := data
+		t.Run(name, func(t *testing.T) {
+			response := test.send(http.MethodPost, map[string]string{"Authorization": "Bearer " + token})
+
+			mocks.AssertEquals(t, response.Code, http.StatusOK)
+			mocks.AssertEquals(t, response.Body.String(), "user-1")
+		})
+	}
+}
+
+func TestMiddleware_InvalidTokens_Rejected(t *testing.T) {
+	test := newAuthTest(t)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	expired := test.validClaims()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	wrongIssuer := test.validClaims()
+	wrongIssuer["iss"] = "someone else"
+	testData := map[string]string{
+		"expired":         test.signToken(jwt.SigningMethodRS256, "rsa", test.rsaKey, expired),
+		"wrong issuer":    test.signToken(jwt.SigningMethodRS256, "rsa", test.rsaKey, wrongIssuer),
+		"unknown kid":     test.signToken(jwt.SigningMethodRS256, "other", test.rsaKey, test.validClaims()),
+		"wrong signature": test.signToken(jwt.SigningMethodRS256, "rsa", otherKey, test.validClaims()),
+		"hmac with rsa":   test.signToken(jwt.SigningMethodHS256, "rsa", test.rsaKey.PublicKey.N.Bytes(), test.validClaims()),
+		"garbage":         "not.a.token",
+	}
+
+	for name, data := range testData {
+		token := data
+		t.Run(name, func(t *testing.T) {
+			response := test.send(http.MethodGet, map[string]string{"Authorization": "Bearer " + token})
+
+			mocks.AssertEquals(t, response.Code, http.StatusUnauthorized)
+		})
+	}
+}
+
+func TestMiddleware_ApiKeys_CheckedAgainstStore(t *testing.T) {
+	test := newAuthTest(t)
+	otherKey, _ := generateApiKey()
+	testData := []struct {
+		name     string
+		headers  map[string]string
+		expected int
+	}{
+		{"header", map[string]string{"X-API-Key": test.validApiKey}, http.StatusOK},
+		{"bearer", map[string]string{"Authorization": "Bearer " + test.validApiKey}, http.StatusOK},
+		{"unknown", map[string]string{"X-API-Key": otherKey}, http.StatusUnauthorized},
+		{"malformed", map[string]string{"X-API-Key": "something"}, http.StatusUnauthorized},
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(currentData.name, func(t *testing.T) {
+			response := test.send(http.MethodPost, currentData.headers)
+
+			mocks.AssertEquals(t, response.Code, currentData.expected)
+		})
+	}
+}
+
+func TestRequire_RolesResolved_PermissionsEnforced(t *testing.T) {
+	test := newAuthTest(t)
+	tokenFor := func(subject string) map[string]string {
+		claims := test.validClaims()
+		claims["sub"] = subject
+		return map[string]string{"Authorization": "Bearer " + test.signToken(jwt.SigningMethodRS256, "rsa", test.rsaKey, claims)}
+	}
+	testData := []struct {
+		name     string
+		method   string
+		path     string
+		headers  map[string]string
+		expected int
+	}{
+		{"anonymous", http.MethodPost, "/releases", nil, http.StatusUnauthorized},
+		{"viewer by default", http.MethodPost, "/releases", tokenFor("someone"), http.StatusForbidden},
+		{"editor adds release", http.MethodPost, "/releases", tokenFor("editor"), http.StatusOK},
+		{"editor deletes platform", http.MethodDelete, "/platforms", tokenFor("editor"), http.StatusForbidden},
+		{"bootstrap admin deletes platform", http.MethodDelete, "/platforms", tokenFor("root"), http.StatusOK},
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(currentData.name, func(t *testing.T) {
+			response := test.sendTo(currentData.method, currentData.path, currentData.headers)
+
+			mocks.AssertEquals(t, response.Code, currentData.expected)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+type PrincipalKind string
+
+const (
+	PrincipalUser   PrincipalKind = "user"
+	PrincipalApiKey PrincipalKind = "apikey"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Kind PrincipalKind `json:"kind"`
+	// Subject is the sub claim of a bearer token, or the id of an api key.
+	Subject string `json:"subject"`
+	// Name is a human readable name of the caller, if known.
+	Name string `json:"name"`
+	Role Role   `json:"role"`
+}
+
+// ApiKey is a long-lived credential meant for services and scripts.
+// Only the hash of the key is ever stored, the key itself is returned once, when it's created.
+type ApiKey struct {
+	Id        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt"`
+}
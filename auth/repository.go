@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"github.com/Geepr/game/metrics"
+	"github.com/Geepr/game/utils"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+func getApiKeys(ctx context.Context) (_ []*ApiKey, err error) {
+	defer metrics.ObserveQuery("getApiKeys", time.Now(), &err)
+	query := "select id, name, created_at, revoked_at from api_keys order by created_at"
+	result, err := getConnector(ctx).QueryRows(query)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to run query on api keys table: %s", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	keys := make([]*ApiKey, 0)
+	for result.Next() {
+		key, err := scanRow(result)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// getActiveApiKeyByHash only returns keys that were not revoked.
+func getActiveApiKeyByHash(ctx context.Context, hash string) (_ *ApiKey, err error) {
+	defer metrics.ObserveQuery("getActiveApiKeyByHash", time.Now(), &err)
+	query := "select id, name, created_at, revoked_at from api_keys where key_hash = $1 and revoked_at is null"
+	result, err := getConnector(ctx).QueryRow(query, hash)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to run query on api keys table: %s", err.Error())
+		return nil, err
+	}
+	return scanRow(result)
+}
+
+func addApiKey(ctx context.Context, apiKey *ApiKey, hash string) (err error) {
+	defer metrics.ObserveQuery("addApiKey", time.Now(), &err)
+	query := "insert into api_keys (name, key_hash) values ($1, $2) returning id, created_at"
+	result, err := getConnector(ctx).QueryRow(query, apiKey.Name, hash)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute insert query on api keys table: %s", err.Error())
+		return utils.ConvertIfDuplicateErr(err)
+	}
+	return result.Scan(&apiKey.Id, &apiKey.CreatedAt)
+}
+
+func revokeApiKey(ctx context.Context, id uuid.UUID) (err error) {
+	defer metrics.ObserveQuery("revokeApiKey", time.Now(), &err)
+	query := "update api_keys set revoked_at = now() where id = $1 and revoked_at is null"
+	result, err := getConnector(ctx).Exec(query, id)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute update query on api keys table: %s", err.Error())
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to get affected rows count when running update query on api keys table: %s", err.Error())
+		return err
+	}
+	if affected != 1 {
+		return utils.DataNotFoundErr
+	}
+	return nil
+}
+
+func scanRow(row gotabase.Row) (*ApiKey, error) {
+	key := ApiKey{}
+	if err := row.Scan(&key.Id, &key.Name, &key.CreatedAt, &key.RevokedAt); err != nil {
+		return nil, utils.ConvertIfNotFoundErr(err)
+	}
+	return &key, nil
+}
+
+func getRoleAssignments(ctx context.Context) (_ []*RoleAssignment, err error) {
+	defer metrics.ObserveQuery("getRoleAssignments", time.Now(), &err)
+	query := "select principal_kind, subject, role from role_assignments order by principal_kind, subject"
+	result, err := getConnector(ctx).QueryRows(query)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to run query on role assignments table: %s", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	assignments := make([]*RoleAssignment, 0)
+	for result.Next() {
+		assignment := RoleAssignment{}
+		if err := result.Scan(&assignment.PrincipalKind, &assignment.Subject, &assignment.Role); err != nil {
+			return nil, utils.ConvertIfNotFoundErr(err)
+		}
+		assignments = append(assignments, &assignment)
+	}
+	return assignments, nil
+}
+
+func getAssignedRole(ctx context.Context, kind PrincipalKind, subject string) (_ Role, err error) {
+	defer metrics.ObserveQuery("getAssignedRole", time.Now(), &err)
+	query := "select role from role_assignments where principal_kind = $1 and subject = $2"
+	result, err := getConnector(ctx).QueryRow(query, kind, subject)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to run query on role assignments table: %s", err.Error())
+		return "", err
+	}
+	var role Role
+	if err := result.Scan(&role); err != nil {
+		return "", utils.ConvertIfNotFoundErr(err)
+	}
+	return role, nil
+}
+
+// setRoleAssignment creates or replaces the role of a principal.
+func setRoleAssignment(ctx context.Context, assignment *RoleAssignment) (err error) {
+	defer metrics.ObserveQuery("setRoleAssignment", time.Now(), &err)
+	query := "insert into role_assignments (principal_kind, subject, role) values ($1, $2, $3) " +
+		"on conflict (principal_kind, subject) do update set role = excluded.role, assigned_at = now()"
+	if _, err := getConnector(ctx).Exec(query, assignment.PrincipalKind, assignment.Subject, assignment.Role); err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute upsert query on role assignments table: %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+func deleteRoleAssignment(ctx context.Context, kind PrincipalKind, subject string) (err error) {
+	defer metrics.ObserveQuery("deleteRoleAssignment", time.Now(), &err)
+	query := "delete from role_assignments where principal_kind = $1 and subject = $2"
+	result, err := getConnector(ctx).Exec(query, kind, subject)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute delete query on role assignments table: %s", err.Error())
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to get affected rows count when running delete query on role assignments table: %s", err.Error())
+		return err
+	}
+	if affected != 1 {
+		return utils.DataNotFoundErr
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"github.com/Geepr/game/mocks"
+	"github.com/Geepr/game/utils"
+	"github.com/KowalskiPiotr98/gotabase"
+	"testing"
+)
+
+type authRepoTest struct {
+	connection gotabase.Connector
+	dbName     string
+}
+
+func newAuthRepoTest(t *testing.T) *authRepoTest {
+	db, name := mocks.GetDatabase()
+	test := &authRepoTest{
+		connection: db,
+		dbName:     name,
+	}
+	getConnector = func(context.Context) gotabase.Connector { return db }
+	t.Cleanup(test.cleanup)
+	return test
+}
+
+func (test *authRepoTest) cleanup() {
+	mocks.DropDatabase(test.dbName)
+}
+
+func TestApiKeyRepository_AddApiKey_New_FoundByHash(t *testing.T) {
+	newAuthRepoTest(t)
+	apiKey := ApiKey{Name: "importer"}
+
+	err := addApiKey(context.Background(), &apiKey, hashApiKey("gk_test"))
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertNotDefault(t, apiKey.Id)
+	loaded, err := getActiveApiKeyByHash(context.Background(), hashApiKey("gk_test"))
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, loaded.Id, apiKey.Id)
+	mocks.AssertEquals(t, loaded.Name, "importer")
+}
+
+func TestApiKeyRepository_RevokeApiKey_Active_NoLongerFound(t *testing.T) {
+	newAuthRepoTest(t)
+	apiKey := ApiKey{Name: "importer"}
+	mocks.PanicOnErr(addApiKey(context.Background(), &apiKey, hashApiKey("gk_test")))
+
+	err := revokeApiKey(context.Background(), apiKey.Id)
+
+	mocks.AssertDefault(t, err)
+	_, err = getActiveApiKeyByHash(context.Background(), hashApiKey("gk_test"))
+	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
+	mocks.AssertEquals(t, revokeApiKey(context.Background(), apiKey.Id), utils.DataNotFoundErr)
+	keys, _ := getApiKeys(context.Background())
+	mocks.AssertCountEqual(t, keys, 1)
+	mocks.AssertEquals(t, keys[0].RevokedAt != nil, true)
+}
+
+func TestRoleRepository_SetRoleAssignment_Repeated_RoleReplaced(t *testing.T) {
+	newAuthRepoTest(t)
+
+	mocks.PanicOnErr(setRoleAssignment(context.Background(), &RoleAssignment{PrincipalKind: PrincipalUser, Subject: "user-1", Role: RoleEditor}))
+	err := setRoleAssignment(context.Background(), &RoleAssignment{PrincipalKind: PrincipalUser, Subject: "user-1", Role: RoleModerator})
+
+	mocks.AssertDefault(t, err)
+	role, err := getAssignedRole(context.Background(), PrincipalUser, "user-1")
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, role, RoleModerator)
+	assignments, _ := getRoleAssignments(context.Background())
+	mocks.AssertCountEqual(t, assignments, 1)
+}
+
+func TestRoleRepository_GetAssignedRole_Missing_ReturnsNotFound(t *testing.T) {
+	newAuthRepoTest(t)
+
+	_, err := getAssignedRole(context.Background(), PrincipalApiKey, "user-1")
+
+	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
+}
+
+func TestRoleRepository_DeleteRoleAssignment_Missing_ReturnsNotFound(t *testing.T) {
+	newAuthRepoTest(t)
+
+	err := deleteRoleAssignment(context.Background(), PrincipalUser, "user-1")
+
+	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
+}
@@ -0,0 +1,96 @@
+package auth
+
+type Role string
+
+const (
+	RoleViewer    Role = "viewer"
+	RoleEditor    Role = "editor"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+type Resource string
+
+const (
+	ResourceGame         Resource = "game"
+	ResourcePlatform     Resource = "platform"
+	ResourceRelease      Resource = "release"
+	ResourceReleaseGroup Resource = "releaseGroup"
+	ResourceApiKey       Resource = "apiKey"
+	ResourceRole         Resource = "role"
+	// ResourceProposal covers suggested changes, creating one submits it for review and updating one reviews it.
+	ResourceProposal Resource = "proposal"
+	// ResourceImport covers bulk changes of games and releases, creating one runs an import.
+	ResourceImport Resource = "import"
+	// ResourceDump covers archives of the whole catalogue, creating one exports the catalogue and updating one restores it.
+	ResourceDump Resource = "dump"
+	// ResourceAudit covers the audit log, reading it reveals who made each change.
+	ResourceAudit Resource = "audit"
+)
+
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+	// ActionRead is only required by resources exposing who made changes, everything else can be read anonymously.
+	ActionRead Action = "read"
+)
+
+// RoleAssignment grants a role to a principal, principals without one are treated as viewers.
+type RoleAssignment struct {
+	PrincipalKind PrincipalKind `json:"principalKind"`
+	Subject       string        `json:"subject"`
+	Role          Role          `json:"role"`
+}
+
+// permissions lists what each role is allowed to change, reading is always allowed unless ActionRead is required.
+// Roles are not hierarchical on purpose, so that each one can be adjusted independently.
+var permissions = map[Role]map[Resource][]Action{
+	RoleViewer: {
+		ResourceProposal: {ActionCreate},
+	},
+	RoleEditor: {
+		ResourceGame:         {ActionCreate, ActionUpdate},
+		ResourceRelease:      {ActionCreate, ActionUpdate},
+		ResourceReleaseGroup: {ActionCreate, ActionUpdate, ActionDelete},
+		ResourceProposal:     {ActionCreate},
+	},
+	RoleModerator: {
+		ResourceGame:         {ActionCreate, ActionUpdate, ActionDelete},
+		ResourcePlatform:     {ActionCreate, ActionUpdate},
+		ResourceRelease:      {ActionCreate, ActionUpdate, ActionDelete},
+		ResourceReleaseGroup: {ActionCreate, ActionUpdate, ActionDelete},
+		ResourceProposal:     {ActionCreate, ActionUpdate, ActionRead},
+		ResourceImport:       {ActionCreate},
+		ResourceAudit:        {ActionRead},
+	},
+	RoleAdmin: {
+		ResourceGame:         {ActionCreate, ActionUpdate, ActionDelete},
+		ResourcePlatform:     {ActionCreate, ActionUpdate, ActionDelete},
+		ResourceRelease:      {ActionCreate, ActionUpdate, ActionDelete},
+		ResourceReleaseGroup: {ActionCreate, ActionUpdate, ActionDelete},
+		ResourceApiKey:       {ActionCreate, ActionUpdate, ActionDelete},
+		ResourceRole:         {ActionCreate, ActionUpdate, ActionDelete},
+		ResourceProposal:     {ActionCreate, ActionUpdate, ActionRead},
+		ResourceImport:       {ActionCreate},
+		ResourceDump:         {ActionCreate, ActionUpdate},
+		ResourceAudit:        {ActionRead},
+	},
+}
+
+// Can checks whether the role allows performing an action on a resource type.
+func (r Role) Can(resource Resource, action Action) bool {
+	for _, allowed := range permissions[r][resource] {
+		if allowed == action {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Role) isValid() bool {
+	_, ok := permissions[r]
+	return ok
+}
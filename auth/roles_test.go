@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"github.com/Geepr/game/mocks"
+	"testing"
+)
+
+func TestRole_Can_MatrixRespected(t *testing.T) {
+	testData := []struct {
+		role     Role
+		resource Resource
+		action   Action
+		expected bool
+	}{
+		{RoleViewer, ResourceGame, ActionCreate, false},
+		{RoleEditor, ResourceRelease, ActionCreate, true},
+		{RoleEditor, ResourceRelease, ActionDelete, false},
+		{RoleEditor, ResourcePlatform, ActionDelete, false},
+		{RoleModerator, ResourcePlatform, ActionUpdate, true},
+		{RoleModerator, ResourcePlatform, ActionDelete, false},
+		{RoleModerator, ResourceRole, ActionUpdate, false},
+		{RoleAdmin, ResourcePlatform, ActionDelete, true},
+		{RoleAdmin, ResourceRole, ActionUpdate, true},
+		{RoleViewer, ResourceProposal, ActionCreate, true},
+		{RoleEditor, ResourceProposal, ActionUpdate, false},
+		{RoleModerator, ResourceProposal, ActionUpdate, true},
+		{RoleEditor, ResourceAudit, ActionRead, false},
+		{RoleModerator, ResourceAudit, ActionRead, true},
+		{RoleViewer, ResourceProposal, ActionRead, false},
+		{RoleAdmin, ResourceProposal, ActionRead, true},
+		{Role("unknown"), ResourceGame, ActionCreate, false},
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(string(currentData.role)+" "+string(currentData.action)+" "+string(currentData.resource), func(t *testing.T) {
+			mocks.AssertEquals(t, currentData.role.Can(currentData.resource, currentData.action), currentData.expected)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"github.com/Geepr/game/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	KeyAlgorithmMismatchErr = errors.New("token algorithm doesn't match the type of the signing key")
+)
+
+type tokenVerifier struct {
+	keys   keySet
+	parser *jwt.Parser
+}
+
+func newTokenVerifier(authConfig config.Auth) (*tokenVerifier, error) {
+	if authConfig.JwksFile == "" {
+		return nil, nil
+	}
+	keys, err := loadKeySet(authConfig.JwksFile)
+	if err != nil {
+		return nil, err
+	}
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if authConfig.Issuer != "" {
+		options = append(options, jwt.WithIssuer(authConfig.Issuer))
+	}
+	if authConfig.Audience != "" {
+		options = append(options, jwt.WithAudience(authConfig.Audience))
+	}
+	return &tokenVerifier{keys: keys, parser: jwt.NewParser(options...)}, nil
+}
+
+// verify checks the signature and standard claims of a token, returning the principal it was issued for.
+func (v *tokenVerifier) verify(token string) (*Principal, error) {
+	var claims struct {
+		jwt.RegisteredClaims
+		Name string `json:"name"`
+	}
+	_, err := v.parser.ParseWithClaims(token, &claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := v.keys.find(kid)
+		if err != nil {
+			return nil, err
+		}
+		// an RSA public key must never be accepted as an HMAC secret, or anyone knowing it could forge tokens
+		switch key.(type) {
+		case *rsa.PublicKey:
+			if token.Method != jwt.SigningMethodRS256 {
+				return nil, KeyAlgorithmMismatchErr
+			}
+		case []byte:
+			if token.Method != jwt.SigningMethodHS256 {
+				return nil, KeyAlgorithmMismatchErr
+			}
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("token is missing the sub claim")
+	}
+	return &Principal{Kind: PrincipalUser, Subject: claims.Subject, Name: claims.Name}, nil
+}
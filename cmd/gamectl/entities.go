@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/game"
+	"github.com/Geepr/game/platform"
+	"github.com/Geepr/game/release"
+	"github.com/gofrs/uuid"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// pageSize is used when listing entities, export goes through all pages.
+const pageSize = 100
+
+// entity adapts the exported functions of an entity package to the generic commands.
+type entity struct {
+	name string
+	// list returns a page of entities ordered by id, filtered by the query if it's not empty.
+	list   func(ctx context.Context, query string, pageIndex int) ([]any, int, error)
+	get    func(ctx context.Context, id uuid.UUID) (any, error)
+	change func(ctx context.Context, action audit.Action, id uuid.UUID, changes json.RawMessage, actor audit.Actor) (uuid.UUID, error)
+	// columns are printed by the list command, with values returned by row.
+	columns []string
+	row     func(item any) []string
+}
+
+var entities = []*entity{
+	{
+		name: "games",
+		list: func(ctx context.Context, query string, pageIndex int) ([]any, int, error) {
+			return toAny(game.List(ctx, query, pageIndex, pageSize, game.SortById))
+		},
+		get:     func(ctx context.Context, id uuid.UUID) (any, error) { return game.Get(ctx, id) },
+		change:  game.Change,
+		columns: []string{"ID", "TITLE", "ARCHIVED", "VERSION"},
+		row: func(item any) []string {
+			g := item.(*game.Game)
+			return []string{g.Id.String(), g.Title, fmt.Sprint(g.Archived), fmt.Sprint(g.Version)}
+		},
+	},
+	{
+		name: "platforms",
+		list: func(ctx context.Context, query string, pageIndex int) ([]any, int, error) {
+			return toAny(platform.List(ctx, query, pageIndex, pageSize, platform.SortById))
+		},
+		get:     func(ctx context.Context, id uuid.UUID) (any, error) { return platform.Get(ctx, id) },
+		change:  platform.Change,
+		columns: []string{"ID", "SHORT NAME", "NAME", "MANUFACTURER", "FAMILY", "VERSION"},
+		row: func(item any) []string {
+			p := item.(*platform.Platform)
+			manufacturer := "-"
+			if p.Manufacturer != nil {
+				manufacturer = *p.Manufacturer
+			}
+			return []string{p.Id.String(), p.ShortName, p.Name, manufacturer, string(p.Family), fmt.Sprint(p.Version)}
+		},
+	},
+	{
+		name: "releases",
+		list: func(ctx context.Context, query string, pageIndex int) ([]any, int, error) {
+			gameId := uuid.Nil
+			if query != "" {
+				var err error
+				if gameId, err = uuid.FromString(query); err != nil {
+					return nil, 0, fmt.Errorf("releases are listed by game id, %q is not a valid uuid", query)
+				}
+			}
+			return toAny(release.List(ctx, gameId, pageIndex, pageSize, release.SortById))
+		},
+		get:     func(ctx context.Context, id uuid.UUID) (any, error) { return release.Get(ctx, id) },
+		change:  release.Change,
+		columns: []string{"ID", "GAME ID", "TITLE", "RELEASE DATE", "PLATFORMS", "VERSION"},
+		row: func(item any) []string {
+			r := item.(*release.GameRelease)
+			title, releaseDate := "-", "-"
+			if r.TitleOverride != nil {
+				title = *r.TitleOverride
+			}
+			if r.ReleaseDate != nil {
+				releaseDate = r.ReleaseDate.Format("2006-01-02")
+			}
+			return []string{r.Id.String(), r.GameId.String(), title, releaseDate, fmt.Sprint(len(r.PlatformIds)), fmt.Sprint(r.Version)}
+		},
+	},
+}
+
+func toAny[T any](items []T, total int, err error) ([]any, int, error) {
+	if err != nil {
+		return nil, 0, err
+	}
+	result := make([]any, len(items))
+	for i, item := range items {
+		result[i] = item
+	}
+	return result, total, nil
+}
+
+func runEntityCommand(ctx context.Context, target *entity, args []string, input io.Reader, output io.Writer, actor audit.Actor) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "list":
+		return listEntities(ctx, target, strings.Join(args[1:], " "), output)
+	case "get":
+		id, err := parseId(args, 1)
+		if err != nil {
+			return err
+		}
+		return printEntity(ctx, target, id, output)
+	case "create":
+		changes, err := readChanges(args, 1, input)
+		if err != nil {
+			return err
+		}
+		id, err := target.change(ctx, audit.ActionInsert, uuid.Nil, changes, actor)
+		if err != nil {
+			return err
+		}
+		return printEntity(ctx, target, id, output)
+	case "update":
+		id, err := parseId(args, 1)
+		if err != nil {
+			return err
+		}
+		changes, err := readChanges(args, 2, input)
+		if err != nil {
+			return err
+		}
+		if _, err = target.change(ctx, audit.ActionUpdate, id, changes, actor); err != nil {
+			return err
+		}
+		return printEntity(ctx, target, id, output)
+	case "delete":
+		id, err := parseId(args, 1)
+		if err != nil {
+			return err
+		}
+		if _, err = target.change(ctx, audit.ActionDelete, id, nil, actor); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(output, "Deleted %s\n", id)
+		return err
+	default:
+		return fmt.Errorf("unknown %s command %q\n%s", target.name, args[0], usage)
+	}
+}
+
+func listEntities(ctx context.Context, target *entity, query string, output io.Writer) error {
+	items, total, err := target.list(ctx, query, 1)
+	if err != nil {
+		return err
+	}
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, strings.Join(target.columns, "\t"))
+	for _, item := range items {
+		_, _ = fmt.Fprintln(writer, strings.Join(target.row(item), "\t"))
+	}
+	if total > len(items) {
+		_, _ = fmt.Fprintf(writer, "... %d more, use export to get all of them\n", total-len(items))
+	}
+	return writer.Flush()
+}
+
+func printEntity(ctx context.Context, target *entity, id uuid.UUID, output io.Writer) error {
+	item, err := target.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(item)
+}
+
+func parseId(args []string, index int) (uuid.UUID, error) {
+	if len(args) <= index {
+		return uuid.Nil, errors.New(usage)
+	}
+	id, err := uuid.FromString(args[index])
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%q is not a valid uuid", args[index])
+	}
+	return id, nil
+}
+
+// readChanges returns the merge patch given as an argument, or read from the input when the argument is "-".
+func readChanges(args []string, index int, input io.Reader) (json.RawMessage, error) {
+	if len(args) <= index {
+		return nil, errors.New(usage)
+	}
+	if args[index] != "-" {
+		return json.RawMessage(args[index]), nil
+	}
+	return io.ReadAll(input)
+}
@@ -0,0 +1,148 @@
+// Command gamectl manages the catalogue directly in the database, for operations that have no api or need to bypass it.
+// It reads the same configuration as the service, with commands given after the flags:
+//
+//	gamectl [flags] games|platforms|releases list [QUERY]|get ID|create PATCH|update ID PATCH|delete ID
+//	gamectl [flags] export games|platforms|releases
+//	gamectl [flags] import games|platforms|releases [FILE]
+//	gamectl [flags] dump [FILE]|restore [FILE]
+//	gamectl [flags] maintenance reindex|recompute
+//	gamectl [flags] seed status|apply [--force]
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/config"
+	"github.com/KowalskiPiotr98/gotabase"
+	_ "github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"os"
+	"os/user"
+)
+
+const usage = `usage: gamectl [flags] COMMAND
+  games|platforms|releases list [QUERY]   list entities, releases are queried by game id
+  games|platforms|releases get ID         print a single entity
+  games|platforms|releases create PATCH   create an entity from a json merge patch, - reads it from stdin
+  games|platforms|releases update ID PATCH
+  games|platforms|releases delete ID
+  export games|platforms|releases         print all entities as json lines
+  import games|platforms|releases [FILE]  create entities from json lines of merge patches, read from stdin by default
+  dump [FILE]                             write a gzip archive of the whole catalogue, to stdout by default
+  restore [FILE]                          load an archive written by dump into an empty database, read from stdin by default
+  maintenance reindex                     rebuild indexes and statistics of catalogue tables
+  maintenance recompute                   regenerate normalised columns used for search
+  seed status                             compare embedded seed packs with the versions applied to the database
+  seed apply [--force]                    apply new seed pack versions, --force applies them again`
+
+func main() {
+	// standard output is left for command results, so that they can be piped
+	log.SetOutput(os.Stderr)
+	log.SetFormatter(&log.TextFormatter{FullTimestamp: true, DisableColors: true})
+
+	cfg, options, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		_, _ = fmt.Fprintln(os.Stderr, usage)
+		return
+	}
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %s", err.Error())
+	}
+	log.SetLevel(cfg.Log.LogLevel())
+	if len(options.Args) == 0 {
+		_, _ = fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err = gotabase.InitialiseConnection(cfg.Database.ConnectionString, "postgres"); err != nil {
+		log.Fatalf("Failed to connect to the database: %s", err.Error())
+	}
+	err = run(context.Background(), options.Args, os.Stdin, os.Stdout)
+	_ = gotabase.CloseConnection()
+	if err != nil {
+		log.Fatalf("Command failed: %s", err.Error())
+	}
+}
+
+func run(ctx context.Context, args []string, input io.Reader, output io.Writer) error {
+	switch args[0] {
+	case "export":
+		target, err := findEntity(args, 1)
+		if err != nil {
+			return err
+		}
+		return exportEntities(ctx, target, output)
+	case "import":
+		target, err := findEntity(args, 1)
+		if err != nil {
+			return err
+		}
+		if len(args) > 2 && args[2] != "-" {
+			file, err := os.Open(args[2])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			input = file
+		}
+		return importEntities(ctx, target, input, output, getActor())
+	case "dump":
+		if len(args) > 1 && args[1] != "-" {
+			file, err := os.Create(args[1])
+			if err != nil {
+				return err
+			}
+			if err = dumpCatalogue(ctx, file); err != nil {
+				_ = file.Close()
+				return err
+			}
+			return file.Close()
+		}
+		return dumpCatalogue(ctx, output)
+	case "restore":
+		if len(args) > 1 && args[1] != "-" {
+			file, err := os.Open(args[1])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			input = file
+		}
+		return restoreCatalogue(ctx, input, output)
+	case "maintenance":
+		return runMaintenance(ctx, args[1:], output)
+	case "seed":
+		return runSeed(ctx, args[1:], output, getActor())
+	default:
+		target, err := findEntity(args, 0)
+		if err != nil {
+			return err
+		}
+		return runEntityCommand(ctx, target, args[1:], input, output, getActor())
+	}
+}
+
+// getActor attributes changes in the audit log to the user running the tool.
+func getActor() audit.Actor {
+	subject := "gamectl"
+	if current, err := user.Current(); err == nil {
+		subject += ":" + current.Username
+	}
+	return audit.Actor{Kind: audit.System.Kind, Subject: &subject}
+}
+
+func findEntity(args []string, index int) (*entity, error) {
+	if len(args) <= index {
+		return nil, errors.New(usage)
+	}
+	for _, e := range entities {
+		if e.name == args[index] {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown command %q\n%s", args[index], usage)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/mocks"
+	"strings"
+	"testing"
+)
+
+func TestFindEntity_KnownName_Found(t *testing.T) {
+	target, err := findEntity([]string{"export", "platforms"}, 1)
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, target.name, "platforms")
+}
+
+func TestRun_InvalidArguments_ReturnsErr(t *testing.T) {
+	testData := [][]string{
+		{"export"},
+		{"export", "studios"},
+		{"games"},
+		{"games", "rename"},
+		{"games", "get", "not-an-id"},
+		{"releases", "update", "1d6c8a57-7a57-4d8c-9c2c-2dcbd9b0b1a1"},
+		{"maintenance", "vacuum"},
+		{"restore", "/nonexistent/catalogue.json.gz"},
+		{"seed", "apply", "--all"},
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(strings.Join(currentData, " "), func(t *testing.T) {
+			err := run(context.Background(), currentData, strings.NewReader(""), &bytes.Buffer{})
+
+			mocks.AssertEquals(t, err != nil, true)
+		})
+	}
+}
+
+func TestReadChanges_Dash_ReadFromInput(t *testing.T) {
+	changes, err := readChanges([]string{"create", "-"}, 1, strings.NewReader(`{"title": "aaa"}`))
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, string(changes), `{"title": "aaa"}`)
+}
+
+func TestGetActor_Always_SystemKind(t *testing.T) {
+	actor := getActor()
+
+	mocks.AssertEquals(t, actor.Kind, audit.System.Kind)
+	mocks.AssertEquals(t, strings.HasPrefix(*actor.Subject, "gamectl"), true)
+}
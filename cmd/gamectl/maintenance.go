@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Geepr/game/maintenance"
+	"io"
+	"sort"
+)
+
+func runMaintenance(ctx context.Context, args []string, output io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "reindex":
+		return maintenance.Reindex(ctx)
+	case "recompute":
+		rewritten, err := maintenance.Recompute(ctx)
+		if err != nil {
+			return err
+		}
+		tables := make([]string, 0, len(rewritten))
+		for table := range rewritten {
+			tables = append(tables, table)
+		}
+		sort.Strings(tables)
+		for _, table := range tables {
+			if _, err = fmt.Fprintf(output, "%s\t%d rows\n", table, rewritten[table]); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown maintenance command %q\n%s", args[0], usage)
+	}
+}
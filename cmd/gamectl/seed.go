@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/seed"
+	"io"
+	"text/tabwriter"
+)
+
+func runSeed(ctx context.Context, args []string, output io.Writer, actor audit.Actor) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "status":
+		statuses, err := seed.GetStatus(ctx)
+		if err != nil {
+			return err
+		}
+		writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(writer, "PACK\tVERSION\tAPPLIED\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedVersion, appliedAt := "-", "-"
+			if status.AppliedAt != nil {
+				appliedVersion, appliedAt = fmt.Sprint(status.AppliedVersion), status.AppliedAt.Format("2006-01-02")
+			}
+			_, _ = fmt.Fprintf(writer, "%s\t%d\t%s\t%s\n", status.Pack, status.Version, appliedVersion, appliedAt)
+		}
+		return writer.Flush()
+	case "apply":
+		// forcing applies packs again, which brings back seeded values of platforms changed since
+		force := len(args) > 1 && args[1] == "--force"
+		if len(args) > 2 || (len(args) == 2 && !force) {
+			return errors.New(usage)
+		}
+		results, err := seed.Apply(ctx, force, actor)
+		if err != nil {
+			return err
+		}
+		for _, result := range results {
+			if _, err = fmt.Fprintln(output, result); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown seed command %q\n%s", args[0], usage)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/dump"
+	"github.com/gofrs/uuid"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"strings"
+)
+
+// exportEntities writes all entities as json lines, in the same format as returned by the api.
+func exportEntities(ctx context.Context, target *entity, output io.Writer) error {
+	encoder := json.NewEncoder(output)
+	for pageIndex := 1; ; pageIndex++ {
+		items, _, err := target.list(ctx, "", pageIndex)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err = encoder.Encode(item); err != nil {
+				return err
+			}
+		}
+		if len(items) < pageSize {
+			return nil
+		}
+	}
+}
+
+// importEntities creates an entity from each line of the input, holding a merge patch like the create command.
+// Lines that fail are reported and skipped, so that the rest of the file is still imported.
+func importEntities(ctx context.Context, target *entity, input io.Reader, output io.Writer, actor audit.Actor) error {
+	scanner := bufio.NewScanner(input)
+	// lines are single entities, but descriptions can make them longer than the default limit
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	created, failed := 0, 0
+	for line := 1; scanner.Scan(); line++ {
+		changes := bytes.TrimSpace(scanner.Bytes())
+		if len(changes) == 0 {
+			continue
+		}
+		id, err := target.change(ctx, audit.ActionInsert, uuid.Nil, bytes.Clone(changes), actor)
+		if err != nil {
+			log.Warnf("Line %d was not imported: %s", line, err.Error())
+			failed++
+			continue
+		}
+		_, _ = fmt.Fprintf(output, "%d\t%s\n", line, id)
+		created++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	log.Infof("Imported %d %s, %d failed", created, target.name, failed)
+	if failed != 0 {
+		return fmt.Errorf("%d of %d lines failed to import", failed, created+failed)
+	}
+	return nil
+}
+
+// dumpCatalogue writes the archive to the output, reporting the number of dumped rows in the log, as the output is usually redirected.
+func dumpCatalogue(ctx context.Context, output io.Writer) error {
+	counts, err := dump.Dump(ctx, output)
+	if err != nil {
+		return err
+	}
+	log.Infof("Dumped %s", formatCounts(counts))
+	return nil
+}
+
+func restoreCatalogue(ctx context.Context, input io.Reader, output io.Writer) error {
+	counts, err := dump.Restore(ctx, input)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(output, "Restored %s\n", formatCounts(counts))
+	return err
+}
+
+// formatCounts lists the number of rows of each section, in the order they are stored in the archive.
+func formatCounts(counts dump.Counts) string {
+	parts := make([]string, 0, len(counts))
+	for _, name := range dump.Sections() {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[name], name))
+	}
+	return strings.Join(parts, ", ")
+}
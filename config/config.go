@@ -0,0 +1,179 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+// Config contains all settings of the service.
+// Values are resolved in the following order, with later sources overriding earlier ones: defaults, config file, environment variables, flags.
+type Config struct {
+	Database Database `yaml:"database" toml:"database"`
+	Server   Server   `yaml:"server" toml:"server"`
+	Log      Log      `yaml:"log" toml:"log"`
+	Auth     Auth     `yaml:"auth" toml:"auth"`
+	Tracing  Tracing  `yaml:"tracing" toml:"tracing"`
+}
+
+type Database struct {
+	// ConnectionString is passed directly to the postgres driver, both key=value and url formats are accepted.
+	ConnectionString string `yaml:"connectionString" toml:"connectionString"`
+	// AutoMigrate applies pending migrations when the server starts.
+	// Disable it to run migrations separately, with the migrate command, before rolling out a new version.
+	AutoMigrate bool `yaml:"autoMigrate" toml:"autoMigrate"`
+	// Seed applies new versions of the embedded seed packs, like well-known platforms, whenever migrations are applied.
+	Seed bool `yaml:"seed" toml:"seed"`
+}
+
+type Server struct {
+	// Address is the host:port pair the http server listens on.
+	Address string `yaml:"address" toml:"address"`
+	// BasePath is prepended to all routes, useful when the service is hosted behind a reverse proxy under a sub path.
+	BasePath string `yaml:"basePath" toml:"basePath"`
+	// TrustedProxies is a list of addresses or CIDR ranges allowed to set forwarding headers. Empty list trusts no one.
+	TrustedProxies []string `yaml:"trustedProxies" toml:"trustedProxies"`
+	// ReadTimeout, WriteTimeout and IdleTimeout limit the duration of reading a request, writing a response and keeping an idle connection open.
+	// Values are durations like "30s" or "2m", zero disables the limit.
+	ReadTimeout  string `yaml:"readTimeout" toml:"readTimeout"`
+	WriteTimeout string `yaml:"writeTimeout" toml:"writeTimeout"`
+	IdleTimeout  string `yaml:"idleTimeout" toml:"idleTimeout"`
+	// ShutdownTimeout is how long in-flight requests are waited for on shutdown, before their connections are closed. Zero closes them right away.
+	ShutdownTimeout string `yaml:"shutdownTimeout" toml:"shutdownTimeout"`
+}
+
+// Timeouts holds the parsed durations of the server settings.
+type Timeouts struct {
+	Read     time.Duration
+	Write    time.Duration
+	Idle     time.Duration
+	Shutdown time.Duration
+}
+
+type Log struct {
+	// Level is one of the logrus level names (panic, fatal, error, warn, info, debug, trace).
+	Level string `yaml:"level" toml:"level"`
+	// Format is either text, for reading logs directly, or json, for log collectors.
+	Format string `yaml:"format" toml:"format"`
+	// SkipPaths lists request paths which are not logged by the request logger, like health checks.
+	SkipPaths []string `yaml:"skipPaths" toml:"skipPaths"`
+}
+
+type Auth struct {
+	// JwksFile is a path to a local JSON Web Key Set used to verify bearer tokens.
+	// Both RSA public keys (RS256) and symmetric keys (HS256) are supported. Bearer tokens are rejected when not set.
+	JwksFile string `yaml:"jwksFile" toml:"jwksFile"`
+	// Issuer and Audience, when set, must match the iss and aud claims of bearer tokens.
+	Issuer   string `yaml:"issuer" toml:"issuer"`
+	Audience string `yaml:"audience" toml:"audience"`
+	// AdminSubjects are token subjects always granted the admin role, regardless of role assignments.
+	// Meant for bootstrapping a fresh installation, before any roles are assigned.
+	AdminSubjects []string `yaml:"adminSubjects" toml:"adminSubjects"`
+}
+
+type Tracing struct {
+	// Exporter is where spans of requests and database queries are sent: none, otlp or stdout.
+	Exporter string `yaml:"exporter" toml:"exporter"`
+	// Endpoint is the url of an OTLP http receiver, like http://localhost:4318, used by the otlp exporter.
+	// When empty, the standard OTEL_EXPORTER_OTLP_ENDPOINT variable is used instead.
+	Endpoint string `yaml:"endpoint" toml:"endpoint"`
+}
+
+// Default returns the configuration used when nothing else is provided, matching a local development setup.
+func Default() *Config {
+	return &Config{
+		Database: Database{
+			ConnectionString: "user=postgres dbname=geepr password=postgres sslmode=disable",
+			AutoMigrate:      true,
+			Seed:             true,
+		},
+		Server: Server{
+			Address:         "localhost:5500",
+			BasePath:        "",
+			TrustedProxies:  []string{},
+			ReadTimeout:     "30s",
+			WriteTimeout:    "60s",
+			IdleTimeout:     "120s",
+			ShutdownTimeout: "30s",
+		},
+		Log: Log{
+			Level:     "info",
+			Format:    "text",
+			SkipPaths: []string{},
+		},
+		Auth: Auth{
+			AdminSubjects: []string{},
+		},
+		Tracing: Tracing{
+			Exporter: "none",
+		},
+	}
+}
+
+// Validate checks that all values are usable, returning all problems found at once.
+func (c *Config) Validate() error {
+	var errs []error
+	if strings.TrimSpace(c.Database.ConnectionString) == "" {
+		errs = append(errs, errors.New("database connection string must not be empty"))
+	}
+	if !strings.Contains(c.Server.Address, ":") {
+		errs = append(errs, fmt.Errorf("server address %q must be in host:port format", c.Server.Address))
+	}
+	if c.Server.BasePath != "" && (!strings.HasPrefix(c.Server.BasePath, "/") || strings.HasSuffix(c.Server.BasePath, "/")) {
+		errs = append(errs, fmt.Errorf("base path %q must start with a slash and must not end with one", c.Server.BasePath))
+	}
+	for _, timeout := range []struct{ name, value string }{
+		{"read", c.Server.ReadTimeout},
+		{"write", c.Server.WriteTimeout},
+		{"idle", c.Server.IdleTimeout},
+		{"shutdown", c.Server.ShutdownTimeout},
+	} {
+		if duration, err := time.ParseDuration(timeout.value); err != nil || duration < 0 {
+			errs = append(errs, fmt.Errorf("server %s timeout %q must be a non-negative duration, like 30s", timeout.name, timeout.value))
+		}
+	}
+	if _, err := log.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log level %q is not valid", c.Log.Level))
+	}
+	if c.Log.Format != "text" && c.Log.Format != "json" {
+		errs = append(errs, fmt.Errorf("log format %q must be either text or json", c.Log.Format))
+	}
+	if c.Tracing.Exporter != "none" && c.Tracing.Exporter != "otlp" && c.Tracing.Exporter != "stdout" {
+		errs = append(errs, fmt.Errorf("tracing exporter %q must be one of none, otlp or stdout", c.Tracing.Exporter))
+	}
+	return errors.Join(errs...)
+}
+
+// Timeouts returns the parsed server timeouts, should only be called on validated configs.
+func (s Server) Timeouts() Timeouts {
+	return Timeouts{
+		Read:     parseDurationOrZero(s.ReadTimeout),
+		Write:    parseDurationOrZero(s.WriteTimeout),
+		Idle:     parseDurationOrZero(s.IdleTimeout),
+		Shutdown: parseDurationOrZero(s.ShutdownTimeout),
+	}
+}
+
+func parseDurationOrZero(value string) time.Duration {
+	duration, _ := time.ParseDuration(value)
+	return duration
+}
+
+// LogLevel returns the parsed log level, should only be called on validated configs.
+func (l Log) LogLevel() log.Level {
+	level, err := log.ParseLevel(l.Level)
+	if err != nil {
+		return log.InfoLevel
+	}
+	return level
+}
+
+// Formatter returns the log formatter matching the format, should only be called on validated configs.
+func (l Log) Formatter() log.Formatter {
+	if l.Format == "json" {
+		return &log.JSONFormatter{}
+	}
+	return &log.TextFormatter{FullTimestamp: true, DisableColors: true}
+}
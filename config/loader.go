@@ -0,0 +1,177 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const envPrefix = "GEEPR_"
+
+// Options are the results of parsing the command line, besides the configuration itself.
+type Options struct {
+	// PrintConfig requests that the resolved configuration is printed out instead of starting the service.
+	PrintConfig bool
+	// Args are the arguments left after flags, starting with the name of a command like migrate.
+	Args []string
+}
+
+// Load resolves the configuration from all sources, args should not include the program name.
+// The config file is taken from the --config flag or the GEEPR_CONFIG variable; when neither is set, no file is read.
+func Load(args []string) (*Config, *Options, error) {
+	return load(args, os.LookupEnv)
+}
+
+func load(args []string, lookupEnv func(string) (string, bool)) (*Config, *Options, error) {
+	config := Default()
+	options := &Options{}
+
+	flags := flag.NewFlagSet("game", flag.ContinueOnError)
+	configPath := flags.String("config", "", "path to a yaml or toml config file")
+	flags.BoolVar(&options.PrintConfig, "print-config", false, "print the resolved configuration with secrets redacted and exit")
+	overrides := newFlagOverrides(flags)
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	options.Args = flags.Args()
+
+	if *configPath == "" {
+		*configPath, _ = lookupEnv(envPrefix + "CONFIG")
+	}
+	if *configPath != "" {
+		if err := loadFile(*configPath, config); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := loadEnv(lookupEnv, config); err != nil {
+		return nil, nil, err
+	}
+	if err := overrides.apply(flags, config); err != nil {
+		return nil, nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return config, options, nil
+}
+
+func loadFile(path string, config *Config) error {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(contents, config)
+	case ".toml":
+		err = toml.Unmarshal(contents, config)
+	default:
+		return fmt.Errorf("config file %s must have a yaml, yml, or toml extension", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// setting binds a single configuration value to its environment variable and flag names.
+type setting struct {
+	env     string
+	flag    string
+	usage   string
+	value   func(config *Config) *string
+	list    func(config *Config) *[]string
+	boolean func(config *Config) *bool
+}
+
+var settings = []setting{
+	{env: "DATABASE_CONNECTION_STRING", flag: "db", usage: "postgres connection string", value: func(c *Config) *string { return &c.Database.ConnectionString }},
+	{env: "DATABASE_AUTO_MIGRATE", flag: "auto-migrate", usage: "apply pending migrations on start, true or false", boolean: func(c *Config) *bool { return &c.Database.AutoMigrate }},
+	{env: "DATABASE_SEED", flag: "seed", usage: "apply new seed pack versions with migrations, true or false", boolean: func(c *Config) *bool { return &c.Database.Seed }},
+	{env: "SERVER_ADDRESS", flag: "address", usage: "address to listen on, in host:port format", value: func(c *Config) *string { return &c.Server.Address }},
+	{env: "SERVER_BASE_PATH", flag: "base-path", usage: "path prefix of all routes", value: func(c *Config) *string { return &c.Server.BasePath }},
+	{env: "SERVER_TRUSTED_PROXIES", flag: "trusted-proxies", usage: "comma separated list of trusted proxy addresses", list: func(c *Config) *[]string { return &c.Server.TrustedProxies }},
+	{env: "SERVER_READ_TIMEOUT", flag: "read-timeout", usage: "maximum duration of reading a request", value: func(c *Config) *string { return &c.Server.ReadTimeout }},
+	{env: "SERVER_WRITE_TIMEOUT", flag: "write-timeout", usage: "maximum duration of writing a response", value: func(c *Config) *string { return &c.Server.WriteTimeout }},
+	{env: "SERVER_IDLE_TIMEOUT", flag: "idle-timeout", usage: "maximum duration of keeping an idle connection open", value: func(c *Config) *string { return &c.Server.IdleTimeout }},
+	{env: "SERVER_SHUTDOWN_TIMEOUT", flag: "shutdown-timeout", usage: "how long in-flight requests are waited for on shutdown", value: func(c *Config) *string { return &c.Server.ShutdownTimeout }},
+	{env: "LOG_LEVEL", flag: "log-level", usage: "minimal level of logged messages", value: func(c *Config) *string { return &c.Log.Level }},
+	{env: "LOG_FORMAT", flag: "log-format", usage: "format of log messages, either text or json", value: func(c *Config) *string { return &c.Log.Format }},
+	{env: "AUTH_JWKS_FILE", flag: "jwks-file", usage: "path to a JSON Web Key Set used to verify bearer tokens", value: func(c *Config) *string { return &c.Auth.JwksFile }},
+	{env: "AUTH_ISSUER", flag: "auth-issuer", usage: "required issuer of bearer tokens", value: func(c *Config) *string { return &c.Auth.Issuer }},
+	{env: "AUTH_AUDIENCE", flag: "auth-audience", usage: "required audience of bearer tokens", value: func(c *Config) *string { return &c.Auth.Audience }},
+	{env: "AUTH_ADMIN_SUBJECTS", flag: "auth-admin-subjects", usage: "comma separated list of token subjects always granted the admin role", list: func(c *Config) *[]string { return &c.Auth.AdminSubjects }},
+	{env: "TRACING_EXPORTER", flag: "tracing-exporter", usage: "where to send traces: none, otlp or stdout", value: func(c *Config) *string { return &c.Tracing.Exporter }},
+	{env: "TRACING_ENDPOINT", flag: "tracing-endpoint", usage: "url of the OTLP http trace receiver", value: func(c *Config) *string { return &c.Tracing.Endpoint }},
+	{env: "LOG_SKIP_PATHS", flag: "log-skip-paths", usage: "comma separated list of request paths excluded from request logs", list: func(c *Config) *[]string { return &c.Log.SkipPaths }},
+}
+
+func loadEnv(lookupEnv func(string) (string, bool), config *Config) error {
+	for _, s := range settings {
+		value, ok := lookupEnv(envPrefix + s.env)
+		if !ok {
+			continue
+		}
+		if err := s.set(config, value); err != nil {
+			return fmt.Errorf("invalid value of %s%s: %w", envPrefix, s.env, err)
+		}
+	}
+	return nil
+}
+
+type flagOverrides map[string]*string
+
+func newFlagOverrides(flags *flag.FlagSet) flagOverrides {
+	overrides := make(flagOverrides, len(settings))
+	for _, s := range settings {
+		overrides[s.flag] = flags.String(s.flag, "", fmt.Sprintf("%s (env: %s%s)", s.usage, envPrefix, s.env))
+	}
+	return overrides
+}
+
+// apply only sets values of flags that were explicitly passed, so that empty flag defaults never override other sources.
+func (o flagOverrides) apply(flags *flag.FlagSet, config *Config) error {
+	var err error
+	flags.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && err == nil {
+				if setErr := s.set(config, *o[f.Name]); setErr != nil {
+					err = fmt.Errorf("invalid value of --%s: %w", f.Name, setErr)
+				}
+			}
+		}
+	})
+	return err
+}
+
+func (s setting) set(config *Config, value string) error {
+	switch {
+	case s.list != nil:
+		*s.list(config) = splitList(value)
+	case s.boolean != nil:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*s.boolean(config) = parsed
+	default:
+		*s.value(config) = value
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	result := make([]string, 0)
+	for _, part := range strings.Split(value, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
@@ -11,6 +11,33 @@ var (
 	migrationFiles embed.FS
 )
 
-func RunMigrations(connector gotabase.Connector) error {
-	return migrations.Migrate(connector, migrationFiles)
+// RunMigrations applies all migrations not applied yet, refusing to run if applied migration files were modified since.
+func RunMigrations() error {
+	migrator, err := NewMigrator()
+	if err != nil {
+		return err
+	}
+	return migrator.Up()
+}
+
+// GetSchemaVersions returns the id of the latest migration applied to the database, and of the latest one known to this build.
+// The database is up-to-date when both are equal.
+func GetSchemaVersions(connector gotabase.Connector) (applied int, latest int, err error) {
+	result, err := connector.QueryRow(migrations.LatestMigrationSelectorSql)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err = result.Scan(&applied); err != nil {
+		return 0, 0, err
+	}
+	latest, err = getLatestMigration()
+	return applied, latest, err
+}
+
+func getLatestMigration() (int, error) {
+	loaded, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return 0, err
+	}
+	return len(loaded) - 1, nil
 }
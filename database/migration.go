@@ -0,0 +1,59 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration is a single schema change, with sql applying it and sql reverting it.
+// Up migrations are stored as sql/N.sql, with the matching down migrations as sql/down/N.sql.
+type Migration struct {
+	Id   int
+	Up   string
+	Down string
+}
+
+// Checksum identifies the contents of the up migration, so that files edited after being applied can be detected.
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+// loadMigrations reads all migrations ordered by id, which must be contiguous starting from 0, each with a down migration.
+func loadMigrations(files fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]Migration, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".sql"))
+		if err != nil {
+			return nil, fmt.Errorf("migration file name %s is not a number: %w", entry.Name(), err)
+		}
+		up, err := fs.ReadFile(files, fmt.Sprintf("sql/%d.sql", id))
+		if err != nil {
+			return nil, err
+		}
+		down, err := fs.ReadFile(files, fmt.Sprintf("sql/down/%d.sql", id))
+		if err != nil {
+			return nil, fmt.Errorf("migration %d has no down migration: %w", id, err)
+		}
+		migrations = append(migrations, Migration{Id: id, Up: string(up), Down: string(down)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Id < migrations[j].Id })
+	for i, migration := range migrations {
+		if migration.Id != i {
+			return nil, fmt.Errorf("migration %d is missing", i)
+		}
+	}
+	return migrations, nil
+}
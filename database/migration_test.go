@@ -0,0 +1,109 @@
+package database
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// assertions from mocks can't be used here, as that package imports this one to set up test databases
+
+func TestLoadMigrations_EmbeddedFiles_AllReversible(t *testing.T) {
+	loaded, err := loadMigrations(migrationFiles)
+
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %s", err.Error())
+	}
+	for i, migration := range loaded {
+		if migration.Id != i || strings.TrimSpace(migration.Down) == "" {
+			t.Fatalf("Expected migration %d to have a down migration", i)
+		}
+	}
+}
+
+func TestLoadMigrations_InvalidFiles_ReturnsErr(t *testing.T) {
+	testData := map[string]fstest.MapFS{
+		"missing down": {
+			"sql/0.sql": {Data: []byte("create table a ();")},
+		},
+		"gap in ids": {
+			"sql/0.sql":      {Data: []byte("create table a ();")},
+			"sql/down/0.sql": {Data: []byte("drop table a;")},
+			"sql/2.sql":      {Data: []byte("create table b ();")},
+			"sql/down/2.sql": {Data: []byte("drop table b;")},
+		},
+		"not a number": {
+			"sql/first.sql": {Data: []byte("create table a ();")},
+		},
+	}
+
+	for name, data := range testData {
+		currentData := data
+		t.Run(name, func(t *testing.T) {
+			_, err := loadMigrations(currentData)
+
+			if err == nil {
+				t.Fatalf("Expected an error")
+			}
+		})
+	}
+}
+
+func TestMigrationChecksum_UpChanged_ChecksumChanged(t *testing.T) {
+	original := Migration{Id: 1, Up: "create table a ();", Down: "drop table a;"}
+	edited := Migration{Id: 1, Up: "create table a (id int);", Down: original.Down}
+
+	if original.Checksum() != (Migration{Id: 1, Up: original.Up}).Checksum() {
+		t.Fatalf("Expected checksum to only depend on the up migration")
+	}
+	if original.Checksum() == edited.Checksum() {
+		t.Fatalf("Expected checksum of edited migration to change")
+	}
+}
+
+func TestPlan_TargetVersion_StepsInExecutionOrder(t *testing.T) {
+	migrations := []Migration{{Id: 0}, {Id: 1}, {Id: 2}, {Id: 3}}
+	testData := []struct {
+		name     string
+		current  int
+		target   int
+		expected string
+	}{
+		{"empty database up", -1, 3, "+0 +1 +2 +3"},
+		{"partial up", 1, 2, "+2"},
+		{"down", 3, 1, "-3 -2"},
+		{"down all", 1, -1, "-1 -0"},
+		{"already there", 2, 2, ""},
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(currentData.name, func(t *testing.T) {
+			steps := plan(migrations, currentData.current, currentData.target)
+
+			described := make([]string, len(steps))
+			for i, s := range steps {
+				described[i] = "-"
+				if s.up {
+					described[i] = "+"
+				}
+				described[i] += strconv.Itoa(s.migration.Id)
+			}
+			if actual := strings.Join(described, " "); actual != currentData.expected {
+				t.Fatalf("Expected steps %q, got %q", currentData.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPrintSteps_DownStep_PrintsDownSql(t *testing.T) {
+	var output bytes.Buffer
+
+	err := printSteps(&output, []step{{migration: Migration{Id: 4, Up: "create table a ();", Down: "drop table a;\n"}}})
+
+	if expected := "-- migration 4 (down)\ndrop table a;\n\n"; err != nil || output.String() != expected {
+		t.Fatalf("Expected %q, got %q", expected, output.String())
+	}
+}
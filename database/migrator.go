@@ -0,0 +1,281 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KowalskiPiotr98/gotabase/migrations"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"strings"
+	"time"
+)
+
+// ChecksumMismatchErr is returned when applied migration files were edited since, as the schema may no longer match them.
+var ChecksumMismatchErr = errors.New("applied migration files were modified")
+
+// checksumTableSql creates the table of migration checksums, which is managed outside the migrations so that all of them can be tracked.
+const checksumTableSql = "create table if not exists migration_checksums (id integer constraint pk_migration_checksums primary key, checksum char(64) not null)"
+
+// MigrationStatus describes a single migration known to this build.
+type MigrationStatus struct {
+	Id        int
+	Applied   bool
+	AppliedAt *time.Time
+	// Modified is set for applied migrations whose file no longer matches the recorded checksum.
+	Modified bool
+}
+
+// step applies or reverts a single migration.
+type step struct {
+	migration Migration
+	up        bool
+}
+
+// Migrator applies and reverts the embedded migrations.
+type Migrator struct {
+	migrations []Migration
+	// DryRun, when set, receives the sql of all planned steps instead of it being executed.
+	DryRun io.Writer
+}
+
+// appliedMigration is a row of the migrations table, with the checksum recorded for it, if any.
+type appliedMigration struct {
+	appliedAt time.Time
+	checksum  string
+}
+
+func NewMigrator() (*Migrator, error) {
+	loaded, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{migrations: loaded}, nil
+}
+
+// Latest returns the id of the latest migration known to this build.
+func (m *Migrator) Latest() int {
+	return len(m.migrations) - 1
+}
+
+// Up applies all migrations not applied yet.
+func (m *Migrator) Up() error {
+	return m.To(m.Latest())
+}
+
+// Down reverts the given number of the latest applied migrations.
+func (m *Migrator) Down(count int) error {
+	if count < 1 {
+		return fmt.Errorf("number of migrations to revert must be positive, got %d", count)
+	}
+	applied, err := getAppliedMigrations()
+	if err != nil {
+		return err
+	}
+	current := getSchemaVersion(applied)
+	if count > current+1 {
+		return fmt.Errorf("cannot revert %d migrations, only %d are applied", count, current+1)
+	}
+	return m.to(applied, current-count)
+}
+
+// To applies or reverts migrations until the schema is at the given version, -1 reverts all of them.
+func (m *Migrator) To(version int) error {
+	applied, err := getAppliedMigrations()
+	if err != nil {
+		return err
+	}
+	return m.to(applied, version)
+}
+
+// Status lists all migrations of this build, along with whether they are applied to the database.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := getAppliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = MigrationStatus{Id: migration.Id}
+		if row, ok := applied[migration.Id]; ok {
+			statuses[i].Applied = true
+			statuses[i].AppliedAt = &row.appliedAt
+			statuses[i].Modified = row.checksum != "" && row.checksum != migration.Checksum()
+		}
+	}
+	return statuses, nil
+}
+
+func (m *Migrator) to(applied map[int]appliedMigration, version int) error {
+	if version < -1 || version > m.Latest() {
+		return fmt.Errorf("schema version %d is not between -1 and the latest migration %d", version, m.Latest())
+	}
+	current := getSchemaVersion(applied)
+	if current > m.Latest() {
+		return fmt.Errorf("database schema version %d is newer than the latest migration %d of this build", current, m.Latest())
+	}
+	if err := m.verify(applied); err != nil {
+		return err
+	}
+
+	steps := plan(m.migrations, current, version)
+	if m.DryRun != nil {
+		return printSteps(m.DryRun, steps)
+	}
+	if err := m.recordMissingChecksums(applied); err != nil {
+		return err
+	}
+	for _, s := range steps {
+		if err := runStep(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verify checks that no applied migration was edited since, as reverting or building upon it could break the schema.
+func (m *Migrator) verify(applied map[int]appliedMigration) error {
+	modified := make([]string, 0)
+	for _, migration := range m.migrations {
+		if row, ok := applied[migration.Id]; ok && row.checksum != "" && row.checksum != migration.Checksum() {
+			modified = append(modified, fmt.Sprint(migration.Id))
+		}
+	}
+	if len(modified) != 0 {
+		return fmt.Errorf("%w: %s", ChecksumMismatchErr, strings.Join(modified, ", "))
+	}
+	return nil
+}
+
+// recordMissingChecksums stores checksums of migrations applied before checksums were tracked, trusting that their files were not edited.
+func (m *Migrator) recordMissingChecksums(applied map[int]appliedMigration) error {
+	if len(applied) == 0 {
+		return nil
+	}
+	if _, err := getConnector().Exec(checksumTableSql); err != nil {
+		return err
+	}
+	for _, migration := range m.migrations {
+		if row, ok := applied[migration.Id]; !ok || row.checksum != "" {
+			continue
+		}
+		log.Infof("Recording checksum of migration %d, applied before checksums were tracked", migration.Id)
+		if _, err := getConnector().Exec("insert into migration_checksums (id, checksum) values ($1, $2)", migration.Id, migration.Checksum()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// plan lists the steps moving the schema from the current version to the target one, in order of execution.
+func plan(migrations []Migration, current int, target int) []step {
+	steps := make([]step, 0)
+	for id := current + 1; id <= target; id++ {
+		steps = append(steps, step{migration: migrations[id], up: true})
+	}
+	for id := current; id > target; id-- {
+		steps = append(steps, step{migration: migrations[id], up: false})
+	}
+	return steps
+}
+
+func printSteps(writer io.Writer, steps []step) error {
+	for _, s := range steps {
+		direction, sql := "up", s.migration.Up
+		if !s.up {
+			direction, sql = "down", s.migration.Down
+		}
+		if _, err := fmt.Fprintf(writer, "-- migration %d (%s)\n%s\n\n", s.migration.Id, direction, strings.TrimSpace(sql)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runStep applies or reverts a single migration in a transaction, keeping the migration tables in sync with the schema.
+func runStep(s step) error {
+	transaction, err := getTransaction()
+	if err != nil {
+		return err
+	}
+	defer transaction.Rollback()
+
+	if s.up {
+		log.Infof("Applying migration %d", s.migration.Id)
+		if _, err = transaction.Exec(s.migration.Up); err != nil {
+			return fmt.Errorf("failed to apply migration %d: %w", s.migration.Id, err)
+		}
+		if _, err = transaction.Exec("insert into migrations (id) values ($1)", s.migration.Id); err != nil {
+			return err
+		}
+		if _, err = transaction.Exec(checksumTableSql); err != nil {
+			return err
+		}
+		if _, err = transaction.Exec("insert into migration_checksums (id, checksum) values ($1, $2) on conflict (id) do update set checksum = excluded.checksum", s.migration.Id, s.migration.Checksum()); err != nil {
+			return err
+		}
+	} else {
+		log.Infof("Reverting migration %d", s.migration.Id)
+		// rows are removed first, as reverting the initial migration drops the migrations table
+		if _, err = transaction.Exec("delete from migrations where id = $1", s.migration.Id); err != nil {
+			return err
+		}
+		if _, err = transaction.Exec("delete from migration_checksums where id = $1", s.migration.Id); err != nil {
+			return err
+		}
+		if _, err = transaction.Exec(s.migration.Down); err != nil {
+			return fmt.Errorf("failed to revert migration %d: %w", s.migration.Id, err)
+		}
+	}
+	return transaction.Commit()
+}
+
+// getAppliedMigrations reads the migrations table, which doesn't exist before the initial migration is applied.
+func getAppliedMigrations() (map[int]appliedMigration, error) {
+	applied := make(map[int]appliedMigration)
+	rows, err := getConnector().QueryRows("select id, applied_at from migrations")
+	if err != nil {
+		if migrations.IsInitialMigrationError(err) {
+			return applied, nil
+		}
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var row appliedMigration
+		if err = rows.Scan(&id, &row.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[id] = row
+	}
+
+	checksums, err := getConnector().QueryRows("select id, checksum from migration_checksums")
+	if err != nil {
+		if migrations.IsInitialMigrationError(err) {
+			return applied, nil
+		}
+		return nil, err
+	}
+	defer checksums.Close()
+	for checksums.Next() {
+		var id int
+		var checksum string
+		if err = checksums.Scan(&id, &checksum); err != nil {
+			return nil, err
+		}
+		if row, ok := applied[id]; ok {
+			row.checksum = checksum
+			applied[id] = row
+		}
+	}
+	return applied, nil
+}
+
+// getSchemaVersion returns the id of the latest applied migration, or -1 if none are.
+func getSchemaVersion(applied map[int]appliedMigration) int {
+	version := -1
+	for id := range applied {
+		version = max(version, id)
+	}
+	return version
+}
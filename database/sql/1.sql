@@ -0,0 +1,14 @@
+create table videos (
+    id uuid constraint pk_videos primary key default gen_random_uuid(),
+    game_id uuid null references games(id) on delete cascade,
+    game_release_id uuid null references game_releases(id) on delete cascade,
+    provider varchar(20) not null,
+    video_id varchar(100) not null,
+    kind varchar(20) not null,
+    language varchar(35) null,
+    published_at date null,
+    constraint ck_videos_single_owner check ( (game_id is null) <> (game_release_id is null) )
+);
+
+create index ix_videos_game_id on videos (game_id);
+create index ix_videos_game_release_id on videos (game_release_id);
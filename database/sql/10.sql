@@ -0,0 +1,4 @@
+-- incremented by every update, used for optimistic concurrency through ETag and If-Match headers
+alter table games add column version integer not null default 1;
+alter table platforms add column version integer not null default 1;
+alter table game_releases add column version integer not null default 1;
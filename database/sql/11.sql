@@ -0,0 +1,2 @@
+-- identifies games in external sources, like store ids, so that repeated imports update the same games
+alter table games add column external_id varchar(100) null constraint ix_games_external_id unique;
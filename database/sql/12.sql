@@ -0,0 +1,8 @@
+alter table platforms add column manufacturer varchar(100) null;
+
+-- versions of embedded seed packs applied to the database, so that each version is only applied once
+create table seed_packs (
+    name varchar(50) constraint pk_seed_packs primary key,
+    version integer not null,
+    applied_at timestamptz not null default now()
+);
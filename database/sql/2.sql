@@ -0,0 +1,16 @@
+alter table game_releases
+    add column single_player bool not null default false,
+    add column local_coop_max_players smallint null constraint ck_game_releases_local_coop check ( local_coop_max_players > 1 ),
+    add column online_coop_max_players smallint null constraint ck_game_releases_online_coop check ( online_coop_max_players > 1 ),
+    add column online_pvp_max_players smallint null constraint ck_game_releases_online_pvp check ( online_pvp_max_players > 1 ),
+    add column cross_play bool not null default false,
+    add column controller_support varchar(10) not null default 'unknown'
+        constraint ck_game_releases_controller_support check ( controller_support in ('unknown', 'none', 'partial', 'full') );
+
+create index ix_game_release_platforms_game_release_id on game_release_platforms (game_release_id);
+
+-- used for filtering releases by platform without nesting subqueries in the where clause
+create function game_release_platform_ids(release_id uuid) returns uuid[] as
+$$
+select array(select grp.platform_id from game_release_platforms grp where grp.game_release_id = release_id)
+$$ language sql stable;
@@ -0,0 +1,32 @@
+alter table game_releases add constraint ix_game_releases_id_game_id unique (id, game_id);
+
+create table release_groups (
+    id uuid constraint pk_release_groups primary key default gen_random_uuid(),
+    game_id uuid not null references games(id) on delete cascade,
+    kind varchar(10) not null constraint ck_release_groups_kind check ( kind in ('crossplay', 'crosssave') ),
+    constraint ix_release_groups_id_game_id_kind unique (id, game_id, kind)
+);
+
+-- game_id and kind are duplicated here so that the constraints can guarantee that all members belong to the same game
+-- and that a release is never a member of two groups of the same kind
+create table release_group_members (
+    release_group_id uuid not null,
+    game_release_id uuid not null,
+    game_id uuid not null,
+    kind varchar(10) not null,
+    constraint fk_release_group_members_group foreign key (release_group_id, game_id, kind) references release_groups (id, game_id, kind) on delete cascade,
+    constraint fk_release_group_members_release foreign key (game_release_id, game_id) references game_releases (id, game_id) on delete cascade,
+    constraint ix_release_group_members_release_kind unique (game_release_id, kind)
+);
+
+create index ix_release_group_members_group on release_group_members (release_group_id);
+
+create function release_group_peers(release_id uuid, group_kind varchar) returns uuid[] as
+$$
+select array(select other.game_release_id
+             from release_group_members own
+                      join release_group_members other on other.release_group_id = own.release_group_id
+             where own.game_release_id = release_id
+               and own.kind = group_kind
+               and other.game_release_id <> release_id)
+$$ language sql stable;
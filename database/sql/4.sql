@@ -0,0 +1,22 @@
+create table game_release_languages (
+    game_release_id uuid not null references game_releases(id) on delete cascade,
+    language varchar(35) not null,
+    interface bool not null,
+    audio bool not null,
+    subtitles bool not null,
+    constraint pk_game_release_languages primary key (game_release_id, language)
+);
+
+-- returns both full language tags and their base languages, so that filtering by "ja" also matches releases with "ja-JP"
+create function game_release_languages(release_id uuid, support_kind varchar) returns varchar[] as
+$$
+select array(select distinct unnest(array [grl.language, split_part(grl.language, '-', 1)])
+             from game_release_languages grl
+             where grl.game_release_id = release_id
+               and case support_kind
+                       when 'interface' then grl.interface
+                       when 'audio' then grl.audio
+                       when 'subtitles' then grl.subtitles
+                       else true
+                 end)
+$$ language sql stable;
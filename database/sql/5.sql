@@ -0,0 +1,23 @@
+alter table platforms
+    add column family varchar(10) not null default 'other'
+        constraint ck_platforms_family check ( family in ('pc', 'console', 'handheld', 'mobile', 'other') );
+
+update platforms set family = 'pc' where short_name_normalised in ('PC', 'WIN', 'MAC', 'LINUX');
+
+create table game_release_system_requirements (
+    game_release_id uuid not null references game_releases(id) on delete cascade,
+    level varchar(12) not null constraint ck_game_release_system_requirements_level check ( level in ('minimum', 'recommended') ),
+    os varchar(200) null,
+    cpu varchar(200) null,
+    gpu varchar(200) null,
+    ram_mb integer null constraint ck_game_release_system_requirements_ram check ( ram_mb > 0 ),
+    storage_mb integer null constraint ck_game_release_system_requirements_storage check ( storage_mb > 0 ),
+    graphics_api varchar(50) null,
+    notes varchar(2000) null,
+    constraint pk_game_release_system_requirements primary key (game_release_id, level)
+);
+
+create function game_release_minimum_ram(release_id uuid) returns integer as
+$$
+select grsr.ram_mb from game_release_system_requirements grsr where grsr.game_release_id = release_id and grsr.level = 'minimum'
+$$ language sql stable;
@@ -0,0 +1,7 @@
+create table api_keys (
+    id uuid constraint pk_api_keys primary key default gen_random_uuid(),
+    name varchar(200) not null,
+    key_hash char(64) not null constraint ix_api_keys_key_hash unique,
+    created_at timestamptz not null default now(),
+    revoked_at timestamptz null
+);
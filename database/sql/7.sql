@@ -0,0 +1,7 @@
+create table role_assignments (
+    principal_kind varchar(10) not null,
+    subject varchar(200) not null,
+    role varchar(10) not null constraint ck_role_assignments_role check ( role in ('viewer', 'editor', 'moderator', 'admin') ),
+    assigned_at timestamptz not null default now(),
+    constraint pk_role_assignments primary key (principal_kind, subject)
+);
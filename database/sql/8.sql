@@ -0,0 +1,16 @@
+create table audit_log (
+    id bigint constraint pk_audit_log primary key generated always as identity,
+    entity_type varchar(20) not null,
+    entity_id uuid not null,
+    action varchar(10) not null constraint ck_audit_log_action check ( action in ('insert', 'update', 'delete') ),
+    actor_kind varchar(10) not null,
+    actor_subject varchar(200) null,
+    request_id varchar(100) null,
+    occurred_at timestamptz not null default now(),
+    before jsonb null,
+    after jsonb null
+);
+
+create index ix_audit_log_entity on audit_log (entity_type, entity_id, id);
+create index ix_audit_log_occurred_at on audit_log (occurred_at);
+create index ix_audit_log_actor on audit_log (actor_subject);
@@ -0,0 +1,21 @@
+create table change_proposals (
+    id uuid constraint pk_change_proposals primary key default gen_random_uuid(),
+    entity_type varchar(20) not null,
+    -- null for proposals of new entities, until they are approved
+    entity_id uuid null,
+    action varchar(10) not null constraint ck_change_proposals_action check ( action in ('insert', 'update', 'delete') ),
+    changes jsonb null,
+    base_revision bigint not null default 0,
+    status varchar(10) not null default 'pending'
+        constraint ck_change_proposals_status check ( status in ('pending', 'approved', 'rejected', 'conflicted') ),
+    proposer_kind varchar(10) not null,
+    proposer_subject varchar(200) null,
+    reviewer_subject varchar(200) null,
+    review_comment varchar(2000) null,
+    created_at timestamptz not null default now(),
+    reviewed_at timestamptz null,
+    constraint ck_change_proposals_entity_id check ( (entity_id is null) = (action = 'insert' and status <> 'approved') )
+);
+
+create index ix_change_proposals_status on change_proposals (status, created_at);
+create index ix_change_proposals_entity on change_proposals (entity_type, entity_id);
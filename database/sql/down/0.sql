@@ -0,0 +1,5 @@
+drop table game_release_platforms;
+drop table game_releases;
+drop table platforms;
+drop table games;
+drop table migrations;
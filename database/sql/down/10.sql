@@ -0,0 +1,3 @@
+alter table game_releases drop column version;
+alter table platforms drop column version;
+alter table games drop column version;
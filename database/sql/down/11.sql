@@ -0,0 +1 @@
+alter table games drop column external_id;
@@ -0,0 +1,2 @@
+drop table seed_packs;
+alter table platforms drop column manufacturer;
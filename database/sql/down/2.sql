@@ -0,0 +1,11 @@
+drop function game_release_platform_ids(uuid);
+
+drop index ix_game_release_platforms_game_release_id;
+
+alter table game_releases
+    drop column single_player,
+    drop column local_coop_max_players,
+    drop column online_coop_max_players,
+    drop column online_pvp_max_players,
+    drop column cross_play,
+    drop column controller_support;
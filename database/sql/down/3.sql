@@ -0,0 +1,6 @@
+drop function release_group_peers(uuid, varchar);
+
+drop table release_group_members;
+drop table release_groups;
+
+alter table game_releases drop constraint ix_game_releases_id_game_id;
@@ -0,0 +1,3 @@
+drop function game_release_languages(uuid, varchar);
+
+drop table game_release_languages;
@@ -0,0 +1,5 @@
+drop function game_release_minimum_ram(uuid);
+
+drop table game_release_system_requirements;
+
+alter table platforms drop column family;
@@ -0,0 +1 @@
+drop table role_assignments;
@@ -0,0 +1,159 @@
+package dump
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var InvalidArchiveErr = errors.New("archive is not valid")
+
+// archiveWriter streams a gzip-compressed json object, with the header fields followed by an array of rows for each section:
+//
+//	{"format": 2, "createdAt": "...", "platforms": [...], "games": [...], ...}
+type archiveWriter struct {
+	gzip   *gzip.Writer
+	output io.Writer
+	// rows is the number of rows written to the current section, used to separate them.
+	rows int
+}
+
+func newArchiveWriter(output io.Writer, createdAt time.Time) (*archiveWriter, error) {
+	compressed := gzip.NewWriter(output)
+	writer := &archiveWriter{gzip: compressed, output: compressed}
+	header, err := json.Marshal(Header{Format: FormatVersion, CreatedAt: createdAt.UTC()})
+	if err != nil {
+		return nil, err
+	}
+	// the header is left open, so that sections are written as further fields of the same object
+	if _, err = writer.output.Write(header[:len(header)-1]); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (w *archiveWriter) beginSection(name string) error {
+	w.rows = 0
+	_, err := fmt.Fprintf(w.output, ",%q:[", name)
+	return err
+}
+
+func (w *archiveWriter) writeRow(row any) error {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	if w.rows > 0 {
+		if _, err = w.output.Write([]byte{','}); err != nil {
+			return err
+		}
+	}
+	w.rows++
+	_, err = w.output.Write(data)
+	return err
+}
+
+func (w *archiveWriter) endSection() error {
+	_, err := w.output.Write([]byte{']'})
+	return err
+}
+
+func (w *archiveWriter) Close() error {
+	if _, err := w.output.Write([]byte{'}'}); err != nil {
+		return err
+	}
+	return w.gzip.Close()
+}
+
+// archiveReader reads sections of an archive one row at a time, so that archives of any size can be restored.
+type archiveReader struct {
+	Header
+	decoder *json.Decoder
+}
+
+// newArchiveReader reads the archive header, failing if the archive was written in a newer format version.
+func newArchiveReader(input io.Reader) (*archiveReader, error) {
+	compressed, err := gzip.NewReader(input)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", InvalidArchiveErr, err.Error())
+	}
+	reader := &archiveReader{decoder: json.NewDecoder(compressed)}
+	reader.decoder.DisallowUnknownFields()
+	if err = reader.expectDelim('{'); err != nil {
+		return nil, err
+	}
+	for _, field := range []struct {
+		name  string
+		value any
+	}{{"format", &reader.Format}, {"createdAt", &reader.CreatedAt}} {
+		if err = reader.expectKey(field.name); err != nil {
+			return nil, err
+		}
+		if err = reader.decode(field.value); err != nil {
+			return nil, err
+		}
+	}
+	if reader.Format < 1 || reader.Format > FormatVersion {
+		return nil, fmt.Errorf("%w: archive format %d is not supported, versions up to %d can be restored", InvalidArchiveErr, reader.Format, FormatVersion)
+	}
+	return reader, nil
+}
+
+// readSection reads all rows of the next section, which has to be the expected one, as rows can only be restored after those they refer to.
+func (r *archiveReader) readSection(name string, handle func(decode func(row any) error) error) error {
+	if err := r.expectKey(name); err != nil {
+		return err
+	}
+	if err := r.expectDelim('['); err != nil {
+		return err
+	}
+	for r.decoder.More() {
+		if err := handle(r.decode); err != nil {
+			return err
+		}
+	}
+	return r.expectDelim(']')
+}
+
+// close checks that nothing follows the last section.
+func (r *archiveReader) close() error {
+	if err := r.expectDelim('}'); err != nil {
+		return err
+	}
+	if _, err := r.decoder.Token(); !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: unexpected data after the end of the archive", InvalidArchiveErr)
+	}
+	return nil
+}
+
+func (r *archiveReader) expectKey(name string) error {
+	token, err := r.decoder.Token()
+	if err != nil {
+		return fmt.Errorf("%w: expected %s: %s", InvalidArchiveErr, name, err.Error())
+	}
+	if token != name {
+		return fmt.Errorf("%w: expected %s, found %v", InvalidArchiveErr, name, token)
+	}
+	return nil
+}
+
+func (r *archiveReader) expectDelim(delim json.Delim) error {
+	token, err := r.decoder.Token()
+	if err != nil {
+		return fmt.Errorf("%w: expected %s: %s", InvalidArchiveErr, delim, err.Error())
+	}
+	if token != delim {
+		return fmt.Errorf("%w: expected %s, found %v", InvalidArchiveErr, delim, token)
+	}
+	return nil
+}
+
+func (r *archiveReader) decode(value any) error {
+	if err := r.decoder.Decode(value); err != nil {
+		return fmt.Errorf("%w: %s", InvalidArchiveErr, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package dump
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"github.com/Geepr/game/mocks"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/gofrs/uuid"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeResult struct{}
+
+func (fakeResult) LastInsertId() (int64, error) { return 0, nil }
+func (fakeResult) RowsAffected() (int64, error) { return 1, nil }
+
+// fakeConnector records statements executed by restore.
+type fakeConnector struct {
+	queries []string
+	args    [][]any
+}
+
+func (c *fakeConnector) QueryRow(_ string, _ ...interface{}) (gotabase.Row, error)   { return nil, nil }
+func (c *fakeConnector) QueryRows(_ string, _ ...interface{}) (gotabase.Rows, error) { return nil, nil }
+func (c *fakeConnector) Exec(query string, args ...interface{}) (gotabase.Result, error) {
+	c.queries = append(c.queries, query)
+	c.args = append(c.args, args)
+	return fakeResult{}, nil
+}
+
+// writeArchive writes all sections, with rows only in the ones listed.
+func writeArchive(rows map[string][]any) []byte {
+	var buffer bytes.Buffer
+	writer, err := newArchiveWriter(&buffer, time.Now())
+	mocks.PanicOnErr(err)
+	for _, name := range Sections() {
+		mocks.PanicOnErr(writer.beginSection(name))
+		for _, row := range rows[name] {
+			mocks.PanicOnErr(writer.writeRow(row))
+		}
+		mocks.PanicOnErr(writer.endSection())
+	}
+	mocks.PanicOnErr(writer.Close())
+	return buffer.Bytes()
+}
+
+func compress(data string) []byte {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	_, err := writer.Write([]byte(data))
+	mocks.PanicOnErr(err)
+	mocks.PanicOnErr(writer.Close())
+	return buffer.Bytes()
+}
+
+func decompress(data []byte) []byte {
+	var buffer bytes.Buffer
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	mocks.PanicOnErr(err)
+	_, err = buffer.ReadFrom(reader)
+	mocks.PanicOnErr(err)
+	return buffer.Bytes()
+}
+
+func TestRestoreSections_WrittenArchive_RowsInsertedWithIds(t *testing.T) {
+	platformId, gameId := uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4())
+	description := "desc"
+	archive := writeArchive(map[string][]any{
+		"platforms": {Platform{Id: platformId, Name: "PlayStation 5", ShortName: "PS5", Family: "console", Version: 2}},
+		"games":     {Game{Id: gameId, Title: "aaa", Description: &description, Version: 3}, Game{Id: uuid.Must(uuid.NewV4()), Title: "bbb", Version: 1}},
+	})
+	connector := &fakeConnector{}
+
+	reader, err := newArchiveReader(bytes.NewReader(archive))
+	mocks.AssertDefault(t, err)
+	counts, err := restoreSections(context.Background(), connector, reader)
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, counts["platforms"], 1)
+	mocks.AssertEquals(t, counts["games"], 2)
+	mocks.AssertEquals(t, counts["releases"], 0)
+	mocks.AssertCountEqual(t, connector.queries, 3)
+	mocks.AssertEquals(t, connector.queries[0], "insert into platforms (id, name, short_name, manufacturer, family, version) values ($1, $2, $3, $4, $5, $6)")
+	mocks.AssertEquals(t, *connector.args[0][0].(*uuid.UUID), platformId)
+	mocks.AssertEquals(t, *connector.args[1][0].(*uuid.UUID), gameId)
+	mocks.AssertEquals(t, **connector.args[1][2].(**string), description)
+	mocks.AssertEquals(t, *connector.args[1][5].(*int), 3)
+}
+
+func TestNewArchiveReader_InvalidArchives_ReturnsErr(t *testing.T) {
+	testData := []struct {
+		name    string
+		archive []byte
+	}{
+		{"not gzip", []byte(`{"format": 1}`)},
+		{"not json", compress("format")},
+		{"newer format", compress(`{"format": 3, "createdAt": "2024-01-01T00:00:00Z"}`)},
+		{"no format", compress(`{"createdAt": "2024-01-01T00:00:00Z"}`)},
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(currentData.name, func(t *testing.T) {
+			_, err := newArchiveReader(bytes.NewReader(currentData.archive))
+
+			mocks.AssertEquals(t, errors.Is(err, InvalidArchiveErr), true)
+		})
+	}
+}
+
+func TestRestoreSections_InvalidSections_ReturnsErr(t *testing.T) {
+	header := `{"format": 2, "createdAt": "2024-01-01T00:00:00Z", `
+	// the unknown field is added to an otherwise complete archive, so that nothing else makes it invalid
+	withPlatform := string(decompress(writeArchive(map[string][]any{"platforms": {Platform{Id: uuid.Must(uuid.NewV4()), Name: "PlayStation 5", ShortName: "PS5", Family: "console", Version: 1}}})))
+	testData := []struct {
+		name    string
+		archive string
+	}{
+		{"out of order", header + `"games": [], "platforms": []}`},
+		{"unknown field", strings.Replace(withPlatform, `"family"`, `"studio": "Sony", "family"`, 1)},
+		{"missing sections", header + `"platforms": [], "games": []}`},
+		{"trailing section", strings.TrimSuffix(string(decompress(writeArchive(nil))), "}") + `, "studios": []}`},
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(currentData.name, func(t *testing.T) {
+			reader, err := newArchiveReader(bytes.NewReader(compress(currentData.archive)))
+			mocks.AssertDefault(t, err)
+
+			_, err = restoreSections(context.Background(), &fakeConnector{}, reader)
+
+			mocks.AssertEquals(t, errors.Is(err, InvalidArchiveErr), true)
+		})
+	}
+}
+
+func TestRestoreSections_FormatOneArchive_PlatformsRestoredWithoutManufacturer(t *testing.T) {
+	platformId := uuid.Must(uuid.NewV4())
+	sections := make([]string, 0, len(Sections()))
+	for _, name := range Sections() {
+		rows := "[]"
+		if name == "platforms" {
+			rows = `[{"id": "` + platformId.String() + `", "name": "PlayStation 5", "shortName": "PS5", "family": "console", "version": 1}]`
+		}
+		sections = append(sections, `"`+name+`": `+rows)
+	}
+	archive := `{"format": 1, "createdAt": "2024-01-01T00:00:00Z", ` + strings.Join(sections, ", ") + "}"
+	connector := &fakeConnector{}
+
+	reader, err := newArchiveReader(bytes.NewReader(compress(archive)))
+	mocks.AssertDefault(t, err)
+	counts, err := restoreSections(context.Background(), connector, reader)
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, reader.Format, 1)
+	mocks.AssertEquals(t, counts["platforms"], 1)
+	mocks.AssertCountEqual(t, connector.queries, 1)
+	mocks.AssertEquals(t, *connector.args[0][0].(*uuid.UUID), platformId)
+	mocks.AssertEquals(t, *connector.args[0][3].(**string) == nil, true)
+}
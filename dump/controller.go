@@ -0,0 +1,62 @@
+package dump
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Geepr/game/auth"
+	"github.com/Geepr/game/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+const archiveContentType = "application/gzip"
+
+// dumpRoute streams an archive of the whole catalogue, errors after the archive has started can only cut it short.
+func dumpRoute(c *gin.Context) {
+	// archives of large catalogues take longer to stream than the server timeouts meant for regular requests allow
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	c.Header("Content-Type", archiveContentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="catalogue-%s.json.gz"`, time.Now().UTC().Format("20060102-150405")))
+	counts, err := Dump(c, c.Writer)
+	if err != nil {
+		if !c.Writer.Written() {
+			utils.AbortWithRelevantError(err, c)
+			return
+		}
+		utils.Logger(c).Warnf("Failed to write catalogue dump: %s", err.Error())
+		_ = c.Error(err)
+		c.Abort()
+		return
+	}
+	utils.Logger(c).Infof("Dumped catalogue: %v", counts)
+}
+
+// restoreRoute loads an archive sent as the request body into an empty catalogue, responding with the number of restored rows.
+func restoreRoute(c *gin.Context) {
+	controller := http.NewResponseController(c.Writer)
+	_ = controller.SetReadDeadline(time.Time{})
+	_ = controller.SetWriteDeadline(time.Time{})
+	counts, err := Restore(c, c.Request.Body)
+	if errors.Is(err, InvalidArchiveErr) {
+		utils.Logger(c).Infof("Failed to read catalogue archive: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	if errors.Is(err, NotEmptyErr) {
+		utils.AbortWithProblem(http.StatusConflict, err.Error(), c)
+		return
+	}
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+	c.JSON(http.StatusOK, counts)
+}
+
+func SetupRoutes(engine *gin.Engine, basePath string) {
+	baseUrl := fmt.Sprintf("%s/api/v0/dump", basePath)
+
+	engine.GET(baseUrl, auth.Require(auth.ResourceDump, auth.ActionCreate), dumpRoute)
+	engine.POST(baseUrl+"/restore", auth.Require(auth.ResourceDump, auth.ActionUpdate), restoreRoute)
+}
@@ -0,0 +1,14 @@
+package dump
+
+import (
+	"context"
+	"github.com/Geepr/game/tracing"
+	"github.com/KowalskiPiotr98/gotabase"
+)
+
+var (
+	getConnector = func(ctx context.Context) gotabase.Connector {
+		return tracing.WrapConnector(ctx, gotabase.GetConnection())
+	}
+	getTransaction = func(ctx context.Context) (*tracing.Transaction, error) { return tracing.BeginTransaction(ctx) }
+)
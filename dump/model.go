@@ -0,0 +1,106 @@
+package dump
+
+import (
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+// FormatVersion is increased with every change of the archive contents, archives of newer versions can't be restored.
+// Older archives are restored with columns added since left empty.
+const FormatVersion = 2
+
+// Header is written at the start of each archive, before rows of all tables.
+type Header struct {
+	Format    int       `json:"format"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Counts holds the number of rows dumped or restored, by the name of their archive section.
+type Counts map[string]int
+
+// Rows of the archive mirror the catalogue tables, without generated columns, so that restored entities keep their ids and versions.
+
+type Platform struct {
+	Id        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	ShortName string    `json:"shortName"`
+	// Manufacturer was added in version 2.
+	Manufacturer *string `json:"manufacturer"`
+	Family       string  `json:"family"`
+	Version      int     `json:"version"`
+}
+
+type Game struct {
+	Id          uuid.UUID `json:"id"`
+	Title       string    `json:"title"`
+	Description *string   `json:"description"`
+	Archived    bool      `json:"archived"`
+	ExternalId  *string   `json:"externalId"`
+	Version     int       `json:"version"`
+}
+
+type Release struct {
+	Id                   uuid.UUID  `json:"id"`
+	GameId               uuid.UUID  `json:"gameId"`
+	TitleOverride        *string    `json:"titleOverride"`
+	Description          *string    `json:"description"`
+	ReleaseDate          *time.Time `json:"releaseDate"`
+	ReleaseDateUnknown   bool       `json:"releaseDateUnknown"`
+	SinglePlayer         bool       `json:"singlePlayer"`
+	LocalCoopMaxPlayers  *int       `json:"localCoopMaxPlayers"`
+	OnlineCoopMaxPlayers *int       `json:"onlineCoopMaxPlayers"`
+	OnlinePvpMaxPlayers  *int       `json:"onlinePvpMaxPlayers"`
+	CrossPlay            bool       `json:"crossPlay"`
+	ControllerSupport    string     `json:"controllerSupport"`
+	Version              int        `json:"version"`
+}
+
+type ReleasePlatform struct {
+	ReleaseId  uuid.UUID `json:"releaseId"`
+	PlatformId uuid.UUID `json:"platformId"`
+}
+
+type ReleaseLanguage struct {
+	ReleaseId uuid.UUID `json:"releaseId"`
+	Language  string    `json:"language"`
+	Interface bool      `json:"interface"`
+	Audio     bool      `json:"audio"`
+	Subtitles bool      `json:"subtitles"`
+}
+
+type SystemRequirements struct {
+	ReleaseId   uuid.UUID `json:"releaseId"`
+	Level       string    `json:"level"`
+	Os          *string   `json:"os"`
+	Cpu         *string   `json:"cpu"`
+	Gpu         *string   `json:"gpu"`
+	RamMb       *int      `json:"ramMb"`
+	StorageMb   *int      `json:"storageMb"`
+	GraphicsApi *string   `json:"graphicsApi"`
+	Notes       *string   `json:"notes"`
+}
+
+// Video is owned either by a game or by a release, the other id is nil.
+type Video struct {
+	Id          uuid.UUID  `json:"id"`
+	GameId      *uuid.UUID `json:"gameId"`
+	ReleaseId   *uuid.UUID `json:"releaseId"`
+	Provider    string     `json:"provider"`
+	VideoId     string     `json:"videoId"`
+	Kind        string     `json:"kind"`
+	Language    *string    `json:"language"`
+	PublishedAt *time.Time `json:"publishedAt"`
+}
+
+type ReleaseGroup struct {
+	Id     uuid.UUID `json:"id"`
+	GameId uuid.UUID `json:"gameId"`
+	Kind   string    `json:"kind"`
+}
+
+type ReleaseGroupMember struct {
+	GroupId   uuid.UUID `json:"groupId"`
+	ReleaseId uuid.UUID `json:"releaseId"`
+	GameId    uuid.UUID `json:"gameId"`
+	Kind      string    `json:"kind"`
+}
@@ -0,0 +1,251 @@
+package dump
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Geepr/game/metrics"
+	"github.com/Geepr/game/utils"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/lib/pq"
+	"io"
+	"strings"
+	"time"
+)
+
+var NotEmptyErr = errors.New("catalogue can only be restored into an empty database")
+
+// section is a catalogue table stored in the archive.
+type section interface {
+	name() string
+	dump(ctx context.Context, connector gotabase.Connector, writer *archiveWriter) (int, error)
+	restore(ctx context.Context, connector gotabase.Connector, reader *archiveReader) (int, error)
+}
+
+// table maps rows of a table to archive rows of type T, with fields returning pointers to the values of each column.
+type table[T any] struct {
+	section string
+	table   string
+	columns []string
+	order   string
+	fields  func(row *T) []any
+}
+
+// sections are ordered so that rows are always restored after those they refer to.
+var sections = []section{
+	table[Platform]{
+		section: "platforms",
+		table:   "platforms",
+		columns: []string{"id", "name", "short_name", "manufacturer", "family", "version"},
+		order:   "id",
+		fields: func(p *Platform) []any {
+			return []any{&p.Id, &p.Name, &p.ShortName, &p.Manufacturer, &p.Family, &p.Version}
+		},
+	},
+	table[Game]{
+		section: "games",
+		table:   "games",
+		columns: []string{"id", "title", "description", "archived", "external_id", "version"},
+		order:   "id",
+		fields: func(g *Game) []any {
+			return []any{&g.Id, &g.Title, &g.Description, &g.Archived, &g.ExternalId, &g.Version}
+		},
+	},
+	table[Release]{
+		section: "releases",
+		table:   "game_releases",
+		columns: []string{"id", "game_id", "title_override", "description", "release_date", "release_date_unknown", "single_player",
+			"local_coop_max_players", "online_coop_max_players", "online_pvp_max_players", "cross_play", "controller_support", "version"},
+		order: "id",
+		fields: func(r *Release) []any {
+			return []any{&r.Id, &r.GameId, &r.TitleOverride, &r.Description, &r.ReleaseDate, &r.ReleaseDateUnknown, &r.SinglePlayer,
+				&r.LocalCoopMaxPlayers, &r.OnlineCoopMaxPlayers, &r.OnlinePvpMaxPlayers, &r.CrossPlay, &r.ControllerSupport, &r.Version}
+		},
+	},
+	table[ReleasePlatform]{
+		section: "releasePlatforms",
+		table:   "game_release_platforms",
+		columns: []string{"game_release_id", "platform_id"},
+		order:   "game_release_id, platform_id",
+		fields:  func(r *ReleasePlatform) []any { return []any{&r.ReleaseId, &r.PlatformId} },
+	},
+	table[ReleaseLanguage]{
+		section: "releaseLanguages",
+		table:   "game_release_languages",
+		columns: []string{"game_release_id", "language", "interface", "audio", "subtitles"},
+		order:   "game_release_id, language",
+		fields: func(l *ReleaseLanguage) []any {
+			return []any{&l.ReleaseId, &l.Language, &l.Interface, &l.Audio, &l.Subtitles}
+		},
+	},
+	table[SystemRequirements]{
+		section: "systemRequirements",
+		table:   "game_release_system_requirements",
+		columns: []string{"game_release_id", "level", "os", "cpu", "gpu", "ram_mb", "storage_mb", "graphics_api", "notes"},
+		order:   "game_release_id, level",
+		fields: func(s *SystemRequirements) []any {
+			return []any{&s.ReleaseId, &s.Level, &s.Os, &s.Cpu, &s.Gpu, &s.RamMb, &s.StorageMb, &s.GraphicsApi, &s.Notes}
+		},
+	},
+	table[Video]{
+		section: "videos",
+		table:   "videos",
+		columns: []string{"id", "game_id", "game_release_id", "provider", "video_id", "kind", "language", "published_at"},
+		order:   "id",
+		fields: func(v *Video) []any {
+			return []any{&v.Id, &v.GameId, &v.ReleaseId, &v.Provider, &v.VideoId, &v.Kind, &v.Language, &v.PublishedAt}
+		},
+	},
+	table[ReleaseGroup]{
+		section: "releaseGroups",
+		table:   "release_groups",
+		columns: []string{"id", "game_id", "kind"},
+		order:   "id",
+		fields:  func(g *ReleaseGroup) []any { return []any{&g.Id, &g.GameId, &g.Kind} },
+	},
+	table[ReleaseGroupMember]{
+		section: "releaseGroupMembers",
+		table:   "release_group_members",
+		columns: []string{"release_group_id", "game_release_id", "game_id", "kind"},
+		order:   "release_group_id, game_release_id",
+		fields:  func(m *ReleaseGroupMember) []any { return []any{&m.GroupId, &m.ReleaseId, &m.GameId, &m.Kind} },
+	},
+}
+
+// Dump writes an archive of the whole catalogue to the output, as seen by a single snapshot of the database.
+// Nothing is written if the snapshot can't be started, so that callers can still report the error.
+func Dump(ctx context.Context, output io.Writer) (_ Counts, err error) {
+	defer metrics.ObserveQuery("dump", time.Now(), &err)
+	transaction, err := getTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer transaction.Rollback()
+	if _, err = transaction.Exec("set transaction isolation level repeatable read, read only"); err != nil {
+		utils.Logger(ctx).Warnf("Failed to start dump snapshot: %s", err.Error())
+		return nil, err
+	}
+
+	writer, err := newArchiveWriter(output, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	counts := make(Counts, len(sections))
+	for _, section := range sections {
+		if counts[section.name()], err = section.dump(ctx, transaction, writer); err != nil {
+			return nil, err
+		}
+	}
+	return counts, writer.Close()
+}
+
+// Restore loads an archive into an empty catalogue in a single transaction, keeping the ids and versions of all entities.
+// Restored entities are not recorded in the audit log, as they are copies of entities already recorded elsewhere.
+func Restore(ctx context.Context, input io.Reader) (_ Counts, err error) {
+	defer metrics.ObserveQuery("restore", time.Now(), &err)
+	reader, err := newArchiveReader(input)
+	if err != nil {
+		return nil, err
+	}
+	transaction, err := getTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer transaction.Rollback()
+
+	var empty bool
+	row, err := transaction.QueryRow("select not exists(select 1 from games) and not exists(select 1 from platforms)")
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to check if the catalogue is empty: %s", err.Error())
+		return nil, err
+	}
+	if err = row.Scan(&empty); err != nil {
+		return nil, err
+	}
+	if !empty {
+		return nil, NotEmptyErr
+	}
+
+	counts, err := restoreSections(ctx, transaction, reader)
+	if err != nil {
+		return nil, err
+	}
+	return counts, transaction.Commit()
+}
+
+func restoreSections(ctx context.Context, connector gotabase.Connector, reader *archiveReader) (_ Counts, err error) {
+	counts := make(Counts, len(sections))
+	for _, section := range sections {
+		if counts[section.name()], err = section.restore(ctx, connector, reader); err != nil {
+			return nil, err
+		}
+	}
+	return counts, reader.close()
+}
+
+func (t table[T]) name() string {
+	return t.section
+}
+
+func (t table[T]) dump(ctx context.Context, connector gotabase.Connector, writer *archiveWriter) (int, error) {
+	query := fmt.Sprintf("select %s from %s order by %s", strings.Join(t.columns, ", "), t.table, t.order)
+	result, err := connector.QueryRows(query)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to run dump query on %s: %s", t.table, err.Error())
+		return 0, err
+	}
+	defer result.Close()
+
+	if err = writer.beginSection(t.section); err != nil {
+		return 0, err
+	}
+	count := 0
+	for result.Next() {
+		var row T
+		if err = result.Scan(t.fields(&row)...); err != nil {
+			return 0, err
+		}
+		if err = writer.writeRow(&row); err != nil {
+			return 0, err
+		}
+		count++
+	}
+	return count, writer.endSection()
+}
+
+func (t table[T]) restore(ctx context.Context, connector gotabase.Connector, reader *archiveReader) (int, error) {
+	placeholders := make([]string, len(t.columns))
+	for i := range placeholders {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	query := fmt.Sprintf("insert into %s (%s) values (%s)", t.table, strings.Join(t.columns, ", "), strings.Join(placeholders, ", "))
+
+	count := 0
+	err := reader.readSection(t.section, func(decode func(row any) error) error {
+		var row T
+		if err := decode(&row); err != nil {
+			return err
+		}
+		if _, err := connector.Exec(query, t.fields(&row)...); err != nil {
+			utils.Logger(ctx).Warnf("Failed to restore row %d of %s: %s", count+1, t.section, err.Error())
+			// rows breaking constraints mean that the archive is not consistent, rather than that the database failed
+			var pgErr *pq.Error
+			if errors.As(err, &pgErr) && pgErr.Code.Class() == "23" {
+				return fmt.Errorf("%w: row %d of %s: %s", InvalidArchiveErr, count+1, t.section, pgErr.Message)
+			}
+			return err
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// Sections returns names of the archive sections, in the order they are stored.
+func Sections() []string {
+	names := make([]string, len(sections))
+	for i, section := range sections {
+		names[i] = section.name()
+	}
+	return names
+}
@@ -0,0 +1,92 @@
+package dump
+
+import (
+	"bytes"
+	"context"
+	"github.com/Geepr/game/mocks"
+	"github.com/KowalskiPiotr98/gotabase"
+	"testing"
+)
+
+type dumpRepoTest struct {
+	connection gotabase.Connector
+	dbName     string
+}
+
+func newDumpRepoTest(t *testing.T) *dumpRepoTest {
+	db, name := mocks.GetDatabase()
+	test := &dumpRepoTest{
+		connection: db,
+		dbName:     name,
+	}
+	t.Cleanup(test.cleanup)
+	return test
+}
+
+func (test *dumpRepoTest) cleanup() {
+	mocks.DropDatabase(test.dbName)
+}
+
+func (test *dumpRepoTest) insertMockData() {
+	_, err := test.connection.Exec("insert into platforms (id, name, short_name, family) values ('0a5d6f62-4d4e-4b43-9d7b-2f7c52a6c001', 'Personal computer', 'PC', 'pc')")
+	mocks.PanicOnErr(err)
+	_, err = test.connection.Exec("insert into games (id, title, archived, external_id, version) values ('0a5d6f62-4d4e-4b43-9d7b-2f7c52a6c002', 'Game', false, 'store:1', 4)")
+	mocks.PanicOnErr(err)
+	_, err = test.connection.Exec("insert into game_releases (id, game_id, release_date, release_date_unknown, local_coop_max_players) " +
+		"values ('0a5d6f62-4d4e-4b43-9d7b-2f7c52a6c003', '0a5d6f62-4d4e-4b43-9d7b-2f7c52a6c002', '2024-02-29', false, 4)")
+	mocks.PanicOnErr(err)
+	_, err = test.connection.Exec("insert into game_release_platforms (platform_id, game_release_id) values ('0a5d6f62-4d4e-4b43-9d7b-2f7c52a6c001', '0a5d6f62-4d4e-4b43-9d7b-2f7c52a6c003')")
+	mocks.PanicOnErr(err)
+	_, err = test.connection.Exec("insert into game_release_languages (game_release_id, language, interface, audio, subtitles) values ('0a5d6f62-4d4e-4b43-9d7b-2f7c52a6c003', 'en', true, true, false)")
+	mocks.PanicOnErr(err)
+}
+
+func (test *dumpRepoTest) clear() {
+	_, err := test.connection.Exec("delete from game_release_platforms")
+	mocks.PanicOnErr(err)
+	_, err = test.connection.Exec("delete from games")
+	mocks.PanicOnErr(err)
+	_, err = test.connection.Exec("delete from platforms")
+	mocks.PanicOnErr(err)
+}
+
+func (test *dumpRepoTest) queryString(query string) string {
+	row, err := test.connection.QueryRow(query)
+	mocks.PanicOnErr(err)
+	var value string
+	mocks.PanicOnErr(row.Scan(&value))
+	return value
+}
+
+func TestDumpRepository_DumpAndRestore_CatalogueRecreated(t *testing.T) {
+	test := newDumpRepoTest(t)
+	test.insertMockData()
+	var archive bytes.Buffer
+
+	dumped, err := Dump(context.Background(), &archive)
+	mocks.AssertDefault(t, err)
+	test.clear()
+	restored, err := Restore(context.Background(), bytes.NewReader(archive.Bytes()))
+
+	mocks.AssertDefault(t, err)
+	for _, name := range Sections() {
+		mocks.AssertEquals(t, restored[name], dumped[name])
+	}
+	mocks.AssertEquals(t, restored["releasePlatforms"], 1)
+	mocks.AssertEquals(t, test.queryString("select id || ':' || version || ':' || external_id from games"), "0a5d6f62-4d4e-4b43-9d7b-2f7c52a6c002:4:store:1")
+	mocks.AssertEquals(t, test.queryString("select title_normalised from games"), "GAME")
+	mocks.AssertEquals(t, test.queryString("select release_date::text || ':' || local_coop_max_players from game_releases"), "2024-02-29:4")
+	mocks.AssertEquals(t, test.queryString("select language from game_release_languages"), "en")
+}
+
+func TestDumpRepository_RestoreIntoExistingCatalogue_ReturnsErr(t *testing.T) {
+	test := newDumpRepoTest(t)
+	test.insertMockData()
+	var archive bytes.Buffer
+	_, err := Dump(context.Background(), &archive)
+	mocks.AssertDefault(t, err)
+
+	_, err = Restore(context.Background(), bytes.NewReader(archive.Bytes()))
+
+	mocks.AssertEquals(t, err, NotEmptyErr)
+}
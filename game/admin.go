@@ -0,0 +1,39 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Geepr/game/audit"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/gofrs/uuid"
+)
+
+// List returns a page of games with titles matching the query, along with the number of all matching games.
+// It's meant for tooling working on the database directly, like gamectl, routes use the repository instead.
+func List(ctx context.Context, titleQuery string, pageIndex int, pageSize int, order SortOrder) ([]*Game, int, error) {
+	return getGames(ctx, titleQuery, pageIndex, pageSize, order)
+}
+
+// Get returns the game with the given id, including aggregated languages of its releases.
+func Get(ctx context.Context, id uuid.UUID) (*Game, error) {
+	return getGameById(ctx, id)
+}
+
+// Change creates, updates or deletes a game, with changes given as a merge patch validated like PATCH requests.
+// The id is ignored for inserts, the id of the changed game is returned.
+func Change(ctx context.Context, action audit.Action, id uuid.UUID, changes json.RawMessage, actor audit.Actor) (uuid.UUID, error) {
+	transaction, err := getTransaction(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer transaction.Rollback()
+	if id, err = ChangeWith(ctx, transaction, action, id, changes, actor); err != nil {
+		return uuid.Nil, err
+	}
+	return id, transaction.Commit()
+}
+
+// ChangeWith works like Change, but with the connector, which should be a transaction, so that the game can be changed along with other data.
+func ChangeWith(ctx context.Context, connector gotabase.Connector, action audit.Action, id uuid.UUID, changes json.RawMessage, actor audit.Actor) (uuid.UUID, error) {
+	return (proposalApplier{}).Apply(ctx, connector, action, id, changes, actor)
+}
@@ -2,9 +2,13 @@ package game
 
 import (
 	"fmt"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/auth"
+	"github.com/Geepr/game/proposal"
 	"github.com/Geepr/game/utils"
+	"github.com/Geepr/game/video"
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
+	"github.com/gofrs/uuid"
 	"net/http"
 )
 
@@ -15,15 +19,22 @@ func getRoute(c *gin.Context) {
 		PageIndex int       `form:"page"`
 		PageSize  int       `form:"size"`
 	}
-	if err := c.BindQuery(&query); err != nil {
-		log.Infof("Failed to bind game query: %s", err.Error())
-		c.AbortWithStatus(http.StatusBadRequest)
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.Logger(c).Infof("Failed to bind game query: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	if utils.WantsCsv(c) {
+		utils.ServeCsv(c, "games.csv", csvHeader, query.PageIndex, query.PageSize, func(pageIndex int, pageSize int) ([][]string, int, error) {
+			games, totalItems, err := getGames(c, query.Title, pageIndex, pageSize, query.SortOrder)
+			return toCsvRows(games), totalItems, err
+		})
 		return
 	}
 
-	games, totalItems, err := getGames(query.Title, query.PageIndex, query.PageSize, query.SortOrder)
+	games, totalItems, err := getGames(c, query.Title, query.PageIndex, query.PageSize, query.SortOrder)
 	if err != nil {
-		c.AbortWithStatus(http.StatusInternalServerError)
+		utils.AbortWithProblem(http.StatusInternalServerError, "", c)
 		return
 	}
 
@@ -44,27 +55,38 @@ func getRoute(c *gin.Context) {
 func getByIdRoute(c *gin.Context) {
 	lookupUuid, err := utils.ParseUuidFromParam(c)
 	if err != nil {
-		c.AbortWithStatus(http.StatusBadRequest)
+		utils.AbortWithBindingError(err, c)
 		return
 	}
 
-	game, err := getGameById(lookupUuid)
+	game, err := getGameById(c, lookupUuid)
 	if err != nil {
 		utils.AbortWithRelevantError(err, c)
 		return
 	}
+	if utils.AbortIfNotModified(c, game.Version) {
+		return
+	}
+	utils.SetETag(c, game.Version)
 
 	c.JSON(http.StatusOK, game)
 }
 
 func createRoute(c *gin.Context) {
 	var createModel struct {
-		Title       string `json:"title" binding:"required,max=200"`
-		Description string `json:"description" binding:"max=2000"`
+		Title       string              `json:"title" binding:"required,max=200"`
+		Description string              `json:"description" binding:"max=2000"`
+		Videos      []video.CreateModel `json:"videos" binding:"dive"`
 	}
-	if err := c.BindJSON(&createModel); err != nil {
-		log.Infof("Failed to parse game creation model: %s", err.Error())
-		c.AbortWithStatus(http.StatusBadRequest)
+	if err := c.ShouldBindJSON(&createModel); err != nil {
+		utils.Logger(c).Infof("Failed to parse game creation model: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	videos, err := video.FromCreateModels(createModel.Videos)
+	if err != nil {
+		utils.Logger(c).Infof("Failed to parse game videos: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
 		return
 	}
 
@@ -72,29 +94,43 @@ func createRoute(c *gin.Context) {
 		Title:       createModel.Title,
 		Description: utils.GetNilIfDefault(createModel.Description),
 		Archived:    false,
+		Videos:      videos,
 	}
-	if err := addGame(&game); err != nil {
+	if err := addGame(c, &game, audit.ActorFromContext(c)); err != nil {
 		utils.AbortWithRelevantError(err, c)
 		return
 	}
+	utils.SetETag(c, game.Version)
 
 	c.JSON(http.StatusCreated, &game)
 }
 
 func updateRoute(c *gin.Context) {
 	var updateModel struct {
-		Title       string `json:"title" binding:"required,max=200"`
-		Description string `json:"description" binding:"max=2000"`
-		Archived    bool   `json:"archived"`
+		Title       string              `json:"title" binding:"required,max=200"`
+		Description string              `json:"description" binding:"max=2000"`
+		Archived    bool                `json:"archived"`
+		Videos      []video.CreateModel `json:"videos" binding:"dive"`
 	}
-	if err := c.BindJSON(&updateModel); err != nil {
-		log.Infof("Failed to parse game updateRoute model: %s", err.Error())
-		c.AbortWithStatus(http.StatusBadRequest)
+	if err := c.ShouldBindJSON(&updateModel); err != nil {
+		utils.Logger(c).Infof("Failed to parse game updateRoute model: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
 		return
 	}
 	id, err := utils.ParseUuidFromParam(c)
 	if err != nil {
-		c.AbortWithStatus(http.StatusBadRequest)
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	version, err := utils.ParseIfMatch(c, currentVersion(c, id))
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+	videos, err := video.FromCreateModels(updateModel.Videos)
+	if err != nil {
+		utils.Logger(c).Infof("Failed to parse game videos: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
 		return
 	}
 
@@ -102,25 +138,75 @@ func updateRoute(c *gin.Context) {
 		Title:       updateModel.Title,
 		Description: utils.GetNilIfDefault(updateModel.Description),
 		Archived:    updateModel.Archived,
+		Videos:      videos,
+		Version:     version,
 	}
-	if err := updateGame(id, &game); err != nil {
+	if err := updateGame(c, id, &game, audit.ActorFromContext(c)); err != nil {
 		utils.AbortWithRelevantError(err, c)
 		return
 	}
+	utils.SetETag(c, game.Version)
 
 	// this is here just so that it displays properly when returned to the user
 	game.Id = id
 	c.JSON(http.StatusOK, &game)
 }
 
+// patchRoute applies an RFC 7396 merge patch to the game.
+// The patch is applied to the current state, so the update fails if the game is changed in the meantime, even with If-Match: *.
+func patchRoute(c *gin.Context) {
+	id, err := utils.ParseUuidFromParam(c)
+	if err != nil {
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	version, err := utils.ParseIfMatch(c, currentVersion(c, id))
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+	patch, err := c.GetRawData()
+	if err != nil {
+		utils.Logger(c).Infof("Failed to read game patch: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+
+	game, err := getGameById(c, id)
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+	if version != 0 && version != game.Version {
+		utils.AbortWithRelevantError(utils.VersionMismatchErr, c)
+		return
+	}
+	if err := applyPatch(c, game, patch); err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+	if err := updateGame(c, id, game, audit.ActorFromContext(c)); err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+
+	utils.SetETag(c, game.Version)
+	c.JSON(http.StatusOK, game)
+}
+
 func deleteRoute(c *gin.Context) {
 	id, err := utils.ParseUuidFromParam(c)
 	if err != nil {
-		c.AbortWithStatus(http.StatusBadRequest)
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	version, err := utils.ParseIfMatch(c, currentVersion(c, id))
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
 		return
 	}
 
-	err = deleteGame(id)
+	err = deleteGame(c, id, version, audit.ActorFromContext(c))
 	if err != nil {
 		utils.AbortWithRelevantError(err, c)
 		return
@@ -129,12 +215,53 @@ func deleteRoute(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+func revertRoute(c *gin.Context) {
+	id, err := utils.ParseUuidFromParam(c)
+	if err != nil {
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	revision, err := audit.ParseRevisionFromParam(c)
+	if err != nil {
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+
+	if err := revertGame(c, id, revision, audit.ActorFromContext(c)); err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+
+	game, err := getGameById(c, id)
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+	utils.SetETag(c, game.Version)
+	c.JSON(http.StatusOK, game)
+}
+
+// currentVersion reads the version of the game, for If-Match headers listing several tags.
+func currentVersion(c *gin.Context, id uuid.UUID) func() (int, error) {
+	return func() (int, error) {
+		game, err := getGameById(c, id)
+		if err != nil {
+			return 0, err
+		}
+		return game.Version, nil
+	}
+}
+
 func SetupRoutes(engine *gin.Engine, basePath string) {
 	baseUrl := fmt.Sprintf("%s/api/v0/games", basePath)
+	proposal.RegisterApplier(audit.EntityGame, proposalApplier{})
 
 	engine.GET(baseUrl, getRoute)
 	engine.GET(baseUrl+"/:id", getByIdRoute)
-	engine.POST(baseUrl, createRoute)
-	engine.PUT(baseUrl+"/:id", updateRoute)
-	engine.DELETE(baseUrl+"/:id", deleteRoute)
+	engine.POST(baseUrl, auth.Require(auth.ResourceGame, auth.ActionCreate), createRoute)
+	engine.PUT(baseUrl+"/:id", auth.Require(auth.ResourceGame, auth.ActionUpdate), updateRoute)
+	engine.PATCH(baseUrl+"/:id", auth.Require(auth.ResourceGame, auth.ActionUpdate), patchRoute)
+	engine.DELETE(baseUrl+"/:id", auth.Require(auth.ResourceGame, auth.ActionDelete), deleteRoute)
+	engine.GET(baseUrl+"/:id/history", auth.Require(auth.ResourceAudit, auth.ActionRead), audit.HistoryRoute(audit.EntityGame))
+	engine.POST(baseUrl+"/:id/revisions/:rev/revert", auth.Require(auth.ResourceGame, auth.ActionUpdate), revertRoute)
 }
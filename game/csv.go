@@ -0,0 +1,17 @@
+package game
+
+import (
+	"github.com/Geepr/game/utils"
+	"strconv"
+)
+
+// csvHeader names columns the same way as csv imports do, so that exported games can be imported back.
+var csvHeader = []string{"id", "gameTitle", "gameDescription", "archived", "gameExternalId", "version"}
+
+func toCsvRows(games []*Game) [][]string {
+	rows := make([][]string, len(games))
+	for i, game := range games {
+		rows[i] = []string{game.Id.String(), utils.CsvText(game.Title), utils.CsvValue(game.Description), strconv.FormatBool(game.Archived), utils.CsvValue(game.ExternalId), strconv.Itoa(game.Version)}
+	}
+	return rows
+}
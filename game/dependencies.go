@@ -1,7 +1,14 @@
 package game
 
-import "github.com/KowalskiPiotr98/gotabase"
+import (
+	"context"
+	"github.com/Geepr/game/tracing"
+	"github.com/KowalskiPiotr98/gotabase"
+)
 
 var (
-	getConnector = func() gotabase.Connector { return gotabase.GetConnection() }
+	getConnector = func(ctx context.Context) gotabase.Connector {
+		return tracing.WrapConnector(ctx, gotabase.GetConnection())
+	}
+	getTransaction = func(ctx context.Context) (*tracing.Transaction, error) { return tracing.BeginTransaction(ctx) }
 )
@@ -1,6 +1,8 @@
 package game
 
 import (
+	"github.com/Geepr/game/language"
+	"github.com/Geepr/game/video"
 	"github.com/gofrs/uuid"
 )
 
@@ -14,4 +16,15 @@ type Game struct {
 	// Archived games are generally hidden from most views, but not removed outright.
 	// This allows users to hide certain titles but keep the data for future reference.
 	Archived bool `json:"archived"`
+	// ExternalId identifies the game in an external source, like a store, it's set by imports to match games on repeated runs.
+	// Once set, it can be changed with merge patches, but not removed.
+	ExternalId *string `json:"externalId"`
+	// Videos contains trailers and other videos related to the game as a whole, rather than to a specific release.
+	Videos []*video.Video `json:"videos"`
+	// Languages aggregates language support of all releases of the game.
+	// It's only loaded when a single game is requested.
+	Languages []*language.Support `json:"languages,omitempty"`
+	// Version is incremented with every change of the game, it's also returned in the ETag header.
+	// When updating, a non-zero version is required to match the stored one.
+	Version int `json:"version"`
 }
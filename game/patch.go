@@ -0,0 +1,30 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Geepr/game/utils"
+)
+
+// patchModel lists the fields of a game that can be changed by merge patches, both in PATCH requests and change proposals.
+// Videos are left out, they can only be replaced as a whole with PUT.
+type patchModel struct {
+	Title       string  `json:"title" binding:"required,max=200"`
+	Description *string `json:"description" binding:"omitempty,max=2000"`
+	Archived    bool    `json:"archived"`
+	// ExternalId can be set or changed, but not removed, so that imports keep matching the game.
+	ExternalId *string `json:"externalId" binding:"omitempty,min=1,max=100"`
+}
+
+// applyPatch changes the game according to a merge patch, leaving it untouched if the result is not valid.
+func applyPatch(ctx context.Context, game *Game, patch json.RawMessage) error {
+	model := patchModel{Title: game.Title, Description: game.Description, Archived: game.Archived, ExternalId: game.ExternalId}
+	if err := utils.ApplyMergePatch(ctx, &model, patch); err != nil {
+		return err
+	}
+	game.Title, game.Description, game.Archived = model.Title, model.Description, model.Archived
+	if model.ExternalId != nil {
+		game.ExternalId = model.ExternalId
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Geepr/game/audit"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/gofrs/uuid"
+)
+
+type proposalApplier struct{}
+
+func (proposalApplier) Check(ctx context.Context, action audit.Action, id uuid.UUID, changes json.RawMessage) error {
+	_, err := getProposedGame(ctx, getConnector(ctx), action, id, changes)
+	return err
+}
+
+func (proposalApplier) Apply(ctx context.Context, connector gotabase.Connector, action audit.Action, id uuid.UUID, changes json.RawMessage, actor audit.Actor) (uuid.UUID, error) {
+	game, err := getProposedGame(ctx, connector, action, id, changes)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	switch action {
+	case audit.ActionInsert:
+		err = addGameWith(ctx, connector, game, actor)
+	case audit.ActionUpdate:
+		err = updateGameWith(ctx, connector, id, game, actor)
+	default:
+		err = deleteGameWith(ctx, connector, id, game.Version, actor)
+	}
+	return game.Id, err
+}
+
+// getProposedGame returns the game as it would be after applying the changes.
+func getProposedGame(ctx context.Context, connector gotabase.Connector, action audit.Action, id uuid.UUID, changes json.RawMessage) (*Game, error) {
+	game := &Game{}
+	if action != audit.ActionInsert {
+		var err error
+		if game, err = getGameByIdWith(ctx, connector, id); err != nil {
+			return nil, err
+		}
+	}
+	if action == audit.ActionDelete {
+		return game, nil
+	}
+	if err := applyPatch(ctx, game, changes); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
@@ -1,12 +1,19 @@
 package game
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/language"
+	"github.com/Geepr/game/metrics"
 	"github.com/Geepr/game/utils"
+	"github.com/Geepr/game/video"
 	"github.com/KowalskiPiotr98/gotabase"
 	"github.com/gofrs/uuid"
-	log "github.com/sirupsen/logrus"
 	"strings"
+	"time"
 )
 
 type SortOrder uint8
@@ -16,81 +23,156 @@ const (
 	SortByTitle
 )
 
-func getGames(titleQuery string, pageIndex int, pageSize int, order SortOrder) ([]*Game, int, error) {
-	query := "select id, title, description, archived from games"
+func getGames(ctx context.Context, titleQuery string, pageIndex int, pageSize int, order SortOrder) (_ []*Game, _ int, err error) {
+	defer metrics.ObserveQuery("getGames", time.Now(), &err)
+	query := "select id, title, description, archived, external_id, version from games"
 	query, args := utils.AppendWhereClause(query, "title_normalised", "like", utils.MakeLikeQuery(strings.ToUpper(titleQuery)), utils.IsStringNotEmpty, []any{})
 	query += fmt.Sprintf(" order by %s", order.getSqlColumnName())
 	query, countQuery, err := utils.Paginate(query, pageIndex, pageSize)
 	if err != nil {
 		return nil, 0, err
 	}
-	countResults, err := utils.ScanCountQuery(getConnector(), countQuery, args...)
+	countResults, err := utils.ScanCountQuery(ctx, getConnector(ctx), countQuery, args...)
 	if err != nil {
 		return nil, 0, err
 	}
-	results, err := scanGames(query, args...)
-	return results, countResults, err
+	results, err := scanGames(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return results, countResults, attachVideos(ctx, getConnector(ctx), results...)
+}
+
+func getGameById(ctx context.Context, id uuid.UUID) (*Game, error) {
+	return getGameByIdWith(ctx, getConnector(ctx), id)
 }
 
-func getGameById(id uuid.UUID) (*Game, error) {
-	query := "select id, title, description, archived from games where id = $1"
-	return scanGame(query, id)
+// getGameByIdWith reads the game with the connector, which sees changes made earlier in its transaction.
+func getGameByIdWith(ctx context.Context, connector gotabase.Connector, id uuid.UUID) (_ *Game, err error) {
+	defer metrics.ObserveQuery("getGameById", time.Now(), &err)
+	query := "select id, title, description, archived, external_id, version from games where id = $1"
+	game, err := scanGame(ctx, connector, query, id)
+	if err != nil {
+		return nil, err
+	}
+	if game.Languages, err = language.GetAggregatedForGame(ctx, connector, id); err != nil {
+		return nil, err
+	}
+	return game, attachVideos(ctx, connector, game)
 }
 
-func addGame(game *Game) error {
-	query := "insert into games (title, description, archived) VALUES ($1, $2, $3) returning id"
-	result, err := getConnector().QueryRow(query, game.Title, game.Description, game.Archived)
+func addGame(ctx context.Context, game *Game, actor audit.Actor) error {
+	transaction, err := getTransaction(ctx)
 	if err != nil {
-		log.Warnf("Failed to execute insert query on games table: %s", err.Error())
 		return err
 	}
-	if err = result.Scan(&game.Id); err != nil {
+	defer transaction.Rollback()
+	if err = addGameWith(ctx, transaction, game, actor); err != nil {
 		return err
 	}
-	return nil
+	return transaction.Commit()
 }
 
-func updateGame(id uuid.UUID, updatedGame *Game) error {
-	query := "update games set title = $2, description = $3, archived = $4 where id = $1"
-	result, err := getConnector().Exec(query, id, updatedGame.Title, updatedGame.Description, updatedGame.Archived)
+// addGameWith stores the game with the connector, which should be a transaction, so that the game isn't stored without its videos and audit entry.
+func addGameWith(ctx context.Context, connector gotabase.Connector, game *Game, actor audit.Actor) (err error) {
+	defer metrics.ObserveQuery("addGame", time.Now(), &err)
+	query := "insert into games (title, description, archived, external_id) VALUES ($1, $2, $3, $4) returning id, version"
+	result, err := connector.QueryRow(query, game.Title, game.Description, game.Archived, game.ExternalId)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute insert query on games table: %s", err.Error())
+		return utils.ConvertIfDuplicateErr(err)
+	}
+	if err = result.Scan(&game.Id, &game.Version); err != nil {
+		return utils.ConvertIfDuplicateErr(err)
+	}
+	if err = video.ReplaceForGame(ctx, connector, game.Id, game.Videos); err != nil {
+		return err
+	}
+	return audit.Record(ctx, connector, audit.EntityGame, game.Id, audit.ActionInsert, actor, nil)
+}
 
+func updateGame(ctx context.Context, id uuid.UUID, updatedGame *Game, actor audit.Actor) error {
+	transaction, err := getTransaction(ctx)
 	if err != nil {
-		log.Warnf("Failed to execute update query on games table: %s", err.Error())
 		return err
 	}
-	affected, err := result.RowsAffected()
+	defer transaction.Rollback()
+	if err = updateGameWith(ctx, transaction, id, updatedGame, actor); err != nil {
+		return err
+	}
+	return transaction.Commit()
+}
+
+// updateGameWith changes the game with the connector, which should be a transaction, like addGameWith.
+// The external id is only changed when set, as PUT requests don't carry it.
+func updateGameWith(ctx context.Context, connector gotabase.Connector, id uuid.UUID, updatedGame *Game, actor audit.Actor) (err error) {
+	defer metrics.ObserveQuery("updateGame", time.Now(), &err)
+	query := "update games set title = $2, description = $3, archived = $4, external_id = coalesce($6, external_id), version = version + 1 where id = $1 and ($5 = 0 or version = $5) returning version"
+	before, err := audit.Snapshot(ctx, connector, audit.EntityGame, id)
 	if err != nil {
-		log.Warnf("Failed to get affected rows count when running update query on games table: %s", err.Error())
 		return err
 	}
-	if affected != 1 {
-		return utils.DataNotFoundErr
+	result, err := connector.QueryRow(query, id, updatedGame.Title, updatedGame.Description, updatedGame.Archived, updatedGame.Version, updatedGame.ExternalId)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute update query on games table: %s", err.Error())
+		return utils.ConvertIfDuplicateErr(err)
 	}
-	return nil
+	if err = result.Scan(&updatedGame.Version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.ConvertIfVersionMismatchErr(err, before != nil)
+		}
+		return utils.ConvertIfDuplicateErr(err)
+	}
+	if err = video.ReplaceForGame(ctx, connector, id, updatedGame.Videos); err != nil {
+		return err
+	}
+	return audit.Record(ctx, connector, audit.EntityGame, id, audit.ActionUpdate, actor, before)
 }
 
-func deleteGame(id uuid.UUID) error {
-	query := "delete from games where id = $1"
-	result, err := getConnector().Exec(query, id)
+// deleteGame removes the game, a non-zero version is required to match the stored one.
+func deleteGame(ctx context.Context, id uuid.UUID, version int, actor audit.Actor) error {
+	transaction, err := getTransaction(ctx)
 	if err != nil {
-		log.Warnf("Failed to execute delete query on games table: %s", err.Error())
+		return err
+	}
+	defer transaction.Rollback()
+	if err = deleteGameWith(ctx, transaction, id, version, actor); err != nil {
+		return err
+	}
+	return transaction.Commit()
+}
+
+// deleteGameWith removes the game with the connector, which should be a transaction, like addGameWith.
+func deleteGameWith(ctx context.Context, connector gotabase.Connector, id uuid.UUID, version int, actor audit.Actor) (err error) {
+	defer metrics.ObserveQuery("deleteGame", time.Now(), &err)
+	query := "delete from games where id = $1 and ($2 = 0 or version = $2)"
+	before, err := audit.Snapshot(ctx, connector, audit.EntityGame, id)
+	if err != nil {
+		return err
+	}
+	result, err := connector.Exec(query, id, version)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute delete query on games table: %s", err.Error())
 		return err
 	}
 	affected, err := result.RowsAffected()
 	if err != nil {
-		log.Warnf("Failed to get affected rows count when running deleteRoute query on games table: %s", err.Error())
+		utils.Logger(ctx).Warnf("Failed to get affected rows count when running deleteRoute query on games table: %s", err.Error())
 		return err
 	}
 	if affected != 1 {
+		if before != nil {
+			return utils.VersionMismatchErr
+		}
 		return utils.DataNotFoundErr
 	}
-	return nil
+	return audit.Record(ctx, connector, audit.EntityGame, id, audit.ActionDelete, actor, before)
 }
 
-func scanGames(sql string, args ...interface{}) ([]*Game, error) {
-	result, err := getConnector().QueryRows(sql, args...)
+func scanGames(ctx context.Context, sql string, args ...interface{}) ([]*Game, error) {
+	result, err := getConnector(ctx).QueryRows(sql, args...)
 	if err != nil {
-		log.Warnf("Failed to run query on games table: %s", err.Error())
+		utils.Logger(ctx).Warnf("Failed to run query on games table: %s", err.Error())
 		return nil, err
 	}
 	defer result.Close()
@@ -107,10 +189,10 @@ func scanGames(sql string, args ...interface{}) ([]*Game, error) {
 	return games, nil
 }
 
-func scanGame(sql string, args ...interface{}) (*Game, error) {
-	result, err := getConnector().QueryRow(sql, args...)
+func scanGame(ctx context.Context, connector gotabase.Connector, sql string, args ...interface{}) (*Game, error) {
+	result, err := connector.QueryRow(sql, args...)
 	if err != nil {
-		log.Warnf("Failed to run query on games table: %s", err.Error())
+		utils.Logger(ctx).Warnf("Failed to run query on games table: %s", err.Error())
 		return nil, err
 	}
 	return scanRow(result)
@@ -118,12 +200,30 @@ func scanGame(sql string, args ...interface{}) (*Game, error) {
 
 func scanRow(row gotabase.Row) (*Game, error) {
 	game := Game{}
-	if err := row.Scan(&game.Id, &game.Title, &game.Description, &game.Archived); err != nil {
+	if err := row.Scan(&game.Id, &game.Title, &game.Description, &game.Archived, &game.ExternalId, &game.Version); err != nil {
 		return nil, utils.ConvertIfNotFoundErr(err)
 	}
 	return &game, nil
 }
 
+func attachVideos(ctx context.Context, connector gotabase.Connector, games ...*Game) error {
+	ids := make([]uuid.UUID, len(games))
+	for i, game := range games {
+		ids[i] = game.Id
+	}
+	videos, err := video.GetForGames(ctx, connector, ids)
+	if err != nil {
+		return err
+	}
+	for _, game := range games {
+		game.Videos = videos[game.Id]
+		if game.Videos == nil {
+			game.Videos = make([]*video.Video, 0)
+		}
+	}
+	return nil
+}
+
 func (o SortOrder) getSqlColumnName() string {
 	switch o {
 	case SortById:
@@ -133,3 +233,40 @@ func (o SortOrder) getSqlColumnName() string {
 	}
 	return "id"
 }
+
+// revertGame restores the fields and videos of a game to the state recorded by an audit revision.
+// Games deleted since are created again, with the same id.
+func revertGame(ctx context.Context, id uuid.UUID, revision int64, actor audit.Actor) (err error) {
+	defer metrics.ObserveQuery("revertGame", time.Now(), &err)
+	transaction, err := getTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	defer transaction.Rollback()
+	state, err := audit.GetRevision(ctx, transaction, audit.EntityGame, id, revision)
+	if err != nil {
+		return err
+	}
+	before, err := audit.Snapshot(ctx, transaction, audit.EntityGame, id)
+	if err != nil {
+		return err
+	}
+	// populating the record over the current row keeps the values of columns missing in older revisions
+	query := "update games g set version = g.version + 1, (title, description, archived, external_id) = (select r.title, r.description, r.archived, r.external_id from jsonb_populate_record(g, $2) r) where g.id = $1"
+	action := audit.ActionUpdate
+	if before == nil {
+		query = "insert into games (id, title, description, archived, external_id) select $1, r.title, r.description, r.archived, r.external_id from jsonb_populate_record(null::games, $2) r"
+		action = audit.ActionInsert
+	}
+	if _, err = transaction.Exec(query, id, string(state)); err != nil {
+		utils.Logger(ctx).Warnf("Failed to revert game: %s", err.Error())
+		return err
+	}
+	if err = audit.RestoreOwned(ctx, transaction, state, "videos", "videos", "game_id", id, "provider", "video_id", "kind", "language", "published_at"); err != nil {
+		return err
+	}
+	if err = audit.Record(ctx, transaction, audit.EntityGame, id, action, actor, before); err != nil {
+		return err
+	}
+	return transaction.Commit()
+}
@@ -1,8 +1,12 @@
 package game
 
 import (
+	"context"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/language"
 	"github.com/Geepr/game/mocks"
 	"github.com/Geepr/game/utils"
+	"github.com/Geepr/game/video"
 	"github.com/KowalskiPiotr98/gotabase"
 	"github.com/gofrs/uuid"
 	"strings"
@@ -21,7 +25,7 @@ func newGameRepoTest(t *testing.T) *gameRepoTest {
 		connection: db,
 		dbName:     name,
 	}
-	getConnector = func() gotabase.Connector { return db }
+	getConnector = func(context.Context) gotabase.Connector { return db }
 	t.Cleanup(test.cleanup)
 	return test
 }
@@ -65,7 +69,7 @@ func TestGameRepository_GetGames_NoParametersSet_ReturnsAllGames(t *testing.T) {
 	test := newGameRepoTest(t)
 	test.insertMockData()
 
-	result, count, err := getGames("", 0, 100, SortById)
+	result, count, err := getGames(context.Background(), "", 0, 100, SortById)
 
 	mocks.AssertDefault(t, err)
 	mocks.AssertEquals(t, count, 4)
@@ -81,7 +85,7 @@ func TestGameRepository_GetGames_TitleQueryDefined_ReturnsMatching(t *testing.T)
 	test := newGameRepoTest(t)
 	test.insertMockData()
 
-	result, count, err := getGames("Aa", 0, 100, SortById)
+	result, count, err := getGames(context.Background(), "Aa", 0, 100, SortById)
 
 	mocks.AssertDefault(t, err)
 	mocks.AssertCountEqual(t, result, 2)
@@ -100,7 +104,7 @@ func TestGameRepository_GetGames_TitleQueryDefinedAndNotFound_ReturnsEmpty(t *te
 	test := newGameRepoTest(t)
 	test.insertMockData()
 
-	result, count, err := getGames("definitely not found", 0, 100, SortById)
+	result, count, err := getGames(context.Background(), "definitely not found", 0, 100, SortById)
 
 	mocks.AssertDefault(t, err)
 	mocks.AssertCountEqual(t, result, 0)
@@ -114,7 +118,7 @@ func TestGameRepository_GetGameById_GameIdValid_GameReturned(t *testing.T) {
 	for _, testCaseGlobal := range test.mockData {
 		testCase := testCaseGlobal
 		t.Run(testCase.Id.String(), func(t *testing.T) {
-			result, err := getGameById(testCase.Id)
+			result, err := getGameById(context.Background(), testCase.Id)
 
 			mocks.AssertDefault(t, err)
 			mocks.AssertEquals(t, result.Id, testCase.Id)
@@ -123,12 +127,31 @@ func TestGameRepository_GetGameById_GameIdValid_GameReturned(t *testing.T) {
 	}
 }
 
+func TestGameRepository_GetGameById_ReleasesWithLanguages_LanguagesAggregated(t *testing.T) {
+	test := newGameRepoTest(t)
+	test.insertMockData()
+	release1, _ := uuid.NewV4()
+	release2, _ := uuid.NewV4()
+	_, err := test.connection.Exec("insert into game_releases (id, game_id, release_date_unknown) values ($1, $3, true), ($2, $3, true)", release1, release2, test.mockData[0].Id)
+	mocks.PanicOnErr(err)
+	_, err = test.connection.Exec("insert into game_release_languages (game_release_id, language, interface, audio, subtitles) values "+
+		"($1, 'en', true, true, false), ($2, 'en', false, false, true), ($2, 'ja', true, false, false)", release1, release2)
+	mocks.PanicOnErr(err)
+
+	result, err := getGameById(context.Background(), test.mockData[0].Id)
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertCountEqual(t, result.Languages, 2)
+	mocks.AssertEquals(t, *result.Languages[0], language.Support{Language: "en", Interface: true, Audio: true, Subtitles: true})
+	mocks.AssertEquals(t, *result.Languages[1], language.Support{Language: "ja", Interface: true})
+}
+
 func TestGameRepository_GetGameById_GameIdNotFound_ReturnsSpecificError(t *testing.T) {
 	test := newGameRepoTest(t)
 	test.insertMockData()
 	testId, _ := uuid.NewV4()
 
-	_, err := getGameById(testId)
+	_, err := getGameById(context.Background(), testId)
 
 	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
 }
@@ -140,12 +163,35 @@ func TestGameRepository_AddGame_NewName_GameAdded(t *testing.T) {
 		Title: "totally new and unique title",
 	}
 
-	err := addGame(&newGame)
+	err := addGame(context.Background(), &newGame, audit.System)
 
 	mocks.AssertDefault(t, err)
 	mocks.AssertNotDefault(t, newGame.Id)
 }
 
+func TestGameRepository_AddGame_WithVideos_VideosStored(t *testing.T) {
+	test := newGameRepoTest(t)
+	test.insertMockData()
+	videos, _ := video.FromCreateModels([]video.CreateModel{
+		{Url: "https://youtu.be/dQw4w9WgXcQ", Kind: video.KindTrailer},
+		{Url: "https://vimeo.com/76979871", Kind: video.KindGameplay, Language: "ja"},
+	})
+	newGame := Game{
+		Title:  "game with videos",
+		Videos: videos,
+	}
+
+	err := addGame(context.Background(), &newGame, audit.System)
+
+	mocks.AssertDefault(t, err)
+	loaded, err := getGameById(context.Background(), newGame.Id)
+	mocks.AssertDefault(t, err)
+	mocks.AssertCountEqual(t, loaded.Videos, 2)
+	mocks.AssertArrayContains(t, loaded.Videos, func(value *video.Video) bool {
+		return value.Provider == video.ProviderVimeo && value.VideoId == "76979871" && *value.Language == "ja"
+	})
+}
+
 func TestGameRepository_UpdateGame_GameExists_Updates(t *testing.T) {
 	test := newGameRepoTest(t)
 	test.insertMockData()
@@ -155,10 +201,10 @@ func TestGameRepository_UpdateGame_GameExists_Updates(t *testing.T) {
 	modified.Description = &desc
 	modified.Archived = true
 
-	err := updateGame(modified.Id, modified)
+	err := updateGame(context.Background(), modified.Id, modified, audit.System)
 
 	mocks.AssertDefault(t, err)
-	loaded, _ := getGameById(modified.Id)
+	loaded, _ := getGameById(context.Background(), modified.Id)
 	mocks.AssertEquals(t, loaded.Id, modified.Id)
 	mocks.AssertEquals(t, loaded.Title, modified.Title)
 	mocks.AssertEquals(t, *loaded.Description, *modified.Description)
@@ -171,7 +217,7 @@ func TestGameRepository_UpdateGame_GameMissing_ReturnsNotFound(t *testing.T) {
 	fakeId, _ := uuid.NewV4()
 	modified := test.mockData[0]
 
-	err := updateGame(fakeId, modified)
+	err := updateGame(context.Background(), fakeId, modified, audit.System)
 
 	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
 }
@@ -181,10 +227,10 @@ func TestGameRepository_DeleteGame_GameExists_RemovesGame(t *testing.T) {
 	test.insertMockData()
 	toDelete := test.mockData[2]
 
-	err := deleteGame(toDelete.Id)
+	err := deleteGame(context.Background(), toDelete.Id, 0, audit.System)
 
 	mocks.AssertDefault(t, err)
-	_, err = getGameById(toDelete.Id)
+	_, err = getGameById(context.Background(), toDelete.Id)
 	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
 }
 
@@ -193,7 +239,86 @@ func TestGameRepository_DeleteGame_MissingId_ReturnsNotFound(t *testing.T) {
 	test.insertMockData()
 	fakeId, _ := uuid.NewV4()
 
-	err := deleteGame(fakeId)
+	err := deleteGame(context.Background(), fakeId, 0, audit.System)
 
 	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
 }
+
+func TestGameRepository_UpdateGame_GameExists_ChangeRecorded(t *testing.T) {
+	test := newGameRepoTest(t)
+	test.insertMockData()
+	modified := test.mockData[0]
+	oldTitle := modified.Title
+	modified.Title = "new title"
+
+	err := updateGame(context.Background(), modified.Id, modified, audit.System)
+
+	mocks.AssertDefault(t, err)
+	var before, after string
+	row, err := test.connection.QueryRow("select before ->> 'title', after ->> 'title' from audit_log where entity_type = 'game' and entity_id = $1 and action = 'update'", modified.Id)
+	mocks.PanicOnErr(err)
+	mocks.PanicOnErr(row.Scan(&before, &after))
+	mocks.AssertEquals(t, before, oldTitle)
+	mocks.AssertEquals(t, after, modified.Title)
+}
+
+func TestGameRepository_RevertGame_Deleted_GameRecreated(t *testing.T) {
+	test := newGameRepoTest(t)
+	test.insertMockData()
+	modified := test.mockData[1]
+	modified.Title = "new title"
+	mocks.PanicOnErr(updateGame(context.Background(), modified.Id, modified, audit.System))
+	var revision int64
+	row, err := test.connection.QueryRow("select max(id) from audit_log")
+	mocks.PanicOnErr(err)
+	mocks.PanicOnErr(row.Scan(&revision))
+	mocks.PanicOnErr(deleteGame(context.Background(), modified.Id, 0, audit.System))
+
+	err = revertGame(context.Background(), modified.Id, revision, audit.System)
+
+	mocks.AssertDefault(t, err)
+	loaded, err := getGameById(context.Background(), modified.Id)
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, loaded.Title, modified.Title)
+}
+
+func TestGameRepository_UpdateGame_MatchingVersion_VersionIncremented(t *testing.T) {
+	test := newGameRepoTest(t)
+	test.insertMockData()
+	modified := test.mockData[0]
+	modified.Version = 1
+
+	err := updateGame(context.Background(), modified.Id, modified, audit.System)
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, modified.Version, 2)
+	loaded, _ := getGameById(context.Background(), modified.Id)
+	mocks.AssertEquals(t, loaded.Version, 2)
+}
+
+func TestGameRepository_UpdateGame_StaleVersion_ReturnsVersionMismatch(t *testing.T) {
+	test := newGameRepoTest(t)
+	test.insertMockData()
+	modified := test.mockData[0]
+	mocks.PanicOnErr(updateGame(context.Background(), modified.Id, modified, audit.System))
+	modified.Version = 1
+	modified.Title = "stale"
+
+	err := updateGame(context.Background(), modified.Id, modified, audit.System)
+
+	mocks.AssertEquals(t, err, utils.VersionMismatchErr)
+	loaded, _ := getGameById(context.Background(), modified.Id)
+	mocks.AssertEquals(t, loaded.Title == "stale", false)
+}
+
+func TestGameRepository_DeleteGame_StaleVersion_ReturnsVersionMismatch(t *testing.T) {
+	test := newGameRepoTest(t)
+	test.insertMockData()
+	toDelete := test.mockData[2]
+
+	err := deleteGame(context.Background(), toDelete.Id, 5, audit.System)
+
+	mocks.AssertEquals(t, err, utils.VersionMismatchErr)
+	_, err = getGameById(context.Background(), toDelete.Id)
+	mocks.AssertDefault(t, err)
+}
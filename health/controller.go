@@ -0,0 +1,62 @@
+package health
+
+import (
+	"fmt"
+	"github.com/Geepr/game/database"
+	"github.com/Geepr/game/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+// readinessTimeout limits how long the database is waited for, as the driver calls can't be cancelled.
+const readinessTimeout = 2 * time.Second
+
+func livenessRoute(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+type schemaVersions struct {
+	applied int
+	latest  int
+	err     error
+}
+
+// readinessRoute reports the service as ready once the database is reachable and has all migrations of this build applied.
+func readinessRoute(c *gin.Context) {
+	checked := make(chan schemaVersions, 1)
+	go func() {
+		applied, latest, err := database.GetSchemaVersions(getConnector())
+		checked <- schemaVersions{applied: applied, latest: latest, err: err}
+	}()
+
+	var versions schemaVersions
+	select {
+	case versions = <-checked:
+	case <-time.After(readinessTimeout):
+		utils.AbortWithProblem(http.StatusServiceUnavailable, "database did not respond in time", c)
+		return
+	}
+	if versions.err != nil {
+		utils.Logger(c).Warnf("Readiness check failed to read schema version: %s", versions.err.Error())
+		utils.AbortWithProblem(http.StatusServiceUnavailable, "database is not reachable", c)
+		return
+	}
+	if versions.applied < versions.latest {
+		utils.AbortWithProblem(http.StatusServiceUnavailable, fmt.Sprintf("database schema version %d is behind the latest migration %d", versions.applied, versions.latest), c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ready", "schemaVersion": versions.applied})
+}
+
+func versionRoute(c *gin.Context) {
+	c.JSON(http.StatusOK, getBuildInfo())
+}
+
+// SetupRoutes registers the health and version routes outside the base path, as they are meant for orchestration rather than api users.
+func SetupRoutes(engine *gin.Engine) {
+	engine.GET("/healthz", livenessRoute)
+	engine.GET("/readyz", readinessRoute)
+	engine.GET("/version", versionRoute)
+}
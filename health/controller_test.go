@@ -0,0 +1,86 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/Geepr/game/mocks"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeConnector answers the schema version query with a fixed migration id, or fails all queries if err is set.
+type fakeConnector struct {
+	applied int
+	err     error
+}
+
+type fakeRow struct {
+	value int
+	err   error
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	*(dest[0].(*int)) = r.value
+	return nil
+}
+
+func (c fakeConnector) QueryRow(_ string, _ ...interface{}) (gotabase.Row, error) {
+	return fakeRow{value: c.applied, err: c.err}, nil
+}
+func (c fakeConnector) QueryRows(_ string, _ ...interface{}) (gotabase.Rows, error) {
+	return nil, c.err
+}
+func (c fakeConnector) Exec(_ string, _ ...interface{}) (gotabase.Result, error) { return nil, c.err }
+
+func serveReadiness(t *testing.T, connector gotabase.Connector) *httptest.ResponseRecorder {
+	getConnector = func() gotabase.Connector { return connector }
+	t.Cleanup(func() { getConnector = func() gotabase.Connector { return gotabase.GetConnection() } })
+	engine := gin.New()
+	SetupRoutes(engine)
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	return recorder
+}
+
+func TestReadinessRoute_SchemaUpToDate_Ready(t *testing.T) {
+	recorder := serveReadiness(t, fakeConnector{applied: 1000})
+
+	mocks.AssertEquals(t, recorder.Code, http.StatusOK)
+	var body struct {
+		SchemaVersion int `json:"schemaVersion"`
+	}
+	mocks.PanicOnErr(json.Unmarshal(recorder.Body.Bytes(), &body))
+	mocks.AssertEquals(t, body.SchemaVersion, 1000)
+}
+
+func TestReadinessRoute_SchemaBehind_Unavailable(t *testing.T) {
+	recorder := serveReadiness(t, fakeConnector{applied: 0})
+
+	mocks.AssertEquals(t, recorder.Code, http.StatusServiceUnavailable)
+	mocks.AssertEquals(t, recorder.Header().Get("Content-Type"), "application/problem+json")
+}
+
+func TestReadinessRoute_DatabaseDown_Unavailable(t *testing.T) {
+	recorder := serveReadiness(t, fakeConnector{err: errors.New("connection refused")})
+
+	mocks.AssertEquals(t, recorder.Code, http.StatusServiceUnavailable)
+}
+
+func TestVersionRoute_Always_ReturnsVersion(t *testing.T) {
+	engine := gin.New()
+	SetupRoutes(engine)
+	recorder := httptest.NewRecorder()
+
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	var info BuildInfo
+	mocks.PanicOnErr(json.Unmarshal(recorder.Body.Bytes(), &info))
+	mocks.AssertEquals(t, info.Version, Version)
+	mocks.AssertNotDefault(t, info.GoVersion)
+}
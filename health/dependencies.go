@@ -0,0 +1,7 @@
+package health
+
+import "github.com/KowalskiPiotr98/gotabase"
+
+var (
+	getConnector = func() gotabase.Connector { return gotabase.GetConnection() }
+)
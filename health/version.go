@@ -0,0 +1,40 @@
+package health
+
+import (
+	"runtime/debug"
+)
+
+// Version is the released version of the service, meant to be set when building:
+//
+//	go build -ldflags "-X github.com/Geepr/game/health.Version=1.2.0"
+var Version = "dev"
+
+// BuildInfo describes the running binary.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	CommitAt  string `json:"commitTime,omitempty"`
+	Modified  bool   `json:"modified"`
+	GoVersion string `json:"goVersion"`
+}
+
+// getBuildInfo reads the vcs details stamped by the go toolchain, which are only present when building from a git checkout.
+func getBuildInfo() BuildInfo {
+	info := BuildInfo{Version: Version}
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.GoVersion = buildInfo.GoVersion
+	for _, setting := range buildInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Commit = setting.Value
+		case "vcs.time":
+			info.CommitAt = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
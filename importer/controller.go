@@ -0,0 +1,63 @@
+package importer
+
+import (
+	"fmt"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/auth"
+	"github.com/Geepr/game/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"net/http"
+)
+
+// importRoute creates or updates games and their releases from ndjson, a json array when sent as application/json,
+// or csv when sent as text/csv, with columns mapped to fields by columns[field]=column query parameters.
+// Failed lines are reported along with the rest, the request itself only fails if the body can't be read.
+func importRoute(c *gin.Context) {
+	var query struct {
+		DryRun    bool   `form:"dryRun"`
+		Mode      Mode   `form:"mode" binding:"omitempty,oneof=upsert insert"`
+		Delimiter string `form:"delimiter" binding:"omitempty,len=1"`
+	}
+	if err := c.ShouldBindWith(&query, binding.Query); err != nil {
+		utils.Logger(c).Infof("Failed to bind import query: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	if query.Mode == "" {
+		query.Mode = ModeUpsert
+	}
+
+	var lines []parsedLine
+	var err error
+	switch c.ContentType() {
+	case binding.MIMEJSON:
+		lines, err = parseJsonArray(c.Request.Body)
+	case utils.MIMECSV:
+		delimiter := ','
+		if query.Delimiter != "" {
+			delimiter = rune(query.Delimiter[0])
+		}
+		lines, err = parseCsv(c.Request.Body, c.QueryMap("columns"), delimiter)
+	default:
+		lines, err = parseNdjson(c.Request.Body)
+	}
+	if err != nil {
+		utils.Logger(c).Infof("Failed to read import: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+
+	report, err := runImport(c, lines, query.Mode, query.DryRun, audit.ActorFromContext(c))
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+func SetupRoutes(engine *gin.Engine, basePath string) {
+	baseUrl := fmt.Sprintf("%s/api/v0/import", basePath)
+
+	engine.POST(baseUrl, auth.Require(auth.ResourceImport, auth.ActionCreate), importRoute)
+}
@@ -0,0 +1,188 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/Geepr/game/utils"
+	"github.com/gin-gonic/gin/binding"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// csvFields are the values read from csv rows, each row describes a game and optionally one of its releases.
+// By default, they are read from columns named the same way, ignoring case; the mapping can point them at other columns.
+var csvFields = []string{"gameExternalId", "gameTitle", "gameDescription", "title", "description", "releaseDate", "releaseDateUnknown", "platforms"}
+
+// csvColumns holds the index of each mapped field in a row, fields missing from the file are not set.
+type csvColumns map[string]int
+
+func newCsvColumns(header []string, mapping map[string]string) (csvColumns, error) {
+	for field := range mapping {
+		if !isCsvField(field) {
+			return nil, fmt.Errorf("column mapping of %q is not valid, fields are: %s", field, strings.Join(csvFields, ", "))
+		}
+	}
+	columns := make(csvColumns)
+	for _, field := range csvFields {
+		name, mapped := mapping[field]
+		if !mapped {
+			name = field
+		}
+		index := findColumn(header, name)
+		if index == -1 && mapped {
+			return nil, fmt.Errorf("column %q mapped to %s is missing from the header", name, field)
+		}
+		if index != -1 {
+			columns[field] = index
+		}
+	}
+	if _, ok := columns["gameTitle"]; !ok {
+		return nil, errors.New("a column with the game title is required, map it with columns[gameTitle]")
+	}
+	return columns, nil
+}
+
+func isCsvField(field string) bool {
+	for _, known := range csvFields {
+		if known == field {
+			return true
+		}
+	}
+	return false
+}
+
+func findColumn(header []string, name string) int {
+	for i, column := range header {
+		if strings.EqualFold(strings.TrimSpace(column), strings.TrimSpace(name)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// get returns the trimmed value of the field, or nil if it's empty or not mapped.
+// Values escaped by csv exports are read as they were before escaping.
+func (c csvColumns) get(row []string, field string) *string {
+	index, ok := c[field]
+	if !ok || index >= len(row) {
+		return nil
+	}
+	value := utils.ParseCsvText(strings.TrimSpace(row[index]))
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// parseCsv groups rows of the same game, by external id or title, into a single line numbered after its first row.
+// Rows without platforms only describe the game, others add a release to it.
+func parseCsv(body io.Reader, mapping map[string]string, delimiter rune) ([]parsedLine, error) {
+	reader := csv.NewReader(body)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+	columns, err := newCsvColumns(header, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]parsedLine, 0)
+	groups := make(map[string]int)
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		number, _ := reader.FieldPos(0)
+		key := getGroupKey(columns, row)
+		index, grouped := groups[key]
+		if !grouped {
+			if len(lines) == maxLines {
+				return nil, tooManyLinesErr
+			}
+			index = len(lines)
+			groups[key] = index
+			lines = append(lines, parsedLine{number: number, game: &GameLine{Releases: make([]ReleaseLine, 0)}})
+		}
+		if lines[index].err == nil {
+			if err = addCsvRow(lines[index].game, columns, row); err != nil {
+				lines[index] = parsedLine{number: lines[index].number, err: fmt.Errorf("row %d: %w", number, err)}
+			}
+		}
+	}
+
+	for i, line := range lines {
+		if line.err == nil {
+			if err = binding.Validator.ValidateStruct(line.game); err != nil {
+				lines[i].err = err
+			}
+		}
+	}
+	return lines, nil
+}
+
+func getGroupKey(columns csvColumns, row []string) string {
+	if externalId := columns.get(row, "gameExternalId"); externalId != nil {
+		return "id:" + *externalId
+	}
+	if title := columns.get(row, "gameTitle"); title != nil {
+		return "title:" + strings.ToUpper(*title)
+	}
+	return ""
+}
+
+func addCsvRow(game *GameLine, columns csvColumns, row []string) error {
+	if game.ExternalId == nil {
+		game.ExternalId = columns.get(row, "gameExternalId")
+	}
+	if title := columns.get(row, "gameTitle"); game.Title == "" && title != nil {
+		game.Title = *title
+	}
+	if game.Description == nil {
+		game.Description = columns.get(row, "gameDescription")
+	}
+
+	platforms := columns.get(row, "platforms")
+	if platforms == nil {
+		return nil
+	}
+	release := ReleaseLine{
+		Title:       columns.get(row, "title"),
+		Description: columns.get(row, "description"),
+		Platforms:   splitPlatforms(*platforms),
+	}
+	if value := columns.get(row, "releaseDate"); value != nil {
+		release.ReleaseDate = &Date{}
+		if err := release.ReleaseDate.UnmarshalJSON([]byte(strconv.Quote(*value))); err != nil {
+			return fmt.Errorf("release date %q must be a date, like 2006-01-02", *value)
+		}
+	}
+	if value := columns.get(row, "releaseDateUnknown"); value != nil {
+		unknown, err := strconv.ParseBool(*value)
+		if err != nil {
+			return fmt.Errorf("releaseDateUnknown %q must be true or false", *value)
+		}
+		release.ReleaseDateUnknown = &unknown
+	}
+	game.Releases = append(game.Releases, release)
+	return nil
+}
+
+// splitPlatforms accepts short names separated by commas or semicolons, as exported in the platforms column.
+func splitPlatforms(value string) []string {
+	platforms := make([]string, 0)
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			platforms = append(platforms, trimmed)
+		}
+	}
+	return platforms
+}
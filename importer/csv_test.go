@@ -0,0 +1,85 @@
+package importer
+
+import (
+	"github.com/Geepr/game/mocks"
+	"strings"
+	"testing"
+)
+
+func TestParseCsv_RowsOfSameGame_Grouped(t *testing.T) {
+	body := `Name;Id;Platforms;ReleaseDate
+aaa;a-1;PC, PS5;2011-04-19
+AAA;;XONE;
+bbb;b-1;;
+ccc;;PC;not a date
+`
+
+	lines, err := parseCsv(strings.NewReader(body), map[string]string{"gameTitle": "name", "gameExternalId": "id"}, ';')
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertCountEqual(t, lines, 4)
+	mocks.AssertEquals(t, lines[0].number, 2)
+	mocks.AssertEquals(t, *lines[0].game.ExternalId, "a-1")
+	mocks.AssertCountEqual(t, lines[0].game.Releases, 1)
+	mocks.AssertEquals(t, strings.Join(lines[0].game.Releases[0].Platforms, "|"), "PC|PS5")
+	// rows without an external id are grouped by title only, as they could describe another game with the same title
+	mocks.AssertEquals(t, lines[1].number, 3)
+	mocks.AssertEquals(t, lines[1].game.Releases[0].ReleaseDate == nil, true)
+	mocks.AssertCountEqual(t, lines[2].game.Releases, 0)
+	mocks.AssertEquals(t, lines[3].number, 5)
+	mocks.AssertEquals(t, lines[3].err != nil, true)
+}
+
+func TestParseCsv_ColumnMapping_ValidatedAgainstHeader(t *testing.T) {
+	testData := []struct {
+		name    string
+		header  string
+		mapping map[string]string
+		valid   bool
+	}{
+		{"default names", "GameTitle,platforms", nil, true},
+		{"mapped title", "name,platforms", map[string]string{"gameTitle": "Name"}, true},
+		{"unknown field", "gameTitle,platforms", map[string]string{"name": "gameTitle"}, false},
+		{"missing column", "gameTitle,platforms", map[string]string{"platforms": "systems"}, false},
+		{"missing title", "name,platforms", nil, false},
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(currentData.name, func(t *testing.T) {
+			body := currentData.header + "\naaa,PC\n"
+
+			_, err := parseCsv(strings.NewReader(body), currentData.mapping, ',')
+
+			mocks.AssertEquals(t, err == nil, currentData.valid)
+		})
+	}
+}
+
+func TestParseCsv_EscapedValues_ReadAsExported(t *testing.T) {
+	body := `gameTitle,title,platforms
+'=cmd,'-1 edition,PC
+`
+
+	lines, err := parseCsv(strings.NewReader(body), nil, ',')
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertCountEqual(t, lines, 1)
+	mocks.AssertEquals(t, lines[0].game.Title, "=cmd")
+	mocks.AssertEquals(t, *lines[0].game.Releases[0].Title, "-1 edition")
+}
+
+func TestParseCsv_GameExport_ReadWithoutMapping(t *testing.T) {
+	body := `id,gameTitle,gameDescription,archived,gameExternalId,version
+0b5a4a8e-7d5e-4d2b-a4c4-1f7b1f3f6a11,'=cmd,''quoted,false,store:1,3
+`
+
+	lines, err := parseCsv(strings.NewReader(body), nil, ',')
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertCountEqual(t, lines, 1)
+	mocks.AssertDefault(t, lines[0].err)
+	mocks.AssertEquals(t, lines[0].game.Title, "=cmd")
+	mocks.AssertEquals(t, *lines[0].game.Description, "'quoted")
+	mocks.AssertEquals(t, *lines[0].game.ExternalId, "store:1")
+}
@@ -0,0 +1,10 @@
+package importer
+
+import (
+	"context"
+	"github.com/Geepr/game/tracing"
+)
+
+var (
+	getTransaction = func(ctx context.Context) (*tracing.Transaction, error) { return tracing.BeginTransaction(ctx) }
+)
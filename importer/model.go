@@ -0,0 +1,118 @@
+package importer
+
+import (
+	"github.com/Geepr/game/utils"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+// GameLine is a single line of an import, describing the desired state of a game and some of its releases.
+// Optional fields that are left out, or null, keep their current values when updating.
+type GameLine struct {
+	// ExternalId, when set, is used to find the game on repeated imports, even after its title changes.
+	ExternalId  *string `json:"externalId" binding:"omitempty,min=1,max=100"`
+	Title       string  `json:"title" binding:"required,max=200"`
+	Description *string `json:"description" binding:"omitempty,max=2000"`
+	// Releases are matched with existing releases of the game by their title and platforms, the ones not listed are left untouched.
+	Releases []ReleaseLine `json:"releases" binding:"dive"`
+}
+
+type ReleaseLine struct {
+	Title       *string `json:"title" binding:"omitempty,max=200"`
+	Description *string `json:"description" binding:"omitempty,max=2000"`
+	// ReleaseDate is either a date, like 2006-01-02, or a full RFC 3339 timestamp.
+	ReleaseDate        *Date `json:"releaseDate"`
+	ReleaseDateUnknown *bool `json:"releaseDateUnknown"`
+	// Platforms are short names of existing platforms, compared case-insensitively.
+	Platforms []string `json:"platforms" binding:"required,min=1,dive,required,max=10"`
+}
+
+// releaseDate returns the date as stored in the database, which keeps no time of day.
+func (r *ReleaseLine) releaseDate() *time.Time {
+	if r.ReleaseDate == nil {
+		return nil
+	}
+	date := time.Date(r.ReleaseDate.Year(), r.ReleaseDate.Month(), r.ReleaseDate.Day(), 0, 0, 0, 0, time.UTC)
+	return &date
+}
+
+// Date accepts both plain dates and timestamps, as spreadsheets and store apis rarely agree on a format.
+type Date struct {
+	time.Time
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	value := string(data)
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return &time.ParseError{Layout: time.DateOnly, Value: value, Message: ": date must be a string"}
+	}
+	value = value[1 : len(value)-1]
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		if parsed, err = time.Parse(time.RFC3339, value); err != nil {
+			return err
+		}
+	}
+	d.Time = parsed
+	return nil
+}
+
+type Status string
+
+const (
+	StatusCreated Status = "created"
+	StatusUpdated Status = "updated"
+	// StatusSkipped is used for games already matching the line, or existing games when only inserting.
+	StatusSkipped Status = "skipped"
+	StatusFailed  Status = "failed"
+)
+
+// Mode decides what happens to games that already exist.
+type Mode string
+
+const (
+	ModeUpsert Mode = "upsert"
+	ModeInsert Mode = "insert"
+)
+
+func failedLine(number int, err error) LineResult {
+	result := LineResult{Line: number, Status: StatusFailed, Error: err.Error(), Errors: utils.FieldErrors(err)}
+	if result.Errors != nil {
+		result.Error = "some fields have invalid values"
+	}
+	return result
+}
+
+// LineResult is the outcome of importing a single line, lines are numbered from 1.
+type LineResult struct {
+	Line   int        `json:"line"`
+	Status Status     `json:"status"`
+	GameId *uuid.UUID `json:"gameId,omitempty"`
+	Error  string     `json:"error,omitempty"`
+	// Errors list invalid fields of lines failing validation, named like in the request.
+	Errors []utils.FieldError `json:"errors,omitempty"`
+}
+
+// Report summarises an import, changes of dry runs are rolled back, but reported as if they were made.
+type Report struct {
+	DryRun  bool         `json:"dryRun"`
+	Created int          `json:"created"`
+	Updated int          `json:"updated"`
+	Skipped int          `json:"skipped"`
+	Failed  int          `json:"failed"`
+	Results []LineResult `json:"results"`
+}
+
+func (r *Report) add(result LineResult) {
+	switch result.Status {
+	case StatusCreated:
+		r.Created++
+	case StatusUpdated:
+		r.Updated++
+	case StatusSkipped:
+		r.Skipped++
+	default:
+		r.Failed++
+	}
+	r.Results = append(r.Results, result)
+}
@@ -0,0 +1,80 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin/binding"
+	"io"
+)
+
+const (
+	// maxLines limits the size of a single import, larger ones should be split so that the transaction stays short.
+	maxLines = 10000
+	// maxLineBytes is enough for a game with dozens of releases, longer lines are most likely not ndjson at all.
+	maxLineBytes = 1024 * 1024
+)
+
+var tooManyLinesErr = fmt.Errorf("import is limited to %d games, split it into smaller ones", maxLines)
+
+// parsedLine is a decoded game of the import, or the reason it couldn't be decoded.
+type parsedLine struct {
+	number int
+	game   *GameLine
+	err    error
+}
+
+// parseNdjson decodes one game per line, empty lines are skipped but still counted, so that line numbers match the file.
+func parseNdjson(body io.Reader) ([]parsedLine, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+	lines := make([]parsedLine, 0)
+	for number := 1; scanner.Scan(); number++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if len(lines) == maxLines {
+			return nil, tooManyLinesErr
+		}
+		lines = append(lines, parseLine(number, line))
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("lines must not be longer than %d bytes", maxLineBytes)
+		}
+		return nil, err
+	}
+	return lines, nil
+}
+
+// parseJsonArray decodes a json array of games, which are numbered by their position in the array, starting from 1.
+func parseJsonArray(body io.Reader) ([]parsedLine, error) {
+	var items []json.RawMessage
+	if err := json.NewDecoder(body).Decode(&items); err != nil {
+		return nil, err
+	}
+	if len(items) > maxLines {
+		return nil, tooManyLinesErr
+	}
+	lines := make([]parsedLine, len(items))
+	for i, item := range items {
+		lines[i] = parseLine(i+1, item)
+	}
+	return lines, nil
+}
+
+func parseLine(number int, data []byte) parsedLine {
+	game := &GameLine{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(game); err != nil {
+		return parsedLine{number: number, err: err}
+	}
+	if err := binding.Validator.ValidateStruct(game); err != nil {
+		return parsedLine{number: number, err: err}
+	}
+	return parsedLine{number: number, game: game}
+}
@@ -0,0 +1,57 @@
+package importer
+
+import (
+	"github.com/Geepr/game/mocks"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseNdjson_MixedLines_NumberedLikeFile(t *testing.T) {
+	body := `{"title": "aaa", "releases": [{"platforms": ["PC"], "releaseDate": "2011-04-19"}]}
+
+{"title": "bbb", "unknown": 1}
+{"releases": [{"platforms": []}]}
+not json`
+
+	lines, err := parseNdjson(strings.NewReader(body))
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertCountEqual(t, lines, 4)
+	mocks.AssertDefault(t, lines[0].err)
+	mocks.AssertEquals(t, lines[0].game.Releases[0].ReleaseDate.Time, time.Date(2011, 4, 19, 0, 0, 0, 0, time.UTC))
+	mocks.AssertEquals(t, lines[1].number, 3)
+	mocks.AssertEquals(t, lines[1].err != nil, true)
+	mocks.AssertEquals(t, lines[2].err != nil, true)
+	mocks.AssertEquals(t, lines[3].number, 5)
+	mocks.AssertEquals(t, lines[3].err != nil, true)
+}
+
+func TestParseLine_InvalidFields_ReportedByRequestName(t *testing.T) {
+	line := parseLine(1, []byte(`{"title": "", "releases": [{"platforms": ["toolongshortname"]}]}`))
+
+	result := failedLine(line.number, line.err)
+
+	mocks.AssertEquals(t, result.Status, StatusFailed)
+	mocks.AssertCountEqual(t, result.Errors, 2)
+	mocks.AssertEquals(t, result.Errors[0].Field, "title")
+	mocks.AssertEquals(t, result.Errors[1].Field, "releases[0].platforms[0]")
+}
+
+func TestParseJsonArray_Items_NumberedFromOne(t *testing.T) {
+	lines, err := parseJsonArray(strings.NewReader(`[{"title": "aaa"}, {"title": "bbb", "releases": [{"platforms": ["PC"], "releaseDate": "2011-04-19T10:00:00+02:00"}]}]`))
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertCountEqual(t, lines, 2)
+	mocks.AssertEquals(t, lines[1].number, 2)
+	mocks.AssertEquals(t, lines[1].game.Title, "bbb")
+	mocks.AssertEquals(t, *lines[1].game.Releases[0].releaseDate(), time.Date(2011, 4, 19, 0, 0, 0, 0, time.UTC))
+}
+
+func TestParseNdjson_TooManyLines_ReturnsErr(t *testing.T) {
+	body := strings.Repeat("{\"title\": \"aaa\"}\n", maxLines+1)
+
+	_, err := parseNdjson(strings.NewReader(body))
+
+	mocks.AssertEquals(t, err, tooManyLinesErr)
+}
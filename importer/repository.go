@@ -0,0 +1,356 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/game"
+	"github.com/Geepr/game/metrics"
+	"github.com/Geepr/game/release"
+	"github.com/Geepr/game/utils"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/gofrs/uuid"
+	"github.com/lib/pq"
+	"slices"
+	"strings"
+	"time"
+)
+
+// storedGame holds the columns of a game that imports can change.
+type storedGame struct {
+	id          uuid.UUID
+	title       string
+	description *string
+	externalId  *string
+}
+
+// storedRelease holds the columns of a release that imports can change, along with the ones used to match it.
+type storedRelease struct {
+	id                 uuid.UUID
+	title              *string
+	description        *string
+	releaseDate        *time.Time
+	releaseDateUnknown bool
+	platformIds        []uuid.UUID
+}
+
+// runImport imports all lines in a single transaction, with each line in a savepoint so that failed ones don't affect the rest.
+// Dry runs roll the transaction back at the end, so that everything except for the changes is exactly as in a real run.
+func runImport(ctx context.Context, lines []parsedLine, mode Mode, dryRun bool, actor audit.Actor) (_ *Report, err error) {
+	defer metrics.ObserveQuery("runImport", time.Now(), &err)
+	transaction, err := getTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer transaction.Rollback()
+
+	report := &Report{DryRun: dryRun, Results: make([]LineResult, 0, len(lines))}
+	platforms := newPlatformResolver(transaction)
+	for _, line := range lines {
+		if line.err != nil {
+			report.add(failedLine(line.number, line.err))
+			continue
+		}
+		if _, err = transaction.Exec("savepoint import_line"); err != nil {
+			return nil, err
+		}
+		status, gameId, lineErr := importGame(ctx, transaction, platforms, line.game, mode, actor)
+		if lineErr != nil {
+			utils.Logger(ctx).Infof("Failed to import line %d: %s", line.number, lineErr.Error())
+			if _, err = transaction.Exec("rollback to savepoint import_line"); err != nil {
+				return nil, err
+			}
+			report.add(failedLine(line.number, lineErr))
+			continue
+		}
+		if _, err = transaction.Exec("release savepoint import_line"); err != nil {
+			return nil, err
+		}
+		report.add(LineResult{Line: line.number, Status: status, GameId: &gameId})
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	return report, transaction.Commit()
+}
+
+func importGame(ctx context.Context, connector gotabase.Connector, platforms *platformResolver, line *GameLine, mode Mode, actor audit.Actor) (Status, uuid.UUID, error) {
+	platformIds := make([][]uuid.UUID, len(line.Releases))
+	for i, releaseLine := range line.Releases {
+		var err error
+		if platformIds[i], err = platforms.resolve(releaseLine.Platforms); err != nil {
+			return "", uuid.Nil, err
+		}
+	}
+
+	existing, err := findGame(connector, line)
+	if err != nil {
+		return "", uuid.Nil, err
+	}
+	if existing == nil {
+		id, err := insertGame(ctx, connector, line, actor)
+		if err != nil {
+			return "", uuid.Nil, err
+		}
+		for i, releaseLine := range line.Releases {
+			if err = insertRelease(ctx, connector, id, &releaseLine, platformIds[i], actor); err != nil {
+				return "", uuid.Nil, err
+			}
+		}
+		return StatusCreated, id, nil
+	}
+	if mode == ModeInsert {
+		return StatusSkipped, existing.id, nil
+	}
+
+	changed, err := updateGame(ctx, connector, existing, line, actor)
+	if err != nil {
+		return "", uuid.Nil, err
+	}
+	releases, err := getReleases(connector, existing.id)
+	if err != nil {
+		return "", uuid.Nil, err
+	}
+	for i, releaseLine := range line.Releases {
+		releaseChanged, err := upsertRelease(ctx, connector, existing.id, releases, &releaseLine, platformIds[i], actor)
+		if err != nil {
+			return "", uuid.Nil, err
+		}
+		changed = changed || releaseChanged
+	}
+	if !changed {
+		return StatusSkipped, existing.id, nil
+	}
+	return StatusUpdated, existing.id, nil
+}
+
+// findGame matches the line by external id first, and by title otherwise.
+// Lines with an external id are only matched by title with games that don't have one yet, so that distinct games sharing a title stay apart.
+func findGame(connector gotabase.Connector, line *GameLine) (*storedGame, error) {
+	if line.ExternalId != nil {
+		games, err := scanGames(connector, "select id, title, description, external_id from games where external_id = $1", *line.ExternalId)
+		if err != nil || len(games) != 0 {
+			return firstOrNil(games), err
+		}
+	}
+	games, err := scanGames(connector, "select id, title, description, external_id from games where title_normalised = upper($1) and ($2 or external_id is null)", line.Title, line.ExternalId == nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(games) > 1 {
+		return nil, fmt.Errorf("%w: title matches %d games, set externalId to tell them apart", utils.InvalidDataErr, len(games))
+	}
+	return firstOrNil(games), nil
+}
+
+func insertGame(ctx context.Context, connector gotabase.Connector, line *GameLine, actor audit.Actor) (uuid.UUID, error) {
+	changes, err := gameChanges(line)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return game.ChangeWith(ctx, connector, audit.ActionInsert, uuid.Nil, changes, actor)
+}
+
+// updateGame brings the game in line with the import, fields missing from the line are kept, so the external id is never removed.
+func updateGame(ctx context.Context, connector gotabase.Connector, existing *storedGame, line *GameLine, actor audit.Actor) (bool, error) {
+	description, externalId := existing.description, existing.externalId
+	if line.Description != nil {
+		description = line.Description
+	}
+	if line.ExternalId != nil {
+		externalId = line.ExternalId
+	}
+	if existing.title == line.Title && equalNillable(existing.description, description) && equalNillable(existing.externalId, externalId) {
+		return false, nil
+	}
+	changes, err := gameChanges(line)
+	if err != nil {
+		return false, err
+	}
+	_, err = game.ChangeWith(ctx, connector, audit.ActionUpdate, existing.id, changes, actor)
+	return err == nil, err
+}
+
+// gameChanges returns a merge patch of the game, leaving out optional fields missing from the line.
+func gameChanges(line *GameLine) (json.RawMessage, error) {
+	changes := map[string]any{"title": line.Title}
+	if line.Description != nil {
+		changes["description"] = line.Description
+	}
+	if line.ExternalId != nil {
+		changes["externalId"] = line.ExternalId
+	}
+	return json.Marshal(changes)
+}
+
+func getReleases(connector gotabase.Connector, gameId uuid.UUID) ([]*storedRelease, error) {
+	rows, err := connector.QueryRows("select id, title_override, description, release_date, release_date_unknown, game_release_platform_ids(id) from game_releases where game_id = $1", gameId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	releases := make([]*storedRelease, 0)
+	for rows.Next() {
+		stored := &storedRelease{}
+		if err = rows.Scan(&stored.id, &stored.title, &stored.description, &stored.releaseDate, &stored.releaseDateUnknown, pq.Array(&stored.platformIds)); err != nil {
+			return nil, err
+		}
+		releases = append(releases, stored)
+	}
+	return releases, nil
+}
+
+// upsertRelease updates the release with the same title and platforms, or creates a new one if the game has none.
+func upsertRelease(ctx context.Context, connector gotabase.Connector, gameId uuid.UUID, releases []*storedRelease, line *ReleaseLine, platformIds []uuid.UUID, actor audit.Actor) (bool, error) {
+	var existing *storedRelease
+	for _, stored := range releases {
+		if equalTitles(stored.title, line.Title) && samePlatforms(stored.platformIds, platformIds) {
+			existing = stored
+			break
+		}
+	}
+	if existing == nil {
+		return true, insertRelease(ctx, connector, gameId, line, platformIds, actor)
+	}
+
+	description, releaseDate, releaseDateUnknown := existing.description, existing.releaseDate, existing.releaseDateUnknown
+	if line.Description != nil {
+		description = line.Description
+	}
+	if line.ReleaseDate != nil {
+		releaseDate = line.releaseDate()
+	}
+	if line.ReleaseDateUnknown != nil {
+		releaseDateUnknown = *line.ReleaseDateUnknown
+	}
+	if equalNillable(existing.title, line.Title) && equalNillable(existing.description, description) &&
+		existing.releaseDateUnknown == releaseDateUnknown && equalDates(existing.releaseDate, releaseDate) {
+		return false, nil
+	}
+	changes, err := releaseChanges(gameId, line, platformIds)
+	if err != nil {
+		return false, err
+	}
+	_, err = release.ChangeWith(ctx, connector, audit.ActionUpdate, existing.id, changes, actor)
+	return err == nil, err
+}
+
+func insertRelease(ctx context.Context, connector gotabase.Connector, gameId uuid.UUID, line *ReleaseLine, platformIds []uuid.UUID, actor audit.Actor) error {
+	changes, err := releaseChanges(gameId, line, platformIds)
+	if err != nil {
+		return err
+	}
+	_, err = release.ChangeWith(ctx, connector, audit.ActionInsert, uuid.Nil, changes, actor)
+	return err
+}
+
+// releaseChanges returns a merge patch of the release, leaving out optional fields missing from the line.
+// The title is always included, as releases are matched by it.
+func releaseChanges(gameId uuid.UUID, line *ReleaseLine, platformIds []uuid.UUID) (json.RawMessage, error) {
+	changes := map[string]any{"gameId": gameId, "title": line.Title, "platformIds": platformIds}
+	if line.Description != nil {
+		changes["description"] = line.Description
+	}
+	if line.ReleaseDate != nil {
+		changes["releaseDate"] = line.releaseDate()
+	}
+	if line.ReleaseDateUnknown != nil {
+		changes["releaseDateUnknown"] = line.ReleaseDateUnknown
+	}
+	return json.Marshal(changes)
+}
+
+// platformResolver finds platforms by short name, remembering them for the rest of the import.
+type platformResolver struct {
+	connector gotabase.Connector
+	ids       map[string]uuid.UUID
+}
+
+func newPlatformResolver(connector gotabase.Connector) *platformResolver {
+	return &platformResolver{connector: connector, ids: make(map[string]uuid.UUID)}
+}
+
+func (r *platformResolver) resolve(shortNames []string) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(shortNames))
+	for _, shortName := range shortNames {
+		normalised := strings.ToUpper(strings.TrimSpace(shortName))
+		id, ok := r.ids[normalised]
+		if !ok {
+			result, err := r.connector.QueryRow("select id from platforms where short_name_normalised = $1", normalised)
+			if err != nil {
+				return nil, err
+			}
+			if err = result.Scan(&id); err != nil {
+				if utils.ConvertIfNotFoundErr(err) == utils.DataNotFoundErr {
+					return nil, fmt.Errorf("%w: platform %q does not exist", utils.InvalidDataErr, shortName)
+				}
+				return nil, err
+			}
+			r.ids[normalised] = id
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func scanGames(connector gotabase.Connector, query string, args ...any) ([]*storedGame, error) {
+	rows, err := connector.QueryRows(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	games := make([]*storedGame, 0)
+	for rows.Next() {
+		stored := &storedGame{}
+		if err = rows.Scan(&stored.id, &stored.title, &stored.description, &stored.externalId); err != nil {
+			return nil, err
+		}
+		games = append(games, stored)
+	}
+	return games, nil
+}
+
+func firstOrNil[T any](items []*T) *T {
+	if len(items) == 0 {
+		return nil
+	}
+	return items[0]
+}
+
+func equalNillable[T comparable](left *T, right *T) bool {
+	if left == nil || right == nil {
+		return left == right
+	}
+	return *left == *right
+}
+
+// equalTitles compares release titles the same way as games are matched by title, ignoring case.
+func equalTitles(left *string, right *string) bool {
+	if left == nil || right == nil {
+		return left == right
+	}
+	return strings.ToUpper(*left) == strings.ToUpper(*right)
+}
+
+func equalDates(left *time.Time, right *time.Time) bool {
+	if left == nil || right == nil {
+		return left == right
+	}
+	return left.Equal(*right)
+}
+
+func samePlatforms(left []uuid.UUID, right []uuid.UUID) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for _, id := range left {
+		if !slices.Contains(right, id) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+package importer
+
+import (
+	"context"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/mocks"
+	"github.com/KowalskiPiotr98/gotabase"
+	"strings"
+	"testing"
+)
+
+type importRepoTest struct {
+	connection gotabase.Connector
+	dbName     string
+}
+
+func newImportRepoTest(t *testing.T) *importRepoTest {
+	db, name := mocks.GetDatabase()
+	test := &importRepoTest{
+		connection: db,
+		dbName:     name,
+	}
+	t.Cleanup(test.cleanup)
+	return test
+}
+
+func (test *importRepoTest) cleanup() {
+	mocks.DropDatabase(test.dbName)
+}
+
+func (test *importRepoTest) insertMockData() {
+	_, err := test.connection.Exec("insert into platforms (name, short_name) values ('Personal computer', 'PC'), ('PlayStation 5', 'PS5')")
+	mocks.PanicOnErr(err)
+	_, err = test.connection.Exec("insert into games (title, archived) values ('Existing', false)")
+	mocks.PanicOnErr(err)
+}
+
+func (test *importRepoTest) runImport(body string, mode Mode, dryRun bool) *Report {
+	lines, err := parseNdjson(strings.NewReader(body))
+	mocks.PanicOnErr(err)
+	report, err := runImport(context.Background(), lines, mode, dryRun, audit.System)
+	mocks.PanicOnErr(err)
+	return report
+}
+
+func (test *importRepoTest) count(query string) int {
+	row, err := test.connection.QueryRow(query)
+	mocks.PanicOnErr(err)
+	var count int
+	mocks.PanicOnErr(row.Scan(&count))
+	return count
+}
+
+func TestImportRepository_RunImport_NewAndExistingGames_CreatedAndUpdated(t *testing.T) {
+	test := newImportRepoTest(t)
+	test.insertMockData()
+	body := `{"title": "New", "externalId": "store:1", "releases": [{"platforms": ["pc", "PS5"], "releaseDate": "2024-01-01"}]}
+{"title": "EXISTING", "description": "now described"}
+{"title": "EXISTING", "description": "now described"}`
+
+	report := test.runImport(body, ModeUpsert, false)
+
+	mocks.AssertEquals(t, report.Created, 1)
+	mocks.AssertEquals(t, report.Updated, 1)
+	mocks.AssertEquals(t, report.Skipped, 1)
+	mocks.AssertEquals(t, test.count("select count(*) from games"), 2)
+	mocks.AssertEquals(t, test.count("select count(*) from game_release_platforms"), 2)
+	mocks.AssertEquals(t, test.count("select count(*) from games where title = 'EXISTING' and description = 'now described' and version = 2"), 1)
+	mocks.AssertEquals(t, test.count("select count(*) from audit_log"), 3)
+}
+
+func TestImportRepository_RunImport_ExternalIdMatched_TitleUpdated(t *testing.T) {
+	test := newImportRepoTest(t)
+	test.insertMockData()
+	test.runImport(`{"title": "Old title", "externalId": "store:1"}`, ModeUpsert, false)
+
+	report := test.runImport(`{"title": "New title", "externalId": "store:1", "releases": [{"platforms": ["PC"]}]}`, ModeUpsert, false)
+
+	mocks.AssertEquals(t, report.Updated, 1)
+	mocks.AssertEquals(t, test.count("select count(*) from games where external_id = 'store:1' and title = 'New title'"), 1)
+	mocks.AssertEquals(t, test.count("select count(*) from game_releases"), 1)
+}
+
+func TestImportRepository_RunImport_FailedLine_OthersImported(t *testing.T) {
+	test := newImportRepoTest(t)
+	test.insertMockData()
+	body := `{"title": "First"}
+{"title": "Broken", "releases": [{"platforms": ["N64"]}]}
+{"title": "Third"}`
+
+	report := test.runImport(body, ModeUpsert, false)
+
+	mocks.AssertEquals(t, report.Created, 2)
+	mocks.AssertEquals(t, report.Failed, 1)
+	mocks.AssertEquals(t, report.Results[1].Line, 2)
+	mocks.AssertEquals(t, strings.Contains(report.Results[1].Error, "N64"), true)
+	mocks.AssertEquals(t, test.count("select count(*) from games where title = 'Broken'"), 0)
+}
+
+func TestImportRepository_RunImport_DryRun_NothingChanged(t *testing.T) {
+	test := newImportRepoTest(t)
+	test.insertMockData()
+
+	report := test.runImport(`{"title": "New", "releases": [{"platforms": ["PC"]}]}`, ModeUpsert, true)
+
+	mocks.AssertEquals(t, report.DryRun, true)
+	mocks.AssertEquals(t, report.Created, 1)
+	mocks.AssertEquals(t, test.count("select count(*) from games"), 1)
+	mocks.AssertEquals(t, test.count("select count(*) from audit_log"), 0)
+}
+
+func TestImportRepository_RunImport_InsertMode_ExistingSkipped(t *testing.T) {
+	test := newImportRepoTest(t)
+	test.insertMockData()
+
+	report := test.runImport(`{"title": "existing", "description": "changed"}`, ModeInsert, false)
+
+	mocks.AssertEquals(t, report.Skipped, 1)
+	mocks.AssertEquals(t, test.count("select count(*) from games where description is null"), 1)
+}
+
+func TestImportRepository_RunImport_ExistingRelease_UpdatedLikeOtherChanges(t *testing.T) {
+	test := newImportRepoTest(t)
+	test.insertMockData()
+	test.runImport(`{"title": "New", "externalId": "store:1", "releases": [{"platforms": ["PC"]}]}`, ModeUpsert, false)
+
+	report := test.runImport(`{"title": "New", "externalId": "store:1", "releases": [{"platforms": ["PC"], "releaseDate": "2024-01-01"}]}`, ModeUpsert, false)
+
+	mocks.AssertEquals(t, report.Updated, 1)
+	mocks.AssertEquals(t, test.count("select count(*) from game_releases where release_date = '2024-01-01' and version = 2"), 1)
+	mocks.AssertEquals(t, test.count("select count(*) from game_release_platforms"), 1)
+	mocks.AssertEquals(t, test.count("select count(*) from audit_log where entity_type = 'release'"), 2)
+}
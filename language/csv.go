@@ -0,0 +1,103 @@
+package language
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	MissingLanguageColumnErr = errors.New("csv header must contain a language column")
+
+	// headerAliases maps column names used by publishers to the supported columns.
+	headerAliases = map[string]string{
+		"language":  "language",
+		"lang":      "language",
+		"code":      "language",
+		"interface": "interface",
+		"ui":        "interface",
+		"text":      "interface",
+		"audio":     "audio",
+		"voice":     "audio",
+		"voiceover": "audio",
+		"subtitles": "subtitles",
+		"subtitle":  "subtitles",
+		"subs":      "subtitles",
+	}
+	trueValues  = []string{"x", "y", "yes", "true", "1", "✓", "✔"}
+	falseValues = []string{"", "n", "no", "false", "0", "-"}
+)
+
+// ParseCsv reads a language support table, with a single header row followed by one row per language.
+// Support columns accept common spreadsheet markers such as "x", "yes", or "✓", missing columns are treated as not supported.
+func ParseCsv(reader io.Reader) ([]*Support, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.TrimLeadingSpace = true
+	csvReader.FieldsPerRecord = -1
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		normalised := strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+		if column, ok := headerAliases[normalised]; ok {
+			columns[column] = i
+		}
+	}
+	if _, ok := columns["language"]; !ok {
+		return nil, MissingLanguageColumnErr
+	}
+
+	supports := make([]*Support, 0)
+	for line := 2; ; line++ {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read csv line %d: %w", line, err)
+		}
+		support := Support{Language: getCell(record, columns, "language")}
+		if support.Language == "" {
+			continue
+		}
+		if support.Interface, err = parseFlag(getCell(record, columns, "interface")); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if support.Audio, err = parseFlag(getCell(record, columns, "audio")); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if support.Subtitles, err = parseFlag(getCell(record, columns, "subtitles")); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		supports = append(supports, &support)
+	}
+	return normalise(supports)
+}
+
+func getCell(record []string, columns map[string]int, column string) string {
+	index, ok := columns[column]
+	if !ok || index >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[index])
+}
+
+func parseFlag(value string) (bool, error) {
+	value = strings.ToLower(value)
+	for _, trueValue := range trueValues {
+		if value == trueValue {
+			return true, nil
+		}
+	}
+	for _, falseValue := range falseValues {
+		if value == falseValue {
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("unrecognised support marker %q", value)
+}
@@ -0,0 +1,77 @@
+package language
+
+import (
+	"github.com/Geepr/game/mocks"
+	"strings"
+	"testing"
+)
+
+func TestParseCsv_ValidTable_Parsed(t *testing.T) {
+	table := "Language,Interface,Audio,Subtitles,Notes\n" +
+		"en,x,x,x,original\n" +
+		"ja-jp, yes, no, ✓\n" +
+		"fr,x,,\n" +
+		",,,\n"
+
+	result, err := ParseCsv(strings.NewReader(table))
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertCountEqual(t, result, 3)
+	mocks.AssertEquals(t, *result[0], Support{Language: "en", Interface: true, Audio: true, Subtitles: true})
+	mocks.AssertEquals(t, *result[1], Support{Language: "ja-JP", Interface: true, Audio: false, Subtitles: true})
+	mocks.AssertEquals(t, *result[2], Support{Language: "fr", Interface: true})
+}
+
+func TestParseCsv_AliasedColumns_Parsed(t *testing.T) {
+	table := "lang;voice-over;subs\nde;1;0\nDE;0;1\n"
+
+	result, err := ParseCsv(strings.NewReader(strings.ReplaceAll(table, ";", ",")))
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertCountEqual(t, result, 1)
+	mocks.AssertEquals(t, *result[0], Support{Language: "de", Audio: true, Subtitles: true})
+}
+
+func TestParseCsv_MissingLanguageColumn_ReturnsErr(t *testing.T) {
+	_, err := ParseCsv(strings.NewReader("interface,audio\nx,x\n"))
+
+	mocks.AssertEquals(t, err, MissingLanguageColumnErr)
+}
+
+func TestParseCsv_InvalidMarkerOrLanguage_ReturnsErr(t *testing.T) {
+	testData := []string{
+		"language,audio\nen,maybe\n",
+		"language,audio\nnot a language,x\n",
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(currentData, func(t *testing.T) {
+			_, err := ParseCsv(strings.NewReader(currentData))
+
+			mocks.AssertEquals(t, err != nil, true)
+		})
+	}
+}
+
+func TestCanonicalise_ValidTags_Canonicalised(t *testing.T) {
+	testData := []struct {
+		tag       string
+		canonical string
+	}{
+		{"en", "en"},
+		{"EN-us", "en-US"},
+		{" pt-br ", "pt-BR"},
+		{"zh-Hant-TW", "zh-Hant-TW"},
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(currentData.tag, func(t *testing.T) {
+			result, err := Canonicalise(currentData.tag)
+
+			mocks.AssertDefault(t, err)
+			mocks.AssertEquals(t, result, currentData.canonical)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package language
+
+type Kind string
+
+const (
+	KindAny       Kind = "any"
+	KindInterface Kind = "interface"
+	KindAudio     Kind = "audio"
+	KindSubtitles Kind = "subtitles"
+)
+
+// Support describes which parts of a release are localised to a given language.
+type Support struct {
+	// Language is a canonical BCP-47 tag, like "en", "ja", or "pt-BR".
+	Language  string `json:"language"`
+	Interface bool   `json:"interface"`
+	Audio     bool   `json:"audio"`
+	Subtitles bool   `json:"subtitles"`
+}
+
+// CreateModel is the shape in which language support is accepted by the create and update routes of releases.
+type CreateModel struct {
+	Language  string `json:"language" binding:"required,max=35"`
+	Interface bool   `json:"interface"`
+	Audio     bool   `json:"audio"`
+	Subtitles bool   `json:"subtitles"`
+}
+
+// FromCreateModels validates and canonicalises language tags of all passed models.
+// Entries resolving to the same language are merged together.
+func FromCreateModels(models []CreateModel) ([]*Support, error) {
+	supports := make([]*Support, 0, len(models))
+	for _, model := range models {
+		support := &Support{
+			Language:  model.Language,
+			Interface: model.Interface,
+			Audio:     model.Audio,
+			Subtitles: model.Subtitles,
+		}
+		supports = append(supports, support)
+	}
+	return normalise(supports)
+}
+
+// SqlColumn returns the filtering kind in the form accepted by database functions, defaulting to KindAny.
+func (k Kind) SqlColumn() string {
+	switch k {
+	case KindInterface, KindAudio, KindSubtitles:
+		return string(k)
+	}
+	return string(KindAny)
+}
+
+func (s *Support) merge(other *Support) {
+	s.Interface = s.Interface || other.Interface
+	s.Audio = s.Audio || other.Audio
+	s.Subtitles = s.Subtitles || other.Subtitles
+}
@@ -0,0 +1,75 @@
+package language
+
+import (
+	"context"
+	"github.com/Geepr/game/utils"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/gofrs/uuid"
+	"github.com/lib/pq"
+)
+
+// GetForReleases returns language support of all passed releases, grouped by release id.
+func GetForReleases(ctx context.Context, connector gotabase.Connector, releaseIds []uuid.UUID) (map[uuid.UUID][]*Support, error) {
+	grouped := make(map[uuid.UUID][]*Support, len(releaseIds))
+	if len(releaseIds) == 0 {
+		return grouped, nil
+	}
+	query := "select game_release_id, language, interface, audio, subtitles from game_release_languages where game_release_id = any($1) order by language"
+	result, err := connector.QueryRows(query, pq.Array(releaseIds))
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to run query on game release languages: %s", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		var releaseId uuid.UUID
+		support := Support{}
+		if err := result.Scan(&releaseId, &support.Language, &support.Interface, &support.Audio, &support.Subtitles); err != nil {
+			return nil, utils.ConvertIfNotFoundErr(err)
+		}
+		grouped[releaseId] = append(grouped[releaseId], &support)
+	}
+	return grouped, nil
+}
+
+// GetAggregatedForGame returns languages available in any of the releases of a game.
+// Each kind of support is set if at least one release provides it.
+func GetAggregatedForGame(ctx context.Context, connector gotabase.Connector, gameId uuid.UUID) ([]*Support, error) {
+	query := "select grl.language, bool_or(grl.interface), bool_or(grl.audio), bool_or(grl.subtitles) from game_release_languages grl " +
+		"join game_releases gr on gr.id = grl.game_release_id where gr.game_id = $1 group by grl.language order by grl.language"
+	result, err := connector.QueryRows(query, gameId)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to run aggregating query on game release languages: %s", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	supports := make([]*Support, 0)
+	for result.Next() {
+		support := Support{}
+		if err := result.Scan(&support.Language, &support.Interface, &support.Audio, &support.Subtitles); err != nil {
+			return nil, utils.ConvertIfNotFoundErr(err)
+		}
+		supports = append(supports, &support)
+	}
+	return supports, nil
+}
+
+// ReplaceForRelease removes all language support entries of a release and stores the passed ones instead.
+// Should be run inside a transaction, together with the release update.
+func ReplaceForRelease(ctx context.Context, connector gotabase.Connector, releaseId uuid.UUID, supports []*Support) error {
+	if _, err := connector.Exec("delete from game_release_languages where game_release_id = $1", releaseId); err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute delete query on game release languages: %s", err.Error())
+		return err
+	}
+	for _, support := range supports {
+		_, err := connector.Exec("insert into game_release_languages (game_release_id, language, interface, audio, subtitles) values ($1, $2, $3, $4, $5)",
+			releaseId, support.Language, support.Interface, support.Audio, support.Subtitles)
+		if err != nil {
+			utils.Logger(ctx).Warnf("Failed to execute insert query on game release languages: %s", err.Error())
+			return utils.ConvertIfNotFoundErr(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package language
+
+import (
+	"errors"
+	"golang.org/x/text/language"
+	"strings"
+)
+
+var (
+	InvalidLanguageErr = errors.New("language is not a valid BCP-47 tag")
+)
+
+// Canonicalise parses a BCP-47 language tag and returns it in its canonical form (IE: "EN-us" becomes "en-US").
+func Canonicalise(tag string) (string, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return "", InvalidLanguageErr
+	}
+	parsed, err := language.Parse(tag)
+	if err != nil || parsed == language.Und {
+		return "", InvalidLanguageErr
+	}
+	return parsed.String(), nil
+}
+
+func normalise(supports []*Support) ([]*Support, error) {
+	byLanguage := make(map[string]*Support, len(supports))
+	result := make([]*Support, 0, len(supports))
+	for _, support := range supports {
+		canonical, err := Canonicalise(support.Language)
+		if err != nil {
+			return nil, err
+		}
+		support.Language = canonical
+		if existing, ok := byLanguage[canonical]; ok {
+			existing.merge(support)
+			continue
+		}
+		byLanguage[canonical] = support
+		result = append(result, support)
+	}
+	return result, nil
+}
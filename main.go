@@ -1,54 +1,186 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/auth"
+	"github.com/Geepr/game/config"
 	"github.com/Geepr/game/database"
+	"github.com/Geepr/game/dump"
 	"github.com/Geepr/game/game"
+	"github.com/Geepr/game/health"
+	"github.com/Geepr/game/importer"
+	"github.com/Geepr/game/metrics"
 	"github.com/Geepr/game/platform"
+	"github.com/Geepr/game/proposal"
 	"github.com/Geepr/game/release"
+	"github.com/Geepr/game/releasegroup"
+	"github.com/Geepr/game/seed"
 	"github.com/Geepr/game/services"
+	"github.com/Geepr/game/tracing"
+	"github.com/Geepr/game/utils"
 	"github.com/KowalskiPiotr98/gotabase"
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 	log "github.com/sirupsen/logrus"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 func init() {
 	log.SetFormatter(&log.TextFormatter{FullTimestamp: true, DisableColors: true})
 	log.SetOutput(os.Stdout)
-	//todo: configurable
 	log.SetLevel(log.InfoLevel)
 }
 
 func main() {
-	err := gotabase.InitialiseConnection("user=postgres dbname=geepr password=postgres sslmode=disable", "postgres")
+	cfg, options, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		panic(err)
+		log.Fatalf("Failed to load configuration: %s", err.Error())
+	}
+	if options.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatalf("Failed to print configuration: %s", err.Error())
+		}
+		return
+	}
+	log.SetLevel(cfg.Log.LogLevel())
+	log.SetFormatter(cfg.Log.Formatter())
+	if len(options.Args) != 0 {
+		if err := runCommand(cfg, options.Args, os.Stdout); err != nil {
+			log.Fatalf("Command failed: %s", err.Error())
+		}
+		return
+	}
+	shutdownTracing, err := tracing.Setup(cfg.Tracing)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %s", err.Error())
 	}
+	// deferred calls run in reverse, so the connection is closed only after the last spans are flushed
 	defer gotabase.CloseConnection()
-	if err = database.RunMigrations(gotabase.GetConnection()); err != nil {
+	defer shutdownTracing(context.Background())
+
+	err = gotabase.InitialiseConnection(cfg.Database.ConnectionString, "postgres")
+	if err != nil {
 		panic(err)
 	}
+	if cfg.Database.AutoMigrate {
+		if err = database.RunMigrations(); err != nil {
+			panic(err)
+		}
+		if cfg.Database.Seed {
+			results, err := seed.Apply(context.Background(), false, audit.System)
+			if err != nil {
+				log.Panicf("Failed to apply seed packs: %s", err.Error())
+			}
+			for _, result := range results {
+				log.Info(result.String())
+			}
+		}
+	} else if applied, latest, err := database.GetSchemaVersions(gotabase.GetConnection()); err != nil {
+		log.Warnf("Failed to check the database schema version: %s", err.Error())
+	} else if applied < latest {
+		log.Warnf("Database schema version %d is behind the latest migration %d, run the migrate command before serving requests", applied, latest)
+	}
 
-	router := setupEngine()
+	router, err := setupEngine(cfg)
+	if err != nil {
+		log.Panicf("Failed to set up http engine: %s", err.Error())
+	}
 
-	//todo: configurable address
-	if err := router.Run("localhost:5500"); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := serve(ctx, cfg.Server, router); err != nil {
 		log.Panicf("Server failed while listening: %s", err.Error())
 	}
 }
 
-func setupEngine() *gin.Engine {
+// serve handles requests until the context is cancelled, then stops accepting connections and waits for in-flight requests to finish.
+// Requests still running after the shutdown timeout have their client connections closed, but their handlers are not stopped,
+// as neither the server nor gotabase transactions can be cancelled. Their transactions are only rolled back by the database
+// once the process exits, dropping its database connections.
+func serve(ctx context.Context, cfg config.Server, handler http.Handler) error {
+	timeouts := cfg.Timeouts()
+	server := &http.Server{
+		Addr:         cfg.Address,
+		Handler:      handler,
+		ReadTimeout:  timeouts.Read,
+		WriteTimeout: timeouts.Write,
+		IdleTimeout:  timeouts.Idle,
+	}
+	listenErr := make(chan error, 1)
+	go func() {
+		log.Infof("Listening on %s", cfg.Address)
+		listenErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-listenErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Infof("Shutting down, waiting up to %s for in-flight requests", timeouts.Shutdown)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeouts.Shutdown)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Warnf("Not all requests finished before the shutdown timeout: %s", err.Error())
+		return server.Close()
+	}
+	log.Info("All requests finished, server stopped")
+	return nil
+}
+
+func setupEngine(cfg *config.Config) (*gin.Engine, error) {
 	router := gin.New()
-	router.Use(gin.Recovery())
-	router.Use(services.GetGinLogger())
-	//todo: trusted proxies
+	router.HandleMethodNotAllowed = true
+	// repositories get the gin context, which must carry the trace of the request context
+	router.ContextWithFallback = true
+	// recovery goes after the logger, so that requests ending in a panic are logged as well
+	router.Use(services.GetRequestIdMiddleware())
+	router.Use(tracing.GetGinMiddleware())
+	router.Use(services.GetGinLogger(cfg.Log))
+	router.Use(metrics.GetGinMiddleware())
+	router.Use(gin.CustomRecovery(func(c *gin.Context, err any) {
+		_ = c.Error(fmt.Errorf("panic: %v", err))
+		utils.AbortWithProblem(http.StatusInternalServerError, "", c)
+	}))
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, err
+	}
+	authenticator, err := auth.NewAuthenticator(cfg.Auth)
+	if err != nil {
+		return nil, err
+	}
+	router.Use(authenticator.Middleware())
 
-	//todo: base path config
-	basePath := ""
+	basePath := cfg.Server.BasePath
 	game.SetupRoutes(router, basePath)
 	platform.SetupRoutes(router, basePath)
 	release.SetupRoutes(router, basePath)
+	releasegroup.SetupRoutes(router, basePath)
+	auth.SetupRoutes(router, basePath)
+	audit.SetupRoutes(router, basePath)
+	proposal.SetupRoutes(router, basePath)
+	importer.SetupRoutes(router, basePath)
+	dump.SetupRoutes(router, basePath)
+	metrics.SetupRoutes(router)
+	health.SetupRoutes(router)
+	metrics.RegisterCatalogueGauges(gotabase.GetConnection)
+	router.NoRoute(func(c *gin.Context) {
+		utils.AbortWithProblem(http.StatusNotFound, "", c)
+	})
+	router.NoMethod(func(c *gin.Context) {
+		utils.AbortWithProblem(http.StatusMethodNotAllowed, "", c)
+	})
 
-	return router
+	return router, nil
 }
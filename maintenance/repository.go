@@ -0,0 +1,56 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"github.com/Geepr/game/metrics"
+	"github.com/Geepr/game/utils"
+	"github.com/KowalskiPiotr98/gotabase"
+	"time"
+)
+
+// catalogueTables are searched and filtered by the catalogue routes, so their indexes and statistics matter most.
+var catalogueTables = []string{"games", "platforms", "game_releases", "game_release_platforms", "game_release_languages", "game_release_system_requirements", "videos", "release_group_members"}
+
+// normalisedColumns maps tables to a column with a generated, upper case copy used for case-insensitive search and uniqueness.
+var normalisedColumns = map[string]string{
+	"games":         "title",
+	"platforms":     "name",
+	"game_releases": "title_override",
+}
+
+// Reindex rebuilds the indexes of catalogue tables and refreshes their planner statistics, which is worth doing after bulk imports.
+func Reindex(ctx context.Context) (err error) {
+	defer metrics.ObserveQuery("reindex", time.Now(), &err)
+	for _, table := range catalogueTables {
+		utils.Logger(ctx).Infof("Reindexing table %s", table)
+		if _, err = getConnector(ctx).Exec(fmt.Sprintf("reindex table %s", table)); err != nil {
+			utils.Logger(ctx).Warnf("Failed to reindex table %s: %s", table, err.Error())
+			return err
+		}
+		if _, err = getConnector(ctx).Exec(fmt.Sprintf("analyze %s", table)); err != nil {
+			utils.Logger(ctx).Warnf("Failed to analyze table %s: %s", table, err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+// Recompute regenerates the normalised columns, returning the number of rewritten rows of each table.
+// Postgres only computes them when rows are written, so they go stale when upper() changes, like after a collation or major version upgrade.
+func Recompute(ctx context.Context) (_ map[string]int64, err error) {
+	defer metrics.ObserveQuery("recompute", time.Now(), &err)
+	rewritten := make(map[string]int64, len(normalisedColumns))
+	for table, column := range normalisedColumns {
+		// setting a column to itself is enough for generated columns to be computed again, without bumping entity versions
+		var result gotabase.Result
+		if result, err = getConnector(ctx).Exec(fmt.Sprintf("update %s set %s = %s", table, column, column)); err != nil {
+			utils.Logger(ctx).Warnf("Failed to recompute normalised columns of table %s: %s", table, err.Error())
+			return nil, err
+		}
+		if rewritten[table], err = result.RowsAffected(); err != nil {
+			return nil, err
+		}
+	}
+	return rewritten, nil
+}
@@ -0,0 +1,61 @@
+package maintenance
+
+import (
+	"context"
+	"github.com/Geepr/game/mocks"
+	"github.com/KowalskiPiotr98/gotabase"
+	"testing"
+)
+
+type maintenanceRepoTest struct {
+	connection gotabase.Connector
+	dbName     string
+}
+
+func newMaintenanceRepoTest(t *testing.T) *maintenanceRepoTest {
+	db, name := mocks.GetDatabase()
+	test := &maintenanceRepoTest{
+		connection: db,
+		dbName:     name,
+	}
+	getConnector = func(context.Context) gotabase.Connector { return db }
+	t.Cleanup(test.cleanup)
+	return test
+}
+
+func (test *maintenanceRepoTest) cleanup() {
+	mocks.DropDatabase(test.dbName)
+}
+
+func (test *maintenanceRepoTest) insertMockData() {
+	_, err := test.connection.Exec("insert into games (title, archived) values ('aaa', false), ('bbb', false)")
+	mocks.PanicOnErr(err)
+	_, err = test.connection.Exec("insert into platforms (name, short_name) values ('ccc', 'cc')")
+	mocks.PanicOnErr(err)
+}
+
+func TestMaintenanceRepository_Reindex_Succeeds(t *testing.T) {
+	test := newMaintenanceRepoTest(t)
+	test.insertMockData()
+
+	err := Reindex(context.Background())
+
+	mocks.AssertDefault(t, err)
+}
+
+func TestMaintenanceRepository_Recompute_AllRowsRewritten(t *testing.T) {
+	test := newMaintenanceRepoTest(t)
+	test.insertMockData()
+
+	rewritten, err := Recompute(context.Background())
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, rewritten["games"], int64(2))
+	mocks.AssertEquals(t, rewritten["platforms"], int64(1))
+	mocks.AssertEquals(t, rewritten["game_releases"], int64(0))
+	row, err := test.connection.QueryRow("select version from games where title = 'aaa'")
+	mocks.PanicOnErr(err)
+	var version int
+	mocks.PanicOnErr(row.Scan(&version))
+	mocks.AssertEquals(t, version, 1)
+}
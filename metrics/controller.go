@@ -0,0 +1,11 @@
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// SetupRoutes exposes the metrics in the prometheus format under /metrics, outside the base path, as it's meant for scrapers rather than api users.
+func SetupRoutes(engine *gin.Engine) {
+	engine.GET("/metrics", gin.WrapH(promhttp.Handler()))
+}
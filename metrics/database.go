@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/Geepr/game/utils"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+var (
+	queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Time taken by repository functions, including all of their queries.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"function"})
+	queryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_errors_total",
+		Help:      "Number of repository function calls failing with unexpected errors.",
+	}, []string{"function"})
+)
+
+// expectedErrs are results of invalid requests rather than database failures, so they are not counted as errors.
+var expectedErrs = []error{utils.DataNotFoundErr, utils.DuplicateDataErr, utils.InvalidDataErr, utils.ConflictErr, utils.VersionMismatchErr}
+
+// ObserveQuery records the duration of a repository function and whether it failed, it's meant to be deferred at its start:
+//
+//	defer metrics.ObserveQuery("getGames", time.Now(), &err)
+func ObserveQuery(function string, startTime time.Time, err *error) {
+	queryDuration.WithLabelValues(function).Observe(time.Since(startTime).Seconds())
+	if *err != nil && !isExpected(*err) {
+		queryErrors.WithLabelValues(function).Inc()
+	}
+}
+
+func isExpected(err error) bool {
+	for _, expected := range expectedErrs {
+		if errors.Is(err, expected) {
+			return true
+		}
+	}
+	return false
+}
+
+// Pool statistics are missing on purpose: gotabase doesn't expose the *sql.DB it opens, so there is nothing to pass to
+// collectors.NewDBStatsCollector, and a separate pool wouldn't be the one serving queries.
+
+// catalogueCollector reports the size of the catalogue, counted at the time of scraping.
+type catalogueCollector struct {
+	getConnector func() gotabase.Connector
+	entities     *prometheus.Desc
+}
+
+// RegisterCatalogueGauges exposes the number of games, releases and platforms, read with the connector on every scrape.
+func RegisterCatalogueGauges(getConnector func() gotabase.Connector) {
+	prometheus.MustRegister(&catalogueCollector{
+		getConnector: getConnector,
+		entities:     prometheus.NewDesc(prometheus.BuildFQName(namespace, "catalogue", "entities"), "Number of entities in the catalogue.", []string{"type"}, nil),
+	})
+}
+
+func (c *catalogueCollector) Describe(descriptors chan<- *prometheus.Desc) {
+	descriptors <- c.entities
+}
+
+func (c *catalogueCollector) Collect(metrics chan<- prometheus.Metric) {
+	query := "select (select count(*) from games), (select count(*) from game_releases), (select count(*) from platforms)"
+	result, err := c.getConnector().QueryRow(query)
+	if err != nil {
+		log.Warnf("Failed to count catalogue entities: %s", err.Error())
+		return
+	}
+	var games, releases, platforms int
+	if err = result.Scan(&games, &releases, &platforms); err != nil {
+		log.Warnf("Failed to count catalogue entities: %s", err.Error())
+		return
+	}
+	metrics <- prometheus.MustNewConstMetric(c.entities, prometheus.GaugeValue, float64(games), "game")
+	metrics <- prometheus.MustNewConstMetric(c.entities, prometheus.GaugeValue, float64(releases), "release")
+	metrics <- prometheus.MustNewConstMetric(c.entities, prometheus.GaugeValue, float64(platforms), "platform")
+}
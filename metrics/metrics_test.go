@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Geepr/game/mocks"
+	"github.com/Geepr/game/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestObserveQuery_Errors_OnlyUnexpectedCounted(t *testing.T) {
+	testData := []struct {
+		err     error
+		counted bool
+	}{
+		{nil, false},
+		{utils.DataNotFoundErr, false},
+		{fmt.Errorf("%w: title is empty", utils.InvalidDataErr), false},
+		{utils.VersionMismatchErr, false},
+		{errors.New("connection refused"), true},
+	}
+
+	for i, data := range testData {
+		currentData := data
+		function := fmt.Sprintf("testFunction%d", i)
+		t.Run(function, func(t *testing.T) {
+			err := currentData.err
+
+			ObserveQuery(function, time.Now(), &err)
+
+			mocks.AssertEquals(t, testutil.CollectAndCount(queryDuration, "geepr_db_query_duration_seconds") > 0, true)
+			mocks.AssertEquals(t, testutil.ToFloat64(queryErrors.WithLabelValues(function)) == 1, currentData.counted)
+		})
+	}
+}
+
+func TestGetGinMiddleware_Requests_LabelledByRouteTemplate(t *testing.T) {
+	engine := gin.New()
+	engine.Use(GetGinMiddleware())
+	engine.GET("/games/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, path := range []string{"/games/1", "/games/2", "/unknown"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	mocks.AssertEquals(t, testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/games/:id", "204")), float64(2))
+	mocks.AssertEquals(t, testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "unmatched", "404")), float64(1))
+}
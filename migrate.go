@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/config"
+	"github.com/Geepr/game/database"
+	"github.com/Geepr/game/seed"
+	"github.com/KowalskiPiotr98/gotabase"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+const migrateUsage = "usage: game [flags] migrate [--dry-run] up | down [N] | to VERSION | status"
+
+// runCommand runs a command given after the flags instead of starting the server.
+func runCommand(cfg *config.Config, args []string, output io.Writer) error {
+	if args[0] != "migrate" {
+		return fmt.Errorf("unknown command %q, %s", args[0], migrateUsage)
+	}
+	return runMigrate(cfg, args[1:], output)
+}
+
+// runMigrate inspects the schema or moves it to another version, dry runs print the sql that would be executed instead.
+func runMigrate(cfg *config.Config, args []string, output io.Writer) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "print the sql of planned migrations instead of running it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, err := database.NewMigrator()
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		migrator.DryRun = output
+	}
+	if err = gotabase.InitialiseConnection(cfg.Database.ConnectionString, "postgres"); err != nil {
+		return err
+	}
+	defer gotabase.CloseConnection()
+
+	switch command, argument := flags.Arg(0), flags.Arg(1); command {
+	case "up":
+		if err = migrator.Up(); err != nil || !cfg.Database.Seed {
+			return err
+		}
+		if *dryRun {
+			_, err = fmt.Fprintln(output, "-- seed packs are not applied in dry runs")
+			return err
+		}
+		return applySeedPacks(output)
+	case "down":
+		count := 1
+		if argument != "" {
+			if count, err = strconv.Atoi(argument); err != nil {
+				return fmt.Errorf("number of migrations to revert %q is not a number", argument)
+			}
+		}
+		return migrator.Down(count)
+	case "to":
+		version, err := strconv.Atoi(argument)
+		if err != nil {
+			return fmt.Errorf("target version %q is not a number, %s", argument, migrateUsage)
+		}
+		return migrator.To(version)
+	case "status":
+		return printMigrationStatus(migrator, output)
+	default:
+		return fmt.Errorf("unknown migrate command %q, %s", command, migrateUsage)
+	}
+}
+
+func printMigrationStatus(migrator *database.Migrator, output io.Writer) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "ID\tSTATUS\tAPPLIED AT")
+	for _, status := range statuses {
+		state, appliedAt := "pending", "-"
+		if status.Applied {
+			state, appliedAt = "applied", status.AppliedAt.Format("2006-01-02")
+		}
+		if status.Modified {
+			state = "modified"
+		}
+		_, _ = fmt.Fprintf(writer, "%d\t%s\t%s\n", status.Id, state, appliedAt)
+	}
+	return writer.Flush()
+}
+
+// applySeedPacks applies new versions of seed packs once the schema is up-to-date, reporting the changes of each pack.
+func applySeedPacks(output io.Writer) error {
+	results, err := seed.Apply(context.Background(), false, audit.System)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		if _, err = fmt.Fprintln(output, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
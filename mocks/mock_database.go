@@ -21,7 +21,7 @@ func GetDatabase() (gotabase.Connector, string) {
 		PanicOnErr(gotabase.CloseConnection())
 		PanicOnErr(gotabase.InitialiseConnection(baseConnectionString+dbName.String(), "postgres"))
 	}
-	PanicOnErr(database.RunMigrations(gotabase.GetConnection()))
+	PanicOnErr(database.RunMigrations())
 	return gotabase.GetConnection(), dbName.String()
 }
 
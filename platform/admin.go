@@ -0,0 +1,31 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Geepr/game/audit"
+	"github.com/gofrs/uuid"
+)
+
+// List returns a page of platforms with names matching the query, along with the number of all matching platforms.
+// Unlike the routes, it's used outside of http requests, by gamectl.
+func List(ctx context.Context, nameQuery string, pageIndex int, pageSize int, order SortOrder) ([]*Platform, int, error) {
+	return getPlatforms(ctx, nameQuery, pageIndex, pageSize, order)
+}
+
+func Get(ctx context.Context, id uuid.UUID) (*Platform, error) {
+	return getPlatformById(ctx, id)
+}
+
+// Change creates, updates or deletes a platform, applying the merge patch the same way as change proposals do.
+func Change(ctx context.Context, action audit.Action, id uuid.UUID, changes json.RawMessage, actor audit.Actor) (uuid.UUID, error) {
+	transaction, err := getTransaction(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer transaction.Rollback()
+	if id, err = (proposalApplier{}).Apply(ctx, transaction, action, id, changes, actor); err != nil {
+		return uuid.Nil, err
+	}
+	return id, transaction.Commit()
+}
@@ -2,10 +2,13 @@ package platform
 
 import (
 	"fmt"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/auth"
+	"github.com/Geepr/game/proposal"
 	"github.com/Geepr/game/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
-	log "github.com/sirupsen/logrus"
+	"github.com/gofrs/uuid"
 	"net/http"
 )
 
@@ -16,14 +19,22 @@ func getRoute(c *gin.Context) {
 		PageIndex int       `form:"page"`
 		PageSize  int       `form:"size"`
 	}
-	if err := c.MustBindWith(&query, binding.Query); err != nil {
-		log.Infof("Failed to bind platform query: %s", err.Error())
+	if err := c.ShouldBindWith(&query, binding.Query); err != nil {
+		utils.Logger(c).Infof("Failed to bind platform query: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	if utils.WantsCsv(c) {
+		utils.ServeCsv(c, "platforms.csv", csvHeader, query.PageIndex, query.PageSize, func(pageIndex int, pageSize int) ([][]string, int, error) {
+			platforms, totalItems, err := getPlatforms(c, query.Name, pageIndex, pageSize, query.SortOrder)
+			return toCsvRows(platforms), totalItems, err
+		})
 		return
 	}
 
-	platforms, totalItems, err := getPlatforms(query.Name, query.PageIndex, query.PageSize, query.SortOrder)
+	platforms, totalItems, err := getPlatforms(c, query.Name, query.PageIndex, query.PageSize, query.SortOrder)
 	if err != nil {
-		c.AbortWithStatus(http.StatusInternalServerError)
+		utils.AbortWithProblem(http.StatusInternalServerError, "", c)
 		return
 	}
 
@@ -44,77 +55,146 @@ func getRoute(c *gin.Context) {
 func getByIdRoute(c *gin.Context) {
 	id, err := utils.ParseUuidFromParam(c)
 	if err != nil {
-		c.AbortWithStatus(http.StatusBadRequest)
+		utils.AbortWithBindingError(err, c)
 		return
 	}
 
-	game, err := getPlatformById(id)
+	platform, err := getPlatformById(c, id)
 	if err != nil {
 		utils.AbortWithRelevantError(err, c)
 		return
 	}
+	if utils.AbortIfNotModified(c, platform.Version) {
+		return
+	}
 
-	c.JSON(http.StatusOK, game)
+	utils.SetETag(c, platform.Version)
+	c.JSON(http.StatusOK, platform)
 }
 
 func createRoute(c *gin.Context) {
 	var createModel struct {
-		Name      string `json:"name" binding:"required,max=200"`
-		ShortName string `json:"shortName" binding:"required,max=10"`
-	}
-	if err := c.MustBindWith(&createModel, binding.JSON); err != nil {
-		log.Infof("Failed to parse platform creation model: %s", err.Error())
+		Name         string `json:"name" binding:"required,max=200"`
+		ShortName    string `json:"shortName" binding:"required,max=10"`
+		Manufacturer string `json:"manufacturer" binding:"max=100"`
+		Family       Family `json:"family" binding:"omitempty,oneof=pc console handheld mobile other"`
+	}
+	if err := c.ShouldBindWith(&createModel, binding.JSON); err != nil {
+		utils.Logger(c).Infof("Failed to parse platform creation model: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
 		return
 	}
 
 	platform := Platform{
-		Name:      createModel.Name,
-		ShortName: createModel.ShortName,
+		Name:         createModel.Name,
+		ShortName:    createModel.ShortName,
+		Manufacturer: utils.GetNilIfDefault(createModel.Manufacturer),
+		Family:       createModel.Family.orOther(),
 	}
-	if err := addPlatform(&platform); err != nil {
+	if err := addPlatform(c, &platform, audit.ActorFromContext(c)); err != nil {
 		utils.AbortWithRelevantError(err, c)
 		return
 	}
+	utils.SetETag(c, platform.Version)
 
 	c.JSON(http.StatusCreated, &platform)
 }
 
 func updateRoute(c *gin.Context) {
 	var updateModel struct {
-		Name      string `json:"name" binding:"required,max=200"`
-		ShortName string `json:"shortName" binding:"required,max=10"`
-	}
-	if err := c.MustBindWith(&updateModel, binding.JSON); err != nil {
-		log.Infof("Failed to parse platform creation model: %s", err.Error())
+		Name         string `json:"name" binding:"required,max=200"`
+		ShortName    string `json:"shortName" binding:"required,max=10"`
+		Manufacturer string `json:"manufacturer" binding:"max=100"`
+		Family       Family `json:"family" binding:"omitempty,oneof=pc console handheld mobile other"`
+	}
+	if err := c.ShouldBindWith(&updateModel, binding.JSON); err != nil {
+		utils.Logger(c).Infof("Failed to parse platform creation model: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
 		return
 	}
 	id, err := utils.ParseUuidFromParam(c)
 	if err != nil {
-		c.AbortWithStatus(http.StatusBadRequest)
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	version, err := utils.ParseIfMatch(c, currentVersion(c, id))
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
 		return
 	}
 
 	platform := Platform{
-		Name:      updateModel.Name,
-		ShortName: updateModel.ShortName,
+		Name:         updateModel.Name,
+		ShortName:    updateModel.ShortName,
+		Manufacturer: utils.GetNilIfDefault(updateModel.Manufacturer),
+		Family:       updateModel.Family.orOther(),
+		Version:      version,
 	}
-	if err := updatePlatform(id, &platform); err != nil {
+	if err := updatePlatform(c, id, &platform, audit.ActorFromContext(c)); err != nil {
 		utils.AbortWithRelevantError(err, c)
 		return
 	}
+	utils.SetETag(c, platform.Version)
 
 	platform.Id = id
 	c.JSON(http.StatusOK, &platform)
 }
 
+// patchRoute applies an RFC 7396 merge patch to the platform.
+// The patch is applied to the current state, so the update fails if the platform is changed in the meantime, even with If-Match: *.
+func patchRoute(c *gin.Context) {
+	id, err := utils.ParseUuidFromParam(c)
+	if err != nil {
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	version, err := utils.ParseIfMatch(c, currentVersion(c, id))
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+	patch, err := c.GetRawData()
+	if err != nil {
+		utils.Logger(c).Infof("Failed to read platform patch: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+
+	platform, err := getPlatformById(c, id)
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+	if version != 0 && version != platform.Version {
+		utils.AbortWithRelevantError(utils.VersionMismatchErr, c)
+		return
+	}
+	if err := applyPatch(c, platform, patch); err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+	if err := updatePlatform(c, id, platform, audit.ActorFromContext(c)); err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+
+	utils.SetETag(c, platform.Version)
+	c.JSON(http.StatusOK, platform)
+}
+
 func deleteRoute(c *gin.Context) {
 	id, err := utils.ParseUuidFromParam(c)
 	if err != nil {
-		c.AbortWithStatus(http.StatusBadRequest)
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	version, err := utils.ParseIfMatch(c, currentVersion(c, id))
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
 		return
 	}
 
-	if err := deletePlatform(id); err != nil {
+	if err := deletePlatform(c, id, version, audit.ActorFromContext(c)); err != nil {
 		utils.AbortWithRelevantError(err, c)
 		return
 	}
@@ -122,12 +202,53 @@ func deleteRoute(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+func revertRoute(c *gin.Context) {
+	id, err := utils.ParseUuidFromParam(c)
+	if err != nil {
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	revision, err := audit.ParseRevisionFromParam(c)
+	if err != nil {
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+
+	if err := revertPlatform(c, id, revision, audit.ActorFromContext(c)); err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+
+	platform, err := getPlatformById(c, id)
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+	utils.SetETag(c, platform.Version)
+	c.JSON(http.StatusOK, platform)
+}
+
+// currentVersion reads the version of the platform, for If-Match headers listing several tags.
+func currentVersion(c *gin.Context, id uuid.UUID) func() (int, error) {
+	return func() (int, error) {
+		platform, err := getPlatformById(c, id)
+		if err != nil {
+			return 0, err
+		}
+		return platform.Version, nil
+	}
+}
+
 func SetupRoutes(engine *gin.Engine, basePath string) {
 	baseUrl := fmt.Sprintf("%s/api/v0/platforms", basePath)
+	proposal.RegisterApplier(audit.EntityPlatform, proposalApplier{})
 
 	engine.GET(baseUrl, getRoute)
 	engine.GET(baseUrl+"/:id", getByIdRoute)
-	engine.POST(baseUrl, createRoute)
-	engine.PUT(baseUrl+"/:id", updateRoute)
-	engine.DELETE(baseUrl+"/:id", deleteRoute)
+	engine.POST(baseUrl, auth.Require(auth.ResourcePlatform, auth.ActionCreate), createRoute)
+	engine.PUT(baseUrl+"/:id", auth.Require(auth.ResourcePlatform, auth.ActionUpdate), updateRoute)
+	engine.PATCH(baseUrl+"/:id", auth.Require(auth.ResourcePlatform, auth.ActionUpdate), patchRoute)
+	engine.DELETE(baseUrl+"/:id", auth.Require(auth.ResourcePlatform, auth.ActionDelete), deleteRoute)
+	engine.GET(baseUrl+"/:id/history", auth.Require(auth.ResourceAudit, auth.ActionRead), audit.HistoryRoute(audit.EntityPlatform))
+	engine.POST(baseUrl+"/:id/revisions/:rev/revert", auth.Require(auth.ResourcePlatform, auth.ActionUpdate), revertRoute)
 }
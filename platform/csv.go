@@ -0,0 +1,16 @@
+package platform
+
+import (
+	"github.com/Geepr/game/utils"
+	"strconv"
+)
+
+var csvHeader = []string{"id", "name", "shortName", "manufacturer", "family", "version"}
+
+func toCsvRows(platforms []*Platform) [][]string {
+	rows := make([][]string, len(platforms))
+	for i, platform := range platforms {
+		rows[i] = []string{platform.Id.String(), utils.CsvText(platform.Name), utils.CsvText(platform.ShortName), utils.CsvValue(platform.Manufacturer), string(platform.Family), strconv.Itoa(platform.Version)}
+	}
+	return rows
+}
@@ -10,4 +10,21 @@ type Platform struct {
 	Name string `json:"name"`
 	// ShortName is a shortened Name, useful for display when there's less available space (IE: Sony PlayStation 5 == PS5).
 	ShortName string `json:"shortName"`
+	// Manufacturer is the company making the platform hardware, if known.
+	Manufacturer *string `json:"manufacturer"`
+	// Family is a broad category of the platform, used to decide which release details make sense for it.
+	Family Family `json:"family"`
+	// Version is incremented with every change of the platform, it's also returned in the ETag header.
+	// When updating, a non-zero version is required to match the stored one.
+	Version int `json:"version"`
 }
+
+type Family string
+
+const (
+	FamilyPc       Family = "pc"
+	FamilyConsole  Family = "console"
+	FamilyHandheld Family = "handheld"
+	FamilyMobile   Family = "mobile"
+	FamilyOther    Family = "other"
+)
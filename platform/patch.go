@@ -0,0 +1,25 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Geepr/game/utils"
+)
+
+// patchModel lists the fields of a platform that can be changed by merge patches, both in PATCH requests and change proposals.
+type patchModel struct {
+	Name         string  `json:"name" binding:"required,max=200"`
+	ShortName    string  `json:"shortName" binding:"required,max=10"`
+	Manufacturer *string `json:"manufacturer" binding:"omitempty,max=100"`
+	Family       Family  `json:"family" binding:"omitempty,oneof=pc console handheld mobile other"`
+}
+
+// applyPatch changes the platform according to a merge patch, leaving it untouched if the result is not valid.
+func applyPatch(ctx context.Context, platform *Platform, patch json.RawMessage) error {
+	model := patchModel{Name: platform.Name, ShortName: platform.ShortName, Manufacturer: platform.Manufacturer, Family: platform.Family}
+	if err := utils.ApplyMergePatch(ctx, &model, patch); err != nil {
+		return err
+	}
+	platform.Name, platform.ShortName, platform.Manufacturer, platform.Family = model.Name, model.ShortName, model.Manufacturer, model.Family.orOther()
+	return nil
+}
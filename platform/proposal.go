@@ -0,0 +1,50 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Geepr/game/audit"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/gofrs/uuid"
+)
+
+type proposalApplier struct{}
+
+func (proposalApplier) Check(ctx context.Context, action audit.Action, id uuid.UUID, changes json.RawMessage) error {
+	_, err := getProposedPlatform(ctx, getConnector(ctx), action, id, changes)
+	return err
+}
+
+func (proposalApplier) Apply(ctx context.Context, connector gotabase.Connector, action audit.Action, id uuid.UUID, changes json.RawMessage, actor audit.Actor) (uuid.UUID, error) {
+	platform, err := getProposedPlatform(ctx, connector, action, id, changes)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	switch action {
+	case audit.ActionInsert:
+		err = addPlatformWith(ctx, connector, platform, actor)
+	case audit.ActionUpdate:
+		err = updatePlatformWith(ctx, connector, id, platform, actor)
+	default:
+		err = deletePlatformWith(ctx, connector, id, platform.Version, actor)
+	}
+	return platform.Id, err
+}
+
+// getProposedPlatform returns the platform as it would be after applying the changes.
+func getProposedPlatform(ctx context.Context, connector gotabase.Connector, action audit.Action, id uuid.UUID, changes json.RawMessage) (*Platform, error) {
+	platform := &Platform{}
+	if action != audit.ActionInsert {
+		var err error
+		if platform, err = getPlatformByIdWith(ctx, connector, id); err != nil {
+			return nil, err
+		}
+	}
+	if action == audit.ActionDelete {
+		return platform, nil
+	}
+	if err := applyPatch(ctx, platform, changes); err != nil {
+		return nil, err
+	}
+	return platform, nil
+}
@@ -1,12 +1,17 @@
 package platform
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/metrics"
 	"github.com/Geepr/game/utils"
 	"github.com/KowalskiPiotr98/gotabase"
 	"github.com/gofrs/uuid"
-	log "github.com/sirupsen/logrus"
 	"strings"
+	"time"
 )
 
 type SortOrder uint8
@@ -17,80 +22,138 @@ const (
 	SortByShortName
 )
 
-func getPlatforms(nameQuery string, pageIndex int, pageSize int, order SortOrder) ([]*Platform, int, error) {
-	query := "select id, name, short_name from platforms"
+func getPlatforms(ctx context.Context, nameQuery string, pageIndex int, pageSize int, order SortOrder) (_ []*Platform, _ int, err error) {
+	defer metrics.ObserveQuery("getPlatforms", time.Now(), &err)
+	query := "select id, name, short_name, manufacturer, family, version from platforms"
 	query, args := utils.AppendWhereClause(query, "name_normalised", "like", utils.MakeLikeQuery(strings.ToUpper(nameQuery)), utils.IsStringNotEmpty, []any{})
 	query += fmt.Sprintf(" order by %s", order.getSqlColumnName())
 	query, countQuery, err := utils.Paginate(query, pageIndex, pageSize)
 	if err != nil {
 		return nil, 0, err
 	}
-	countResults, err := utils.ScanCountQuery(getConnector(), countQuery, args...)
+	countResults, err := utils.ScanCountQuery(ctx, getConnector(ctx), countQuery, args...)
 	if err != nil {
 		return nil, 0, err
 	}
-	platforms, err := scanPlatforms(query, args...)
+	platforms, err := scanPlatforms(ctx, query, args...)
 	return platforms, countResults, err
 }
 
-func getPlatformById(id uuid.UUID) (*Platform, error) {
-	query := "select id, name, short_name from platforms where id = $1"
-	return scanPlatform(query, id)
+func getPlatformById(ctx context.Context, id uuid.UUID) (*Platform, error) {
+	return getPlatformByIdWith(ctx, getConnector(ctx), id)
 }
 
-func addPlatform(platform *Platform) error {
-	query := "insert into platforms (name, short_name) VALUES ($1, $2) returning id"
-	result, err := getConnector().QueryRow(query, platform.Name, platform.ShortName)
+// getPlatformByIdWith reads the platform with the connector, which sees changes made earlier in its transaction.
+func getPlatformByIdWith(ctx context.Context, connector gotabase.Connector, id uuid.UUID) (_ *Platform, err error) {
+	defer metrics.ObserveQuery("getPlatformById", time.Now(), &err)
+	query := "select id, name, short_name, manufacturer, family, version from platforms where id = $1"
+	return scanPlatform(ctx, connector, query, id)
+}
+
+func addPlatform(ctx context.Context, platform *Platform, actor audit.Actor) error {
+	transaction, err := getTransaction(ctx)
 	if err != nil {
-		log.Warnf("Failed to execute insert query on platforms table: %s", err.Error())
-		return utils.ConvertIfDuplicateErr(err)
+		return err
 	}
-	if err = result.Scan(&platform.Id); err != nil {
+	defer transaction.Rollback()
+	if err = addPlatformWith(ctx, transaction, platform, actor); err != nil {
 		return err
 	}
-	return nil
+	return transaction.Commit()
 }
 
-func updatePlatform(id uuid.UUID, updatedPlatform *Platform) error {
-	query := "update platforms set name = $2, short_name = $3 where id = $1"
-	result, err := getConnector().Exec(query, id, updatedPlatform.Name, updatedPlatform.ShortName)
+// addPlatformWith stores the platform with the connector, which should be a transaction, so that the platform isn't stored without its audit entry.
+func addPlatformWith(ctx context.Context, connector gotabase.Connector, platform *Platform, actor audit.Actor) (err error) {
+	defer metrics.ObserveQuery("addPlatform", time.Now(), &err)
+	query := "insert into platforms (name, short_name, manufacturer, family) VALUES ($1, $2, $3, $4) returning id, version"
+	result, err := connector.QueryRow(query, platform.Name, platform.ShortName, platform.Manufacturer, platform.Family.orOther())
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute insert query on platforms table: %s", err.Error())
+		return utils.ConvertIfDuplicateErr(err)
+	}
+	if err = result.Scan(&platform.Id, &platform.Version); err != nil {
+		return utils.ConvertIfDuplicateErr(err)
+	}
+	return audit.Record(ctx, connector, audit.EntityPlatform, platform.Id, audit.ActionInsert, actor, nil)
+}
+
+func updatePlatform(ctx context.Context, id uuid.UUID, updatedPlatform *Platform, actor audit.Actor) error {
+	transaction, err := getTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	defer transaction.Rollback()
+	if err = updatePlatformWith(ctx, transaction, id, updatedPlatform, actor); err != nil {
+		return err
+	}
+	return transaction.Commit()
+}
 
+// updatePlatformWith changes the platform with the connector, which should be a transaction, like addPlatformWith.
+func updatePlatformWith(ctx context.Context, connector gotabase.Connector, id uuid.UUID, updatedPlatform *Platform, actor audit.Actor) (err error) {
+	defer metrics.ObserveQuery("updatePlatform", time.Now(), &err)
+	query := "update platforms set name = $2, short_name = $3, manufacturer = $4, family = $5, version = version + 1 where id = $1 and ($6 = 0 or version = $6) returning version"
+	before, err := audit.Snapshot(ctx, connector, audit.EntityPlatform, id)
+	if err != nil {
+		return err
+	}
+	result, err := connector.QueryRow(query, id, updatedPlatform.Name, updatedPlatform.ShortName, updatedPlatform.Manufacturer, updatedPlatform.Family.orOther(), updatedPlatform.Version)
 	if err != nil {
 		return utils.ConvertIfDuplicateErr(err)
 	}
-	affected, err := result.RowsAffected()
+	if err = result.Scan(&updatedPlatform.Version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.ConvertIfVersionMismatchErr(err, before != nil)
+		}
+		return utils.ConvertIfDuplicateErr(err)
+	}
+	return audit.Record(ctx, connector, audit.EntityPlatform, id, audit.ActionUpdate, actor, before)
+}
+
+// deletePlatform removes the platform, a non-zero version is required to match the stored one.
+func deletePlatform(ctx context.Context, id uuid.UUID, version int, actor audit.Actor) error {
+	transaction, err := getTransaction(ctx)
 	if err != nil {
-		log.Warnf("Failed to get affected rows count when running update query on platgorms table: %s", err.Error())
 		return err
 	}
-	if affected != 1 {
-		return utils.DataNotFoundErr
+	defer transaction.Rollback()
+	if err = deletePlatformWith(ctx, transaction, id, version, actor); err != nil {
+		return err
 	}
-	return nil
+	return transaction.Commit()
 }
 
-func deletePlatform(id uuid.UUID) error {
-	query := "delete from platforms where id = $1"
-	result, err := getConnector().Exec(query, id)
+// deletePlatformWith removes the platform with the connector, which should be a transaction, like addPlatformWith.
+func deletePlatformWith(ctx context.Context, connector gotabase.Connector, id uuid.UUID, version int, actor audit.Actor) (err error) {
+	defer metrics.ObserveQuery("deletePlatform", time.Now(), &err)
+	query := "delete from platforms where id = $1 and ($2 = 0 or version = $2)"
+	before, err := audit.Snapshot(ctx, connector, audit.EntityPlatform, id)
 	if err != nil {
-		log.Warnf("Failed to execute delete query on platforms table: %s", err.Error())
+		return err
+	}
+	result, err := connector.Exec(query, id, version)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute delete query on platforms table: %s", err.Error())
 		return err
 	}
 	affected, err := result.RowsAffected()
 	if err != nil {
-		log.Warnf("Failed to get affected rows count when running delete query on platforms table: %s", err.Error())
+		utils.Logger(ctx).Warnf("Failed to get affected rows count when running delete query on platforms table: %s", err.Error())
 		return err
 	}
 	if affected != 1 {
+		if before != nil {
+			return utils.VersionMismatchErr
+		}
 		return utils.DataNotFoundErr
 	}
-	return nil
+	return audit.Record(ctx, connector, audit.EntityPlatform, id, audit.ActionDelete, actor, before)
 }
 
-func scanPlatforms(sql string, args ...interface{}) ([]*Platform, error) {
-	result, err := getConnector().QueryRows(sql, args...)
+func scanPlatforms(ctx context.Context, sql string, args ...interface{}) ([]*Platform, error) {
+	result, err := getConnector(ctx).QueryRows(sql, args...)
 	if err != nil {
-		log.Warnf("Failed to run query on platforms table: %s", err.Error())
+		utils.Logger(ctx).Warnf("Failed to run query on platforms table: %s", err.Error())
 		return nil, err
 	}
 	defer result.Close()
@@ -107,10 +170,10 @@ func scanPlatforms(sql string, args ...interface{}) ([]*Platform, error) {
 	return platforms, nil
 }
 
-func scanPlatform(sql string, args ...interface{}) (*Platform, error) {
-	result, err := getConnector().QueryRow(sql, args...)
+func scanPlatform(ctx context.Context, connector gotabase.Connector, sql string, args ...interface{}) (*Platform, error) {
+	result, err := connector.QueryRow(sql, args...)
 	if err != nil {
-		log.Warnf("Failed to run query on platforms table: %s", err.Error())
+		utils.Logger(ctx).Warnf("Failed to run query on platforms table: %s", err.Error())
 		return nil, err
 	}
 	return scanRow(result)
@@ -118,7 +181,7 @@ func scanPlatform(sql string, args ...interface{}) (*Platform, error) {
 
 func scanRow(row gotabase.Row) (*Platform, error) {
 	platform := Platform{}
-	if err := row.Scan(&platform.Id, &platform.Name, &platform.ShortName); err != nil {
+	if err := row.Scan(&platform.Id, &platform.Name, &platform.ShortName, &platform.Manufacturer, &platform.Family, &platform.Version); err != nil {
 		return nil, utils.ConvertIfNotFoundErr(err)
 	}
 	return &platform, nil
@@ -135,3 +198,45 @@ func (o SortOrder) getSqlColumnName() string {
 	}
 	return "id"
 }
+
+func (f Family) orOther() Family {
+	if f == "" {
+		return FamilyOther
+	}
+	return f
+}
+
+// revertPlatform restores the fields of a platform to the state recorded by an audit revision.
+// Platforms deleted since are created again, with the same id.
+func revertPlatform(ctx context.Context, id uuid.UUID, revision int64, actor audit.Actor) (err error) {
+	defer metrics.ObserveQuery("revertPlatform", time.Now(), &err)
+	transaction, err := getTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	defer transaction.Rollback()
+	state, err := audit.GetRevision(ctx, transaction, audit.EntityPlatform, id, revision)
+	if err != nil {
+		return err
+	}
+	before, err := audit.Snapshot(ctx, transaction, audit.EntityPlatform, id)
+	if err != nil {
+		return err
+	}
+	// populating the record over the current row keeps the values of columns missing in older revisions
+	query := "update platforms p set version = p.version + 1, (name, short_name, manufacturer, family) = (select r.name, r.short_name, r.manufacturer, r.family from jsonb_populate_record(p, $2) r) where p.id = $1"
+	action := audit.ActionUpdate
+	if before == nil {
+		query = "insert into platforms (id, name, short_name, manufacturer, family) select $1, r.name, r.short_name, r.manufacturer, coalesce(r.family, 'other') " +
+			"from jsonb_populate_record(null::platforms, $2) r"
+		action = audit.ActionInsert
+	}
+	if _, err = transaction.Exec(query, id, string(state)); err != nil {
+		utils.Logger(ctx).Warnf("Failed to revert platform: %s", err.Error())
+		return utils.ConvertIfDuplicateErr(err)
+	}
+	if err = audit.Record(ctx, transaction, audit.EntityPlatform, id, action, actor, before); err != nil {
+		return err
+	}
+	return transaction.Commit()
+}
@@ -1,6 +1,8 @@
 package platform
 
 import (
+	"context"
+	"github.com/Geepr/game/audit"
 	"github.com/Geepr/game/mocks"
 	"github.com/Geepr/game/utils"
 	"github.com/KowalskiPiotr98/gotabase"
@@ -21,7 +23,7 @@ func newPlatformRepoTest(t *testing.T) *platformRepoTest {
 		connection: db,
 		dbName:     name,
 	}
-	getConnector = func() gotabase.Connector { return db }
+	getConnector = func(context.Context) gotabase.Connector { return db }
 	t.Cleanup(test.cleanup)
 	return test
 }
@@ -65,7 +67,7 @@ func TestPlatformRepository_GetPlatforms_NoParametersSet_ReturnsAllPlatforms(t *
 	test := newPlatformRepoTest(t)
 	test.insertMockData()
 
-	result, items, err := getPlatforms("", 0, 100, SortById)
+	result, items, err := getPlatforms(context.Background(), "", 0, 100, SortById)
 
 	mocks.AssertDefault(t, err)
 	mocks.AssertCountEqual(t, result, 4)
@@ -81,7 +83,7 @@ func TestPlatformRepository_GetPlatforms_NameQueryDefined_ReturnsMatching(t *tes
 	test := newPlatformRepoTest(t)
 	test.insertMockData()
 
-	result, items, err := getPlatforms("Aa", 0, 100, SortById)
+	result, items, err := getPlatforms(context.Background(), "Aa", 0, 100, SortById)
 
 	mocks.AssertDefault(t, err)
 	mocks.AssertCountEqual(t, result, 2)
@@ -100,7 +102,7 @@ func TestPlatformRepository_GetPlatforms_TitleQueryDefinedAndNotFound_ReturnsEmp
 	test := newPlatformRepoTest(t)
 	test.insertMockData()
 
-	result, items, err := getPlatforms("definitely not found", 0, 100, SortById)
+	result, items, err := getPlatforms(context.Background(), "definitely not found", 0, 100, SortById)
 
 	mocks.AssertDefault(t, err)
 	mocks.AssertCountEqual(t, result, 0)
@@ -114,7 +116,7 @@ func TestPlatformRepository_GetPlatformById_ValidId_FoundAndReturned(t *testing.
 	for _, testCaseGlobal := range test.mockData {
 		testCase := testCaseGlobal
 		t.Run(testCase.Id.String(), func(t *testing.T) {
-			result, err := getPlatformById(testCase.Id)
+			result, err := getPlatformById(context.Background(), testCase.Id)
 
 			mocks.AssertDefault(t, err)
 			mocks.AssertEquals(t, result.Id, testCase.Id)
@@ -129,7 +131,7 @@ func TestPlatformRepository_GetPlatformById_PlatformIdNotFound_ReturnsSpecificEr
 	test.insertMockData()
 	testId, _ := uuid.NewV4()
 
-	_, err := getPlatformById(testId)
+	_, err := getPlatformById(context.Background(), testId)
 
 	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
 }
@@ -142,7 +144,7 @@ func TestPlatformRepository_AddPlatform_ValidNewPlatform_PlatformAdded(t *testin
 		ShortName: "test",
 	}
 
-	err := addPlatform(&newPlatform)
+	err := addPlatform(context.Background(), &newPlatform, audit.System)
 
 	mocks.AssertDefault(t, err)
 	mocks.AssertNotDefault(t, newPlatform.Id)
@@ -157,7 +159,7 @@ func TestPlatformRepository_AddPlatform_DuplicateName_ErrorReturned(t *testing.T
 		ShortName: toDuplicate.ShortName,
 	}
 
-	err := addPlatform(&duplicate)
+	err := addPlatform(context.Background(), &duplicate, audit.System)
 
 	mocks.AssertEquals(t, err, utils.DuplicateDataErr)
 }
@@ -168,14 +170,16 @@ func TestPlatformRepository_UpdatePlatform_PlatformExists_Updates(t *testing.T)
 	modified := test.mockData[0]
 	modified.Name = "new name"
 	modified.ShortName = "nn"
+	modified.Family = FamilyPc
 
-	err := updatePlatform(modified.Id, modified)
+	err := updatePlatform(context.Background(), modified.Id, modified, audit.System)
 
 	mocks.AssertDefault(t, err)
-	loaded, _ := getPlatformById(modified.Id)
+	loaded, _ := getPlatformById(context.Background(), modified.Id)
 	mocks.AssertEquals(t, loaded.Id, modified.Id)
 	mocks.AssertEquals(t, loaded.Name, modified.Name)
 	mocks.AssertEquals(t, loaded.ShortName, modified.ShortName)
+	mocks.AssertEquals(t, loaded.Family, FamilyPc)
 }
 
 func TestPlatformRepository_UpdatePlatform_NewNameDuplicate_ReturnsErr(t *testing.T) {
@@ -186,7 +190,7 @@ func TestPlatformRepository_UpdatePlatform_NewNameDuplicate_ReturnsErr(t *testin
 	modified.Name = toDuplicate.Name
 	modified.ShortName = toDuplicate.ShortName
 
-	err := updatePlatform(modified.Id, modified)
+	err := updatePlatform(context.Background(), modified.Id, modified, audit.System)
 
 	mocks.AssertEquals(t, err, utils.DuplicateDataErr)
 }
@@ -197,7 +201,7 @@ func TestPlatformRepository_UpdatePlatform_PlatformMissing_ReturnsNotFound(t *te
 	fakeId, _ := uuid.NewV4()
 	modified := test.mockData[0]
 
-	err := updatePlatform(fakeId, modified)
+	err := updatePlatform(context.Background(), fakeId, modified, audit.System)
 
 	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
 }
@@ -207,10 +211,10 @@ func TestPlatformRepository_DeletePlatform_PlatformExists_RemovesPlatform(t *tes
 	test.insertMockData()
 	toDelete := test.mockData[2]
 
-	err := deletePlatform(toDelete.Id)
+	err := deletePlatform(context.Background(), toDelete.Id, 0, audit.System)
 
 	mocks.AssertDefault(t, err)
-	_, err = getPlatformById(toDelete.Id)
+	_, err = getPlatformById(context.Background(), toDelete.Id)
 	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
 }
 
@@ -219,7 +223,30 @@ func TestPlatformRepository_DeletePlatform_MissingId_ReturnsNotFound(t *testing.
 	test.insertMockData()
 	fakeId, _ := uuid.NewV4()
 
-	err := deletePlatform(fakeId)
+	err := deletePlatform(context.Background(), fakeId, 0, audit.System)
 
 	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
 }
+
+func TestPlatformRepository_RevertPlatform_Exists_FieldsRestored(t *testing.T) {
+	test := newPlatformRepoTest(t)
+	test.insertMockData()
+	modified := test.mockData[0]
+	originalName := modified.Name
+	modified.Family = FamilyConsole
+	mocks.PanicOnErr(updatePlatform(context.Background(), modified.Id, modified, audit.System))
+	var revision int64
+	row, err := test.connection.QueryRow("select max(id) from audit_log")
+	mocks.PanicOnErr(err)
+	mocks.PanicOnErr(row.Scan(&revision))
+	modified.Name = "changed"
+	modified.Family = FamilyPc
+	mocks.PanicOnErr(updatePlatform(context.Background(), modified.Id, modified, audit.System))
+
+	err = revertPlatform(context.Background(), modified.Id, revision, audit.System)
+
+	mocks.AssertDefault(t, err)
+	loaded, _ := getPlatformById(context.Background(), modified.Id)
+	mocks.AssertEquals(t, loaded.Name, originalName)
+	mocks.AssertEquals(t, loaded.Family, FamilyConsole)
+}
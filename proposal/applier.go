@@ -0,0 +1,36 @@
+package proposal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/utils"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/gofrs/uuid"
+)
+
+// Applier validates and applies proposed changes of a single entity type, using the repository functions of the package owning it.
+// Changes are merge patches, applied with utils.ApplyMergePatch.
+type Applier interface {
+	// Check validates the changes against the current state of the entity, without modifying anything.
+	Check(ctx context.Context, action audit.Action, id uuid.UUID, changes json.RawMessage) error
+	// Apply makes the change on behalf of the actor and returns the id of the affected entity.
+	// The change, along with its audit entry, is made with the connector, which is the transaction the proposal is reviewed in.
+	Apply(ctx context.Context, connector gotabase.Connector, action audit.Action, id uuid.UUID, changes json.RawMessage, actor audit.Actor) (uuid.UUID, error)
+}
+
+var appliers = map[audit.EntityType]Applier{}
+
+// RegisterApplier makes proposals of the entity type possible, it's meant to be called when setting up the routes of the entity.
+func RegisterApplier(entityType audit.EntityType, applier Applier) {
+	appliers[entityType] = applier
+}
+
+func getApplier(entityType audit.EntityType) (Applier, error) {
+	applier, ok := appliers[entityType]
+	if !ok {
+		return nil, fmt.Errorf("%w: proposals of %s are not supported", utils.InvalidDataErr, entityType)
+	}
+	return applier, nil
+}
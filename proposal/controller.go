@@ -0,0 +1,173 @@
+package proposal
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/auth"
+	"github.com/Geepr/game/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gofrs/uuid"
+	"net/http"
+)
+
+// entityResources maps the proposed entities to the resources that reviewers need permissions for.
+var entityResources = map[audit.EntityType]auth.Resource{
+	audit.EntityGame:     auth.ResourceGame,
+	audit.EntityPlatform: auth.ResourcePlatform,
+	audit.EntityRelease:  auth.ResourceRelease,
+}
+
+var actionPermissions = map[audit.Action]auth.Action{
+	audit.ActionInsert: auth.ActionCreate,
+	audit.ActionUpdate: auth.ActionUpdate,
+	audit.ActionDelete: auth.ActionDelete,
+}
+
+func getRoute(c *gin.Context) {
+	var query struct {
+		Status     Status           `form:"status" binding:"omitempty,oneof=pending approved rejected conflicted"`
+		EntityType audit.EntityType `form:"entityType" binding:"omitempty,oneof=game platform release"`
+		EntityId   string           `form:"entityId" binding:"omitempty,uuid"`
+		Proposer   string           `form:"proposer"`
+		PageIndex  int              `form:"page"`
+		PageSize   int              `form:"size"`
+	}
+	if err := c.ShouldBindWith(&query, binding.Query); err != nil {
+		utils.Logger(c).Infof("Failed to bind change proposal query: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+
+	filter := proposalFilter{
+		Status:          query.Status,
+		EntityType:      query.EntityType,
+		EntityId:        uuid.FromStringOrNil(query.EntityId),
+		ProposerSubject: query.Proposer,
+	}
+	proposals, totalItems, err := getProposals(c, filter, query.PageIndex, query.PageSize)
+	if err != nil {
+		utils.AbortWithProblem(http.StatusInternalServerError, "", c)
+		return
+	}
+
+	response := struct {
+		Proposals  []*Proposal `json:"proposals"`
+		Page       int         `json:"page"`
+		PageSize   int         `json:"pageSize"`
+		TotalPages int         `json:"totalPages"`
+	}{
+		Proposals:  proposals,
+		Page:       query.PageIndex,
+		PageSize:   query.PageSize,
+		TotalPages: utils.GetPagesFromItems(totalItems, query.PageSize),
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func getByIdRoute(c *gin.Context) {
+	id, err := utils.ParseUuidFromParam(c)
+	if err != nil {
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+
+	proposal, err := getProposalById(c, id)
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, proposal)
+}
+
+func createRoute(c *gin.Context) {
+	var createModel struct {
+		EntityType audit.EntityType `json:"entityType" binding:"required,oneof=game platform release"`
+		EntityId   uuid.UUID        `json:"entityId" binding:"required_unless=Action insert"`
+		Action     audit.Action     `json:"action" binding:"required,oneof=insert update delete"`
+		Changes    json.RawMessage  `json:"changes" binding:"required_unless=Action delete"`
+	}
+	if err := c.ShouldBindWith(&createModel, binding.JSON); err != nil {
+		utils.Logger(c).Infof("Failed to parse change proposal creation model: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+
+	proposal := Proposal{
+		EntityType: createModel.EntityType,
+		EntityId:   utils.GetNilIfDefault(createModel.EntityId),
+		Action:     createModel.Action,
+		Changes:    createModel.Changes,
+		Proposer:   audit.ActorFromContext(c),
+	}
+	proposal.Proposer.RequestId = nil
+	if err := addProposal(c, &proposal); err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, &proposal)
+}
+
+// reviewRoute approves or rejects a proposal, with an optional comment for the proposer.
+func reviewRoute(approve bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reviewModel struct {
+			Comment string `json:"comment" binding:"max=2000"`
+		}
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindWith(&reviewModel, binding.JSON); err != nil {
+				utils.Logger(c).Infof("Failed to parse change proposal review model: %s", err.Error())
+				utils.AbortWithBindingError(err, c)
+				return
+			}
+		}
+		id, err := utils.ParseUuidFromParam(c)
+		if err != nil {
+			utils.AbortWithBindingError(err, c)
+			return
+		}
+
+		review := rejectProposal
+		if approve {
+			if !canApply(c, id) {
+				return
+			}
+			review = approveProposal
+		}
+		proposal, err := review(c, id, audit.ActorFromContext(c), utils.GetNilIfDefault(reviewModel.Comment))
+		if err != nil {
+			utils.AbortWithRelevantError(err, c)
+			return
+		}
+
+		c.JSON(http.StatusOK, proposal)
+	}
+}
+
+// canApply checks whether the reviewer would be allowed to make the proposed change directly, aborting the request if not.
+func canApply(c *gin.Context, id uuid.UUID) bool {
+	proposal, err := getProposalById(c, id)
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return false
+	}
+	principal := auth.GetPrincipal(c)
+	if principal == nil || !principal.Role.Can(entityResources[proposal.EntityType], actionPermissions[proposal.Action]) {
+		utils.AbortWithProblem(http.StatusForbidden, "", c)
+		return false
+	}
+	return true
+}
+
+func SetupRoutes(engine *gin.Engine, basePath string) {
+	baseUrl := fmt.Sprintf("%s/api/v0/proposals", basePath)
+
+	engine.GET(baseUrl, auth.Require(auth.ResourceProposal, auth.ActionRead), getRoute)
+	engine.GET(baseUrl+"/:id", auth.Require(auth.ResourceProposal, auth.ActionRead), getByIdRoute)
+	engine.POST(baseUrl, auth.Require(auth.ResourceProposal, auth.ActionCreate), createRoute)
+	engine.POST(baseUrl+"/:id/approve", auth.Require(auth.ResourceProposal, auth.ActionUpdate), reviewRoute(true))
+	engine.POST(baseUrl+"/:id/reject", auth.Require(auth.ResourceProposal, auth.ActionUpdate), reviewRoute(false))
+}
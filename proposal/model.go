@@ -0,0 +1,40 @@
+package proposal
+
+import (
+	"encoding/json"
+	"github.com/Geepr/game/audit"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusApproved Status = "approved"
+	StatusRejected Status = "rejected"
+	// StatusConflicted proposals were based on a state of the entity that has been changed before they got approved.
+	StatusConflicted Status = "conflicted"
+)
+
+// Proposal is a change of a catalogue entity that is submitted for review instead of being applied directly.
+type Proposal struct {
+	Id         uuid.UUID        `json:"id"`
+	EntityType audit.EntityType `json:"entityType"`
+	// EntityId is nil for proposals creating a new entity, until they are approved.
+	EntityId *uuid.UUID   `json:"entityId"`
+	Action   audit.Action `json:"action"`
+	// Changes is a merge patch of the proposed field values, fields missing from it are left as they are.
+	// It's nil for deletions.
+	Changes json.RawMessage `json:"changes"`
+	// BaseRevision is the latest audit revision of the entity when the proposal was submitted.
+	// It's used to detect changes made before the proposal is approved.
+	BaseRevision int64  `json:"baseRevision"`
+	Status       Status `json:"status"`
+	// Proposer is only filled with the kind and subject of the submitting principal.
+	Proposer        audit.Actor `json:"proposer"`
+	ReviewerSubject *string     `json:"reviewerSubject"`
+	ReviewComment   *string     `json:"reviewComment"`
+	CreatedAt       time.Time   `json:"createdAt"`
+	ReviewedAt      *time.Time  `json:"reviewedAt"`
+}
@@ -0,0 +1,227 @@
+package proposal
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/metrics"
+	"github.com/Geepr/game/utils"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+const proposalColumns = "id, entity_type, entity_id, action, changes, base_revision, status, proposer_kind, proposer_subject, reviewer_subject, review_comment, created_at, reviewed_at"
+
+type proposalFilter struct {
+	Status          Status
+	EntityType      audit.EntityType
+	EntityId        uuid.UUID
+	ProposerSubject string
+}
+
+func getProposals(ctx context.Context, filter proposalFilter, pageIndex int, pageSize int) (_ []*Proposal, _ int, err error) {
+	defer metrics.ObserveQuery("getProposals", time.Now(), &err)
+	query := "select " + proposalColumns + " from change_proposals"
+	query, args := utils.AppendWhereClause(query, "status", "=", filter.Status, func(s Status) bool { return s != "" }, []any{})
+	query, args = utils.AppendWhereClause(query, "entity_type", "=", filter.EntityType, func(t audit.EntityType) bool { return t != "" }, args)
+	query, args = utils.AppendWhereClause(query, "entity_id", "=", filter.EntityId, utils.IsUuidNotEmpty, args)
+	query, args = utils.AppendWhereClause(query, "proposer_subject", "=", filter.ProposerSubject, utils.IsStringNotEmpty, args)
+	// the oldest proposals are reviewed first
+	query += " order by created_at, id"
+	query, countQuery, err := utils.Paginate(query, pageIndex, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	countResults, err := utils.ScanCountQuery(ctx, getConnector(ctx), countQuery, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	proposals, err := scanProposals(ctx, query, args...)
+	return proposals, countResults, err
+}
+
+func getProposalById(ctx context.Context, id uuid.UUID) (_ *Proposal, err error) {
+	defer metrics.ObserveQuery("getProposalById", time.Now(), &err)
+	return getProposalForReview(ctx, getConnector(ctx), id, false)
+}
+
+// getProposalForReview reads a single proposal, optionally locking it until the end of the transaction of the connector.
+func getProposalForReview(ctx context.Context, connector gotabase.Connector, id uuid.UUID, lock bool) (*Proposal, error) {
+	query := "select " + proposalColumns + " from change_proposals where id = $1"
+	if lock {
+		query += " for update"
+	}
+	result, err := connector.QueryRow(query, id)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute select query on change proposals: %s", err.Error())
+		return nil, err
+	}
+	return scanRow(result)
+}
+
+// addProposal validates the proposed changes and stores them for review.
+// The latest revision of the entity is stored along them, to detect conflicting changes on approval.
+func addProposal(ctx context.Context, proposal *Proposal) (err error) {
+	defer metrics.ObserveQuery("addProposal", time.Now(), &err)
+	applier, err := getApplier(proposal.EntityType)
+	if err != nil {
+		return err
+	}
+	var entityId uuid.UUID
+	if proposal.Action == audit.ActionInsert {
+		proposal.EntityId = nil
+	} else if proposal.EntityId == nil {
+		return utils.InvalidDataErr
+	} else {
+		entityId = *proposal.EntityId
+	}
+	if proposal.Action == audit.ActionDelete {
+		proposal.Changes = nil
+	}
+	if err = applier.Check(ctx, proposal.Action, entityId, proposal.Changes); err != nil {
+		return err
+	}
+	if proposal.EntityId != nil {
+		if proposal.BaseRevision, err = audit.LatestRevision(ctx, getConnector(ctx), proposal.EntityType, entityId); err != nil {
+			return err
+		}
+	}
+
+	query := "insert into change_proposals (entity_type, entity_id, action, changes, base_revision, proposer_kind, proposer_subject) " +
+		"values ($1, $2, $3, $4, $5, $6, $7) returning id, status, created_at"
+	result, err := getConnector(ctx).QueryRow(query, proposal.EntityType, proposal.EntityId, proposal.Action, nullableJson(proposal.Changes),
+		proposal.BaseRevision, proposal.Proposer.Kind, proposal.Proposer.Subject)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute insert query on change proposals: %s", err.Error())
+		return err
+	}
+	return result.Scan(&proposal.Id, &proposal.Status, &proposal.CreatedAt)
+}
+
+// approveProposal applies a pending proposal on behalf of the reviewer.
+// If the entity has been changed since the proposal was submitted, it's marked as conflicted and ConflictErr is returned instead.
+func approveProposal(ctx context.Context, id uuid.UUID, reviewer audit.Actor, comment *string) (_ *Proposal, err error) {
+	defer metrics.ObserveQuery("approveProposal", time.Now(), &err)
+	transaction, err := getTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer transaction.Rollback()
+	proposal, err := getProposalForReview(ctx, transaction, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if proposal.Status != StatusPending {
+		return nil, utils.InvalidDataErr
+	}
+	applier, err := getApplier(proposal.EntityType)
+	if err != nil {
+		return nil, err
+	}
+
+	var entityId uuid.UUID
+	if proposal.EntityId != nil {
+		entityId = *proposal.EntityId
+		// the entity stays locked until the change is applied, so that nothing can change it after the revision is checked
+		if err = audit.Lock(ctx, transaction, proposal.EntityType, entityId); err != nil {
+			return nil, err
+		}
+		revision, err := audit.LatestRevision(ctx, transaction, proposal.EntityType, entityId)
+		if err != nil {
+			return nil, err
+		}
+		if revision != proposal.BaseRevision {
+			if err = setReviewed(ctx, transaction, proposal, StatusConflicted, reviewer, comment); err != nil {
+				return nil, err
+			}
+			if err = transaction.Commit(); err != nil {
+				return nil, err
+			}
+			return nil, utils.ConflictErr
+		}
+	}
+
+	// the change is applied in the same transaction, so that the proposal can't be left pending once the entity is changed
+	if entityId, err = applier.Apply(ctx, transaction, proposal.Action, entityId, proposal.Changes, reviewer); err != nil {
+		return nil, err
+	}
+	proposal.EntityId = &entityId
+	if err = setReviewed(ctx, transaction, proposal, StatusApproved, reviewer, comment); err != nil {
+		return nil, err
+	}
+	return proposal, transaction.Commit()
+}
+
+func rejectProposal(ctx context.Context, id uuid.UUID, reviewer audit.Actor, comment *string) (_ *Proposal, err error) {
+	defer metrics.ObserveQuery("rejectProposal", time.Now(), &err)
+	transaction, err := getTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer transaction.Rollback()
+	proposal, err := getProposalForReview(ctx, transaction, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if proposal.Status != StatusPending {
+		return nil, utils.InvalidDataErr
+	}
+	if err = setReviewed(ctx, transaction, proposal, StatusRejected, reviewer, comment); err != nil {
+		return nil, err
+	}
+	return proposal, transaction.Commit()
+}
+
+func setReviewed(ctx context.Context, connector gotabase.Connector, proposal *Proposal, status Status, reviewer audit.Actor, comment *string) error {
+	query := "update change_proposals set status = $2, entity_id = $3, reviewer_subject = $4, review_comment = $5, reviewed_at = now() where id = $1 returning reviewed_at"
+	result, err := connector.QueryRow(query, proposal.Id, status, proposal.EntityId, reviewer.Subject, comment)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute update query on change proposals: %s", err.Error())
+		return err
+	}
+	if err = result.Scan(&proposal.ReviewedAt); err != nil {
+		return utils.ConvertIfNotFoundErr(err)
+	}
+	proposal.Status = status
+	proposal.ReviewerSubject = reviewer.Subject
+	proposal.ReviewComment = comment
+	return nil
+}
+
+func scanProposals(ctx context.Context, sql string, args ...interface{}) ([]*Proposal, error) {
+	result, err := getConnector(ctx).QueryRows(sql, args...)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to run query on change proposals: %s", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	proposals := make([]*Proposal, 0)
+	for result.Next() {
+		proposal, err := scanRow(result)
+		if err != nil {
+			return nil, err
+		}
+		proposals = append(proposals, proposal)
+	}
+	return proposals, nil
+}
+
+func scanRow(row gotabase.Row) (*Proposal, error) {
+	proposal := Proposal{}
+	var changes []byte
+	if err := row.Scan(&proposal.Id, &proposal.EntityType, &proposal.EntityId, &proposal.Action, &changes, &proposal.BaseRevision, &proposal.Status,
+		&proposal.Proposer.Kind, &proposal.Proposer.Subject, &proposal.ReviewerSubject, &proposal.ReviewComment, &proposal.CreatedAt, &proposal.ReviewedAt); err != nil {
+		return nil, utils.ConvertIfNotFoundErr(err)
+	}
+	proposal.Changes = changes
+	return &proposal, nil
+}
+
+func nullableJson(value json.RawMessage) any {
+	if value == nil {
+		return nil
+	}
+	return string(value)
+}
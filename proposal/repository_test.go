@@ -0,0 +1,205 @@
+package proposal
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/mocks"
+	"github.com/Geepr/game/utils"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/gofrs/uuid"
+	"testing"
+)
+
+// titleApplier is a minimal applier of game proposals, only supporting title updates.
+// With failing set, it returns an error once the title is changed.
+type titleApplier struct {
+	failing bool
+}
+
+func (a titleApplier) Check(_ context.Context, action audit.Action, _ uuid.UUID, changes json.RawMessage) error {
+	if action != audit.ActionUpdate {
+		return utils.InvalidDataErr
+	}
+	model := struct {
+		Title string `json:"title" binding:"required"`
+	}{Title: "current"}
+	return utils.ApplyMergePatch(context.Background(), &model, changes)
+}
+
+func (a titleApplier) Apply(ctx context.Context, connector gotabase.Connector, _ audit.Action, id uuid.UUID, changes json.RawMessage, actor audit.Actor) (uuid.UUID, error) {
+	var model struct {
+		Title string `json:"title"`
+	}
+	if err := utils.ApplyMergePatch(context.Background(), &model, changes); err != nil {
+		return uuid.Nil, err
+	}
+	before, _ := audit.Snapshot(ctx, connector, audit.EntityGame, id)
+	if _, err := connector.Exec("update games set title = $2 where id = $1", id, model.Title); err != nil {
+		return uuid.Nil, err
+	}
+	if a.failing {
+		return uuid.Nil, utils.InvalidDataErr
+	}
+	return id, audit.Record(ctx, connector, audit.EntityGame, id, audit.ActionUpdate, actor, before)
+}
+
+type proposalRepoTest struct {
+	connection gotabase.Connector
+	gameId     uuid.UUID
+	dbName     string
+}
+
+func newProposalRepoTest(t *testing.T) *proposalRepoTest {
+	db, name := mocks.GetDatabase()
+	test := &proposalRepoTest{
+		connection: db,
+		dbName:     name,
+	}
+	getConnector = func(context.Context) gotabase.Connector { return db }
+	RegisterApplier(audit.EntityGame, titleApplier{})
+	t.Cleanup(test.cleanup)
+	return test
+}
+
+func (test *proposalRepoTest) cleanup() {
+	mocks.DropDatabase(test.dbName)
+	delete(appliers, audit.EntityGame)
+}
+
+func (test *proposalRepoTest) insertMockData() {
+	test.gameId, _ = uuid.NewV4()
+	_, err := test.connection.Exec("insert into games (id, title, archived) values ($1, 'aaa', false)", test.gameId)
+	mocks.PanicOnErr(err)
+}
+
+func (test *proposalRepoTest) propose(title string) *Proposal {
+	subject := "proposer"
+	proposal := &Proposal{
+		EntityType: audit.EntityGame,
+		EntityId:   &test.gameId,
+		Action:     audit.ActionUpdate,
+		Changes:    json.RawMessage(`{"title": "` + title + `"}`),
+		Proposer:   audit.Actor{Kind: "user", Subject: &subject},
+	}
+	mocks.PanicOnErr(addProposal(context.Background(), proposal))
+	return proposal
+}
+
+func (test *proposalRepoTest) getTitle() string {
+	row, err := test.connection.QueryRow("select title from games where id = $1", test.gameId)
+	mocks.PanicOnErr(err)
+	var title string
+	mocks.PanicOnErr(row.Scan(&title))
+	return title
+}
+
+func TestProposalRepository_AddProposal_Valid_StoredAsPending(t *testing.T) {
+	test := newProposalRepoTest(t)
+	test.insertMockData()
+
+	proposal := test.propose("bbb")
+
+	loaded, err := getProposalById(context.Background(), proposal.Id)
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, loaded.Status, StatusPending)
+	mocks.AssertEquals(t, *loaded.EntityId, test.gameId)
+	mocks.AssertEquals(t, *loaded.Proposer.Subject, "proposer")
+	mocks.AssertEquals(t, test.getTitle(), "aaa")
+}
+
+func TestProposalRepository_AddProposal_InvalidChanges_ReturnsInvalidData(t *testing.T) {
+	test := newProposalRepoTest(t)
+	test.insertMockData()
+	proposal := &Proposal{EntityType: audit.EntityGame, EntityId: &test.gameId, Action: audit.ActionUpdate, Changes: json.RawMessage(`{"other": 1}`)}
+
+	err := addProposal(context.Background(), proposal)
+
+	mocks.AssertEquals(t, err != nil, true)
+	_, items, _ := getProposals(context.Background(), proposalFilter{}, 0, 10)
+	mocks.AssertEquals(t, items, 0)
+}
+
+func TestProposalRepository_ApproveProposal_NoConflict_Applied(t *testing.T) {
+	test := newProposalRepoTest(t)
+	test.insertMockData()
+	proposal := test.propose("bbb")
+	reviewer := "reviewer"
+
+	approved, err := approveProposal(context.Background(), proposal.Id, audit.Actor{Kind: "user", Subject: &reviewer}, nil)
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, approved.Status, StatusApproved)
+	mocks.AssertEquals(t, *approved.ReviewerSubject, reviewer)
+	mocks.AssertEquals(t, test.getTitle(), "bbb")
+}
+
+func TestProposalRepository_ApproveProposal_EntityChangedSince_MarkedConflicted(t *testing.T) {
+	test := newProposalRepoTest(t)
+	test.insertMockData()
+	proposal := test.propose("bbb")
+	mocks.PanicOnErr(audit.Record(context.Background(), test.connection, audit.EntityGame, test.gameId, audit.ActionUpdate, audit.System, nil))
+
+	_, err := approveProposal(context.Background(), proposal.Id, audit.System, nil)
+
+	mocks.AssertEquals(t, err, utils.ConflictErr)
+	loaded, _ := getProposalById(context.Background(), proposal.Id)
+	mocks.AssertEquals(t, loaded.Status, StatusConflicted)
+	mocks.AssertEquals(t, test.getTitle(), "aaa")
+}
+
+func TestProposalRepository_ApproveProposal_ApplyFails_ChangeRolledBackAndStaysPending(t *testing.T) {
+	test := newProposalRepoTest(t)
+	test.insertMockData()
+	proposal := test.propose("bbb")
+	RegisterApplier(audit.EntityGame, titleApplier{failing: true})
+
+	_, err := approveProposal(context.Background(), proposal.Id, audit.System, nil)
+
+	mocks.AssertEquals(t, err, utils.InvalidDataErr)
+	loaded, _ := getProposalById(context.Background(), proposal.Id)
+	mocks.AssertEquals(t, loaded.Status, StatusPending)
+	mocks.AssertEquals(t, test.getTitle(), "aaa")
+}
+
+func TestProposalRepository_RejectProposal_Pending_NotApplied(t *testing.T) {
+	test := newProposalRepoTest(t)
+	test.insertMockData()
+	proposal := test.propose("bbb")
+	comment := "no"
+
+	rejected, err := rejectProposal(context.Background(), proposal.Id, audit.System, &comment)
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, rejected.Status, StatusRejected)
+	mocks.AssertEquals(t, *rejected.ReviewComment, comment)
+	mocks.AssertEquals(t, test.getTitle(), "aaa")
+}
+
+func TestProposalRepository_ApproveProposal_AlreadyReviewed_ReturnsInvalidData(t *testing.T) {
+	test := newProposalRepoTest(t)
+	test.insertMockData()
+	proposal := test.propose("bbb")
+	_, err := rejectProposal(context.Background(), proposal.Id, audit.System, nil)
+	mocks.PanicOnErr(err)
+
+	_, err = approveProposal(context.Background(), proposal.Id, audit.System, nil)
+
+	mocks.AssertEquals(t, err, utils.InvalidDataErr)
+}
+
+func TestProposalRepository_GetProposals_StatusSet_ReturnsMatching(t *testing.T) {
+	test := newProposalRepoTest(t)
+	test.insertMockData()
+	first := test.propose("bbb")
+	test.propose("ccc")
+	_, err := rejectProposal(context.Background(), first.Id, audit.System, nil)
+	mocks.PanicOnErr(err)
+
+	result, items, err := getProposals(context.Background(), proposalFilter{Status: StatusPending}, 0, 10)
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertCountEqual(t, result, 1)
+	mocks.AssertEquals(t, items, 1)
+	mocks.AssertEquals(t, string(result[0].Changes), `{"title": "ccc"}`)
+}
@@ -0,0 +1,38 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Geepr/game/audit"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/gofrs/uuid"
+)
+
+// List returns a page of releases, only those of a single game if gameId is set, along with the number of all matching releases.
+// It's used by gamectl, which has no http request to bind a full filter from.
+func List(ctx context.Context, gameId uuid.UUID, pageIndex int, pageSize int, order SortOrder) ([]*GameRelease, int, error) {
+	return getGameReleases(ctx, releaseFilter{GameId: gameId}, pageIndex, pageSize, order)
+}
+
+func Get(ctx context.Context, id uuid.UUID) (*GameRelease, error) {
+	return getGameReleaseById(ctx, id)
+}
+
+// Change creates, updates or deletes a release with a merge patch, which can use the platformIds add and remove extension.
+// New releases require the gameId and platformIds fields.
+func Change(ctx context.Context, action audit.Action, id uuid.UUID, changes json.RawMessage, actor audit.Actor) (uuid.UUID, error) {
+	transaction, err := getTransaction(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer transaction.Rollback()
+	if id, err = ChangeWith(ctx, transaction, action, id, changes, actor); err != nil {
+		return uuid.Nil, err
+	}
+	return id, transaction.Commit()
+}
+
+// ChangeWith works like Change, but with the connector, which should be a transaction, so that the release can be changed along with other data.
+func ChangeWith(ctx context.Context, connector gotabase.Connector, action audit.Action, id uuid.UUID, changes json.RawMessage, actor audit.Actor) (uuid.UUID, error) {
+	return (proposalApplier{}).Apply(ctx, connector, action, id, changes, actor)
+}
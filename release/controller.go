@@ -2,33 +2,130 @@ package release
 
 import (
 	"fmt"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/auth"
+	"github.com/Geepr/game/language"
+	"github.com/Geepr/game/proposal"
 	"github.com/Geepr/game/utils"
+	"github.com/Geepr/game/video"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/gofrs/uuid"
-	log "github.com/sirupsen/logrus"
 	"net/http"
 	"time"
 )
 
+// capabilitiesModel is shared between create and update models, player counts of 0 mean that a mode is not supported.
+type capabilitiesModel struct {
+	SinglePlayer         bool              `json:"singlePlayer"`
+	LocalCoopMaxPlayers  int               `json:"localCoopMaxPlayers" binding:"omitempty,min=2,max=1000"`
+	OnlineCoopMaxPlayers int               `json:"onlineCoopMaxPlayers" binding:"omitempty,min=2,max=1000"`
+	OnlinePvpMaxPlayers  int               `json:"onlinePvpMaxPlayers" binding:"omitempty,min=2,max=1000"`
+	CrossPlay            bool              `json:"crossPlay"`
+	ControllerSupport    ControllerSupport `json:"controllerSupport" binding:"omitempty,oneof=unknown none partial full"`
+}
+
+func (m capabilitiesModel) toCapabilities() Capabilities {
+	return Capabilities{
+		SinglePlayer:         m.SinglePlayer,
+		LocalCoopMaxPlayers:  utils.GetNilIfDefault(m.LocalCoopMaxPlayers),
+		OnlineCoopMaxPlayers: utils.GetNilIfDefault(m.OnlineCoopMaxPlayers),
+		OnlinePvpMaxPlayers:  utils.GetNilIfDefault(m.OnlinePvpMaxPlayers),
+		CrossPlay:            m.CrossPlay,
+		ControllerSupport:    m.ControllerSupport.orUnknown(),
+	}
+}
+
+// requirementsModel is shared between create and update models, empty values are treated as not provided.
+type requirementsModel struct {
+	Level       RequirementsLevel `json:"level" binding:"required,oneof=minimum recommended"`
+	Os          string            `json:"os" binding:"max=200"`
+	Cpu         string            `json:"cpu" binding:"max=200"`
+	Gpu         string            `json:"gpu" binding:"max=200"`
+	RamMb       int               `json:"ramMb" binding:"min=0"`
+	StorageMb   int               `json:"storageMb" binding:"min=0"`
+	GraphicsApi string            `json:"graphicsApi" binding:"max=50"`
+	Notes       string            `json:"notes" binding:"max=2000"`
+}
+
+func toSystemRequirements(models []requirementsModel) []*SystemRequirements {
+	requirements := make([]*SystemRequirements, len(models))
+	for i, model := range models {
+		requirements[i] = &SystemRequirements{
+			Level:       model.Level,
+			Os:          utils.GetNilIfDefault(model.Os),
+			Cpu:         utils.GetNilIfDefault(model.Cpu),
+			Gpu:         utils.GetNilIfDefault(model.Gpu),
+			RamMb:       utils.GetNilIfDefault(model.RamMb),
+			StorageMb:   utils.GetNilIfDefault(model.StorageMb),
+			GraphicsApi: utils.GetNilIfDefault(model.GraphicsApi),
+			Notes:       utils.GetNilIfDefault(model.Notes),
+		}
+	}
+	return requirements
+}
+
 func getRoute(c *gin.Context) {
 	var query struct {
-		Title     string    `form:"title"`
-		GameId    string    `form:"gameId"`
-		SortOrder SortOrder `form:"order"`
-		PageIndex int       `form:"index"`
-		PageSize  int       `form:"size"`
+		Title             string            `form:"title"`
+		GameId            string            `form:"gameId" binding:"omitempty,uuid"`
+		PlatformId        string            `form:"platformId" binding:"omitempty,uuid"`
+		SinglePlayer      bool              `form:"singlePlayer"`
+		LocalCoopPlayers  int               `form:"localCoopPlayers" binding:"min=0"`
+		OnlineCoopPlayers int               `form:"onlineCoopPlayers" binding:"min=0"`
+		OnlinePvpPlayers  int               `form:"onlinePvpPlayers" binding:"min=0"`
+		CrossPlay         bool              `form:"crossPlay"`
+		ControllerSupport ControllerSupport `form:"controllerSupport" binding:"omitempty,oneof=unknown none partial full"`
+		Language          string            `form:"language" binding:"max=35"`
+		LanguageKind      language.Kind     `form:"kind" binding:"omitempty,oneof=any interface audio subtitles"`
+		MaxRamMb          int               `form:"maxRam" binding:"min=0"`
+		SortOrder         SortOrder         `form:"order"`
+		PageIndex         int               `form:"index"`
+		PageSize          int               `form:"size"`
 	}
-	var err error
-	var gameId uuid.UUID
-	if err, gameId = c.MustBindWith(&query, binding.Query), uuid.FromStringOrNil(query.GameId); err != nil || gameId == uuid.Nil {
-		log.Infof("Failed to bind game release query: %s", err.Error())
+	if err := c.ShouldBindWith(&query, binding.Query); err != nil {
+		utils.Logger(c).Infof("Failed to bind game release query: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
 		return
 	}
+	languageTag := query.Language
+	if languageTag != "" {
+		var err error
+		if languageTag, err = language.Canonicalise(languageTag); err != nil {
+			utils.Logger(c).Infof("Failed to parse game release language query: %s", err.Error())
+			utils.AbortWithBindingError(err, c)
+			return
+		}
+	}
 
-	releases, totalItems, err := getGameReleases(query.Title, gameId, query.PageIndex, query.PageSize, query.SortOrder)
+	filter := releaseFilter{
+		Title:             query.Title,
+		GameId:            uuid.FromStringOrNil(query.GameId),
+		PlatformId:        uuid.FromStringOrNil(query.PlatformId),
+		SinglePlayer:      query.SinglePlayer,
+		LocalCoopPlayers:  query.LocalCoopPlayers,
+		OnlineCoopPlayers: query.OnlineCoopPlayers,
+		OnlinePvpPlayers:  query.OnlinePvpPlayers,
+		CrossPlay:         query.CrossPlay,
+		ControllerSupport: query.ControllerSupport,
+		Language:          languageTag,
+		LanguageKind:      query.LanguageKind,
+		MaxRamMb:          query.MaxRamMb,
+	}
+	if utils.WantsCsv(c) {
+		utils.ServeCsv(c, "releases.csv", csvHeader, query.PageIndex, query.PageSize, func(pageIndex int, pageSize int) ([][]string, int, error) {
+			releases, totalItems, err := getGameReleases(c, filter, pageIndex, pageSize, query.SortOrder)
+			if err != nil {
+				return nil, 0, err
+			}
+			rows, err := toCsvRows(c, releases)
+			return rows, totalItems, err
+		})
+		return
+	}
+	releases, totalItems, err := getGameReleases(c, filter, query.PageIndex, query.PageSize, query.SortOrder)
 	if err != nil {
-		c.AbortWithStatus(http.StatusInternalServerError)
+		utils.AbortWithProblem(http.StatusInternalServerError, "", c)
 		return
 	}
 
@@ -49,30 +146,51 @@ func getRoute(c *gin.Context) {
 func getByIdRoute(c *gin.Context) {
 	id, err := utils.ParseUuidFromParam(c)
 	if err != nil {
-		c.AbortWithStatus(http.StatusBadRequest)
+		utils.AbortWithBindingError(err, c)
 		return
 	}
 
-	release, err := getGameReleaseById(id)
+	release, err := getGameReleaseById(c, id)
 	if err != nil {
 		utils.AbortWithRelevantError(err, c)
 		return
 	}
+	if utils.AbortIfNotModified(c, release.Version) {
+		return
+	}
 
+	utils.SetETag(c, release.Version)
 	c.JSON(http.StatusOK, release)
 }
 
 func createRoute(c *gin.Context) {
 	var createModel struct {
-		GameId             uuid.UUID   `json:"gameId" binding:"required"`
-		TitleOverride      string      `json:"title" binding:"max=200"`
-		Description        string      `json:"description" binding:"max=2000"`
-		ReleaseDateUnknown bool        `json:"releaseDateUnknown"`
-		ReleaseDate        time.Time   `json:"releaseDate"` //in format 2006-01-02T15:04:05Z07:00
-		PlatformIds        []uuid.UUID `json:"platformIds" binding:"required"`
+		GameId             uuid.UUID              `json:"gameId" binding:"required"`
+		TitleOverride      string                 `json:"title" binding:"max=200"`
+		Description        string                 `json:"description" binding:"max=2000"`
+		ReleaseDateUnknown bool                   `json:"releaseDateUnknown"`
+		ReleaseDate        time.Time              `json:"releaseDate"` //in format 2006-01-02T15:04:05Z07:00
+		PlatformIds        []uuid.UUID            `json:"platformIds" binding:"required"`
+		Videos             []video.CreateModel    `json:"videos" binding:"dive"`
+		Languages          []language.CreateModel `json:"languages" binding:"dive"`
+		Capabilities       capabilitiesModel      `json:"capabilities"`
+		SystemRequirements []requirementsModel    `json:"systemRequirements" binding:"max=2,dive"`
 	}
-	if err := c.MustBindWith(&createModel, binding.JSON); err != nil {
-		log.Infof("Failed to parse release creation model: %s", err.Error())
+	if err := c.ShouldBindWith(&createModel, binding.JSON); err != nil {
+		utils.Logger(c).Infof("Failed to parse release creation model: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	videos, err := video.FromCreateModels(createModel.Videos)
+	if err != nil {
+		utils.Logger(c).Infof("Failed to parse release videos: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	languages, err := language.FromCreateModels(createModel.Languages)
+	if err != nil {
+		utils.Logger(c).Infof("Failed to parse release languages: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
 		return
 	}
 
@@ -83,30 +201,57 @@ func createRoute(c *gin.Context) {
 		ReleaseDate:        utils.GetNilIfDefault(createModel.ReleaseDate),
 		ReleaseDateUnknown: createModel.ReleaseDateUnknown,
 		PlatformIds:        createModel.PlatformIds,
+		Videos:             videos,
+		Languages:          languages,
+		Capabilities:       createModel.Capabilities.toCapabilities(),
+		SystemRequirements: toSystemRequirements(createModel.SystemRequirements),
 	}
-	if err := addGameRelease(&release); err != nil {
+	if err := addGameRelease(c, &release, audit.ActorFromContext(c)); err != nil {
 		utils.AbortWithRelevantError(err, c)
 		return
 	}
+	utils.SetETag(c, release.Version)
 
 	c.JSON(http.StatusCreated, &release)
 }
 
 func updateRoute(c *gin.Context) {
 	var updateModel struct {
-		TitleOverride      string      `json:"title" binding:"max=200"`
-		Description        string      `json:"description" binding:"max=2000"`
-		ReleaseDateUnknown bool        `json:"releaseDateUnknown"`
-		ReleaseDate        time.Time   `json:"releaseDate"` //in format 2006-01-02T15:04:05Z07:00
-		PlatformIds        []uuid.UUID `json:"platformIds" binding:"required"`
+		TitleOverride      string                 `json:"title" binding:"max=200"`
+		Description        string                 `json:"description" binding:"max=2000"`
+		ReleaseDateUnknown bool                   `json:"releaseDateUnknown"`
+		ReleaseDate        time.Time              `json:"releaseDate"` //in format 2006-01-02T15:04:05Z07:00
+		PlatformIds        []uuid.UUID            `json:"platformIds" binding:"required"`
+		Videos             []video.CreateModel    `json:"videos" binding:"dive"`
+		Languages          []language.CreateModel `json:"languages" binding:"dive"`
+		Capabilities       capabilitiesModel      `json:"capabilities"`
+		SystemRequirements []requirementsModel    `json:"systemRequirements" binding:"max=2,dive"`
 	}
-	if err := c.MustBindWith(&updateModel, binding.JSON); err != nil {
-		log.Infof("Failed to parse release update model: %s", err.Error())
+	if err := c.ShouldBindWith(&updateModel, binding.JSON); err != nil {
+		utils.Logger(c).Infof("Failed to parse release update model: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
 		return
 	}
 	id, err := utils.ParseUuidFromParam(c)
 	if err != nil {
-		c.AbortWithStatus(http.StatusBadRequest)
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	version, err := utils.ParseIfMatch(c, currentVersion(c, id))
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+	videos, err := video.FromCreateModels(updateModel.Videos)
+	if err != nil {
+		utils.Logger(c).Infof("Failed to parse release videos: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	languages, err := language.FromCreateModels(updateModel.Languages)
+	if err != nil {
+		utils.Logger(c).Infof("Failed to parse release languages: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
 		return
 	}
 
@@ -117,24 +262,106 @@ func updateRoute(c *gin.Context) {
 		ReleaseDate:        utils.GetNilIfDefault(updateModel.ReleaseDate),
 		ReleaseDateUnknown: updateModel.ReleaseDateUnknown,
 		PlatformIds:        updateModel.PlatformIds,
+		Videos:             videos,
+		Languages:          languages,
+		Capabilities:       updateModel.Capabilities.toCapabilities(),
+		SystemRequirements: toSystemRequirements(updateModel.SystemRequirements),
+		Version:            version,
 	}
-	if err := updateGameRelease(id, &release); err != nil {
+	if err := updateGameRelease(c, id, &release, audit.ActorFromContext(c)); err != nil {
 		utils.AbortWithRelevantError(err, c)
 		return
 	}
+	utils.SetETag(c, release.Version)
 
 	release.Id = id
 	c.JSON(http.StatusOK, &release)
 }
 
+// importLanguagesRoute replaces language support of a release with the contents of a csv table.
+// See language.ParseCsv for the accepted format.
+func importLanguagesRoute(c *gin.Context) {
+	id, err := utils.ParseUuidFromParam(c)
+	if err != nil {
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	languages, err := language.ParseCsv(c.Request.Body)
+	if err != nil {
+		utils.Logger(c).Infof("Failed to parse release languages csv: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	version, err := utils.ParseIfMatch(c, currentVersion(c, id))
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+
+	if version, err = replaceGameReleaseLanguages(c, id, version, languages, audit.ActorFromContext(c)); err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+	utils.SetETag(c, version)
+
+	c.JSON(http.StatusOK, languages)
+}
+
+// patchRoute applies an RFC 7396 merge patch to the release, platformIds can also be changed with an {"add": [], "remove": []} object.
+// The patch is applied to the current state, so the update fails if the release is changed in the meantime, even with If-Match: *.
+func patchRoute(c *gin.Context) {
+	id, err := utils.ParseUuidFromParam(c)
+	if err != nil {
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	version, err := utils.ParseIfMatch(c, currentVersion(c, id))
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+	patch, err := c.GetRawData()
+	if err != nil {
+		utils.Logger(c).Infof("Failed to read release patch: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+
+	release, err := getGameReleaseById(c, id)
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+	if version != 0 && version != release.Version {
+		utils.AbortWithRelevantError(utils.VersionMismatchErr, c)
+		return
+	}
+	if err := applyPatch(c, release, patch); err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+	if err := updateGameRelease(c, id, release, audit.ActorFromContext(c)); err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+
+	utils.SetETag(c, release.Version)
+	c.JSON(http.StatusOK, release)
+}
+
 func deleteRoute(c *gin.Context) {
 	id, err := utils.ParseUuidFromParam(c)
 	if err != nil {
-		c.AbortWithStatus(http.StatusBadRequest)
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	version, err := utils.ParseIfMatch(c, currentVersion(c, id))
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
 		return
 	}
 
-	if err := deleteGameRelease(id); err != nil {
+	if err := deleteGameRelease(c, id, version, audit.ActorFromContext(c)); err != nil {
 		utils.AbortWithRelevantError(err, c)
 		return
 	}
@@ -142,12 +369,54 @@ func deleteRoute(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+func revertRoute(c *gin.Context) {
+	id, err := utils.ParseUuidFromParam(c)
+	if err != nil {
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	revision, err := audit.ParseRevisionFromParam(c)
+	if err != nil {
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+
+	if err := revertGameRelease(c, id, revision, audit.ActorFromContext(c)); err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+
+	release, err := getGameReleaseById(c, id)
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+	utils.SetETag(c, release.Version)
+	c.JSON(http.StatusOK, release)
+}
+
+// currentVersion reads the version of the release, for If-Match headers listing several tags.
+func currentVersion(c *gin.Context, id uuid.UUID) func() (int, error) {
+	return func() (int, error) {
+		release, err := getGameReleaseById(c, id)
+		if err != nil {
+			return 0, err
+		}
+		return release.Version, nil
+	}
+}
+
 func SetupRoutes(engine *gin.Engine, basePath string) {
 	baseUrl := fmt.Sprintf("%s/api/v0/releases", basePath)
+	proposal.RegisterApplier(audit.EntityRelease, proposalApplier{})
 
 	engine.GET(baseUrl, getRoute)
 	engine.GET(baseUrl+"/:id", getByIdRoute)
-	engine.POST(baseUrl, createRoute)
-	engine.PUT(baseUrl+"/:id", updateRoute)
-	engine.DELETE(baseUrl+"/:id", deleteRoute)
+	engine.POST(baseUrl, auth.Require(auth.ResourceRelease, auth.ActionCreate), createRoute)
+	engine.PUT(baseUrl+"/:id", auth.Require(auth.ResourceRelease, auth.ActionUpdate), updateRoute)
+	engine.PATCH(baseUrl+"/:id", auth.Require(auth.ResourceRelease, auth.ActionUpdate), patchRoute)
+	engine.PUT(baseUrl+"/:id/languages", auth.Require(auth.ResourceRelease, auth.ActionUpdate), importLanguagesRoute)
+	engine.DELETE(baseUrl+"/:id", auth.Require(auth.ResourceRelease, auth.ActionDelete), deleteRoute)
+	engine.GET(baseUrl+"/:id/history", auth.Require(auth.ResourceAudit, auth.ActionRead), audit.HistoryRoute(audit.EntityRelease))
+	engine.POST(baseUrl+"/:id/revisions/:rev/revert", auth.Require(auth.ResourceRelease, auth.ActionUpdate), revertRoute)
 }
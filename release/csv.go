@@ -0,0 +1,28 @@
+package release
+
+import (
+	"context"
+	"github.com/Geepr/game/utils"
+	"strconv"
+)
+
+// csvHeader names columns the same way as csv imports do, so that exported releases can be imported back.
+var csvHeader = []string{"id", "gameId", "gameExternalId", "gameTitle", "title", "description", "releaseDate", "releaseDateUnknown", "platforms", "version"}
+
+func toCsvRows(ctx context.Context, releases []*GameRelease) ([][]string, error) {
+	details, err := getCsvDetails(ctx, releases)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([][]string, len(releases))
+	for i, release := range releases {
+		releaseDate := ""
+		if release.ReleaseDate != nil {
+			releaseDate = release.ReleaseDate.Format("2006-01-02")
+		}
+		detail := details[release.Id]
+		rows[i] = []string{release.Id.String(), release.GameId.String(), utils.CsvValue(detail.gameExternalId), utils.CsvText(detail.gameTitle), utils.CsvValue(release.TitleOverride),
+			utils.CsvValue(release.Description), releaseDate, strconv.FormatBool(release.ReleaseDateUnknown), utils.CsvText(detail.platforms), strconv.Itoa(release.Version)}
+	}
+	return rows, nil
+}
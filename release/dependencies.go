@@ -1,8 +1,14 @@
 package release
 
-import "github.com/KowalskiPiotr98/gotabase"
+import (
+	"context"
+	"github.com/Geepr/game/tracing"
+	"github.com/KowalskiPiotr98/gotabase"
+)
 
 var (
-	getConnector   = func() gotabase.Connector { return gotabase.GetConnection() }
-	getTransaction = func() (*gotabase.Transaction, error) { return gotabase.BeginTransaction() }
+	getConnector = func(ctx context.Context) gotabase.Connector {
+		return tracing.WrapConnector(ctx, gotabase.GetConnection())
+	}
+	getTransaction = func(ctx context.Context) (*tracing.Transaction, error) { return tracing.BeginTransaction(ctx) }
 )
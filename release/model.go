@@ -1,6 +1,8 @@
 package release
 
 import (
+	"github.com/Geepr/game/language"
+	"github.com/Geepr/game/video"
 	"github.com/gofrs/uuid"
 	"time"
 )
@@ -21,4 +23,63 @@ type GameRelease struct {
 	ReleaseDateUnknown bool `json:"releaseDateUnknown"`
 	// PlatformIds contains ids of platforms assigned to this release.
 	PlatformIds []uuid.UUID `json:"platformIds"`
+	// Videos contains trailers and other videos specific to this release.
+	Videos []*video.Video `json:"videos"`
+	// Languages lists interface, audio, and subtitle localisations available in this release.
+	Languages []*language.Support `json:"languages"`
+	// Capabilities describes game modes and input options available in this release.
+	Capabilities Capabilities `json:"capabilities"`
+	// CrossPlayWith and CrossSaveWith contain ids of other releases sharing a release group of the given kind with this one.
+	// Those are read only, release groups are managed separately.
+	CrossPlayWith []uuid.UUID `json:"crossplayWith"`
+	CrossSaveWith []uuid.UUID `json:"crossSaveWith"`
+	// SystemRequirements are only available for releases on PC family platforms.
+	// They are only loaded when a single release is requested.
+	SystemRequirements []*SystemRequirements `json:"systemRequirements,omitempty"`
+	// Version is incremented with every change of the release, it's also returned in the ETag header.
+	// When updating, a non-zero version is required to match the stored one.
+	Version int `json:"version"`
+}
+
+type RequirementsLevel string
+
+const (
+	RequirementsMinimum     RequirementsLevel = "minimum"
+	RequirementsRecommended RequirementsLevel = "recommended"
+)
+
+// SystemRequirements describes hardware needed to run a PC release at a given level.
+// All structured fields are optional, as publishers rarely provide complete data; Notes can be used for anything that doesn't fit them.
+type SystemRequirements struct {
+	Level RequirementsLevel `json:"level"`
+	Os    *string           `json:"os"`
+	Cpu   *string           `json:"cpu"`
+	Gpu   *string           `json:"gpu"`
+	// RamMb and StorageMb are expressed in megabytes.
+	RamMb     *int `json:"ramMb"`
+	StorageMb *int `json:"storageMb"`
+	// GraphicsApi is the required graphics API version, like "DirectX 12" or "Vulkan 1.3".
+	GraphicsApi *string `json:"graphicsApi"`
+	Notes       *string `json:"notes"`
+}
+
+type ControllerSupport string
+
+const (
+	ControllerSupportUnknown ControllerSupport = "unknown"
+	ControllerSupportNone    ControllerSupport = "none"
+	ControllerSupportPartial ControllerSupport = "partial"
+	ControllerSupportFull    ControllerSupport = "full"
+)
+
+// Capabilities describes game modes supported by a release.
+// Player counts are nil when the mode is not supported at all.
+type Capabilities struct {
+	SinglePlayer         bool `json:"singlePlayer"`
+	LocalCoopMaxPlayers  *int `json:"localCoopMaxPlayers"`
+	OnlineCoopMaxPlayers *int `json:"onlineCoopMaxPlayers"`
+	OnlinePvpMaxPlayers  *int `json:"onlinePvpMaxPlayers"`
+	// CrossPlay indicates that online modes of this release can be played together with releases on other platforms.
+	CrossPlay         bool              `json:"crossPlay"`
+	ControllerSupport ControllerSupport `json:"controllerSupport"`
 }
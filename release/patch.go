@@ -0,0 +1,119 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Geepr/game/utils"
+	"github.com/gofrs/uuid"
+	"slices"
+	"time"
+)
+
+// patchModel lists the fields of a release that can be changed by merge patches, both in PATCH requests and change proposals.
+// Videos, languages and system requirements are left out, they have their own routes or can be replaced as a whole with PUT.
+type patchModel struct {
+	// GameId can only be set when proposing a new release.
+	GameId             uuid.UUID         `json:"gameId" binding:"required"`
+	TitleOverride      *string           `json:"title" binding:"omitempty,max=200"`
+	Description        *string           `json:"description" binding:"omitempty,max=2000"`
+	ReleaseDateUnknown bool              `json:"releaseDateUnknown"`
+	ReleaseDate        *time.Time        `json:"releaseDate"`
+	PlatformIds        []uuid.UUID       `json:"platformIds" binding:"required"`
+	Capabilities       capabilitiesModel `json:"capabilities"`
+}
+
+// platformIdsChange is an extension of merge patches, used in place of the platformIds array
+// to add or remove single platforms instead of replacing all of them.
+type platformIdsChange struct {
+	Add    []uuid.UUID `json:"add"`
+	Remove []uuid.UUID `json:"remove"`
+}
+
+func fromCapabilities(capabilities Capabilities) capabilitiesModel {
+	valueOrZero := func(value *int) int {
+		if value == nil {
+			return 0
+		}
+		return *value
+	}
+	return capabilitiesModel{
+		SinglePlayer:         capabilities.SinglePlayer,
+		LocalCoopMaxPlayers:  valueOrZero(capabilities.LocalCoopMaxPlayers),
+		OnlineCoopMaxPlayers: valueOrZero(capabilities.OnlineCoopMaxPlayers),
+		OnlinePvpMaxPlayers:  valueOrZero(capabilities.OnlinePvpMaxPlayers),
+		CrossPlay:            capabilities.CrossPlay,
+		ControllerSupport:    capabilities.ControllerSupport,
+	}
+}
+
+// applyPatch changes the release according to a merge patch, leaving it untouched if the result is not valid.
+// The game of an existing release can't be changed.
+func applyPatch(ctx context.Context, release *GameRelease, patch json.RawMessage) error {
+	patch, err := expandPlatformIdsChange(ctx, release.PlatformIds, patch)
+	if err != nil {
+		return err
+	}
+	model := patchModel{
+		GameId:             release.GameId,
+		TitleOverride:      release.TitleOverride,
+		Description:        release.Description,
+		ReleaseDateUnknown: release.ReleaseDateUnknown,
+		ReleaseDate:        release.ReleaseDate,
+		PlatformIds:        release.PlatformIds,
+		Capabilities:       fromCapabilities(release.Capabilities),
+	}
+	if err := utils.ApplyMergePatch(ctx, &model, patch); err != nil {
+		return err
+	}
+	if release.GameId != uuid.Nil && model.GameId != release.GameId {
+		return fmt.Errorf("%w: game of a release can't be changed", utils.InvalidDataErr)
+	}
+	release.GameId = model.GameId
+	release.TitleOverride = model.TitleOverride
+	release.Description = model.Description
+	release.ReleaseDateUnknown = model.ReleaseDateUnknown
+	release.ReleaseDate = model.ReleaseDate
+	release.PlatformIds = model.PlatformIds
+	release.Capabilities = model.Capabilities.toCapabilities()
+	return nil
+}
+
+// expandPlatformIdsChange replaces a platformIdsChange in the patch with the array of platform ids it results in.
+// Patches without one are returned as they are.
+func expandPlatformIdsChange(ctx context.Context, current []uuid.UUID, patch json.RawMessage) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &fields); err != nil {
+		// invalid patches are reported by utils.ApplyMergePatch
+		return patch, nil
+	}
+	value, ok := fields["platformIds"]
+	if !ok || !bytes.HasPrefix(bytes.TrimSpace(value), []byte("{")) {
+		return patch, nil
+	}
+	var change platformIdsChange
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&change); err != nil {
+		utils.Logger(ctx).Infof("Failed to decode platform ids change: %s", err.Error())
+		return nil, fmt.Errorf("%w: %s", utils.InvalidDataErr, err.Error())
+	}
+
+	platformIds := make([]uuid.UUID, 0, len(current)+len(change.Add))
+	for _, id := range current {
+		if !slices.Contains(change.Remove, id) {
+			platformIds = append(platformIds, id)
+		}
+	}
+	for _, id := range change.Add {
+		if !slices.Contains(platformIds, id) {
+			platformIds = append(platformIds, id)
+		}
+	}
+	var err error
+	if fields["platformIds"], err = json.Marshal(platformIds); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
@@ -0,0 +1,84 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"github.com/Geepr/game/mocks"
+	"github.com/Geepr/game/utils"
+	"github.com/gofrs/uuid"
+	"testing"
+)
+
+func newPatchTestRelease() *GameRelease {
+	gameId, _ := uuid.NewV4()
+	platform1, _ := uuid.NewV4()
+	platform2, _ := uuid.NewV4()
+	title, players := "title", 4
+	return &GameRelease{
+		GameId:        gameId,
+		TitleOverride: &title,
+		PlatformIds:   []uuid.UUID{platform1, platform2},
+		Capabilities:  Capabilities{SinglePlayer: true, OnlineCoopMaxPlayers: &players, ControllerSupport: ControllerSupportFull},
+	}
+}
+
+func TestApplyPatch_ScalarFields_OnlyPatchedChanged(t *testing.T) {
+	release := newPatchTestRelease()
+
+	err := applyPatch(context.Background(), release, []byte(`{"title": null, "description": "new", "capabilities": {"crossPlay": true}}`))
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, release.TitleOverride == nil, true)
+	mocks.AssertEquals(t, *release.Description, "new")
+	mocks.AssertEquals(t, release.Capabilities.CrossPlay, true)
+	mocks.AssertEquals(t, release.Capabilities.SinglePlayer, true)
+	mocks.AssertEquals(t, *release.Capabilities.OnlineCoopMaxPlayers, 4)
+	mocks.AssertCountEqual(t, release.PlatformIds, 2)
+}
+
+func TestApplyPatch_PlatformIdsChange_AddedAndRemoved(t *testing.T) {
+	release := newPatchTestRelease()
+	kept, removed := release.PlatformIds[0], release.PlatformIds[1]
+	added, _ := uuid.NewV4()
+
+	err := applyPatch(context.Background(), release, []byte(`{"platformIds": {"add": ["`+added.String()+`", "`+kept.String()+`"], "remove": ["`+removed.String()+`"]}}`))
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertCountEqual(t, release.PlatformIds, 2)
+	mocks.AssertEquals(t, release.PlatformIds[0], kept)
+	mocks.AssertEquals(t, release.PlatformIds[1], added)
+}
+
+func TestApplyPatch_PlatformIdsArray_Replaced(t *testing.T) {
+	release := newPatchTestRelease()
+	platform, _ := uuid.NewV4()
+
+	err := applyPatch(context.Background(), release, []byte(`{"platformIds": ["`+platform.String()+`"]}`))
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertCountEqual(t, release.PlatformIds, 1)
+	mocks.AssertEquals(t, release.PlatformIds[0], platform)
+}
+
+func TestApplyPatch_InvalidPatch_ReturnsInvalidData(t *testing.T) {
+	otherGame, _ := uuid.NewV4()
+	testData := map[string]string{
+		"game changed":             `{"gameId": "` + otherGame.String() + `"}`,
+		"platforms removed":        `{"platformIds": null}`,
+		"unknown platform change":  `{"platformIds": {"replace": []}}`,
+		"invalid capabilities":     `{"capabilities": {"localCoopMaxPlayers": 1}}`,
+		"invalid controller value": `{"capabilities": {"controllerSupport": "some"}}`,
+	}
+
+	for name, patch := range testData {
+		currentPatch := patch
+		t.Run(name, func(t *testing.T) {
+			release := newPatchTestRelease()
+
+			err := applyPatch(context.Background(), release, []byte(currentPatch))
+
+			mocks.AssertEquals(t, errors.Is(err, utils.InvalidDataErr), true)
+			mocks.AssertCountEqual(t, release.PlatformIds, 2)
+		})
+	}
+}
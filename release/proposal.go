@@ -0,0 +1,50 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Geepr/game/audit"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/gofrs/uuid"
+)
+
+type proposalApplier struct{}
+
+func (proposalApplier) Check(ctx context.Context, action audit.Action, id uuid.UUID, changes json.RawMessage) error {
+	_, err := getProposedRelease(ctx, getConnector(ctx), action, id, changes)
+	return err
+}
+
+func (proposalApplier) Apply(ctx context.Context, connector gotabase.Connector, action audit.Action, id uuid.UUID, changes json.RawMessage, actor audit.Actor) (uuid.UUID, error) {
+	release, err := getProposedRelease(ctx, connector, action, id, changes)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	switch action {
+	case audit.ActionInsert:
+		err = addGameReleaseWith(ctx, connector, release, actor)
+	case audit.ActionUpdate:
+		err = updateGameReleaseWith(ctx, connector, id, release, actor)
+	default:
+		err = deleteGameReleaseWith(ctx, connector, id, release.Version, actor)
+	}
+	return release.Id, err
+}
+
+// getProposedRelease returns the release as it would be after applying the changes.
+func getProposedRelease(ctx context.Context, connector gotabase.Connector, action audit.Action, id uuid.UUID, changes json.RawMessage) (*GameRelease, error) {
+	release := &GameRelease{}
+	if action != audit.ActionInsert {
+		var err error
+		if release, err = getGameReleaseByIdWith(ctx, connector, id); err != nil {
+			return nil, err
+		}
+	}
+	if action == audit.ActionDelete {
+		return release, nil
+	}
+	if err := applyPatch(ctx, release, changes); err != nil {
+		return nil, err
+	}
+	return release, nil
+}
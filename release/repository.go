@@ -1,126 +1,314 @@
 package release
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/language"
+	"github.com/Geepr/game/metrics"
 	"github.com/Geepr/game/utils"
+	"github.com/Geepr/game/video"
 	"github.com/KowalskiPiotr98/gotabase"
 	"github.com/gofrs/uuid"
 	"github.com/lib/pq"
-	log "github.com/sirupsen/logrus"
 	"strings"
+	"time"
 )
 
 type SortOrder uint8
 
+const detailColumns = "single_player, local_coop_max_players, online_coop_max_players, online_pvp_max_players, cross_play, controller_support, " +
+	"release_group_peers(id, 'crossplay'), release_group_peers(id, 'crosssave'), version"
+
 const (
 	SortById SortOrder = iota
 	SortByTitle
 	SortByDate
 )
 
-func getGameReleases(titleQuery string, gameIdQuery uuid.UUID, pageIndex int, pageSize int, order SortOrder) ([]*GameRelease, int, error) {
-	query := "select id, game_id, title_override, description, release_date, release_date_unknown, array(select grp.platform_id from game_release_platforms grp where grp.game_release_id = id) from game_releases"
+// releaseFilter groups all optional conditions that can be used when listing releases.
+// Default values of each field mean that the condition is not applied.
+type releaseFilter struct {
+	Title      string
+	GameId     uuid.UUID
+	PlatformId uuid.UUID
+	// SinglePlayer, when set, only returns releases with a single player mode.
+	SinglePlayer bool
+	// LocalCoopPlayers, OnlineCoopPlayers, and OnlinePvpPlayers filter for releases supporting at least this many players in a given mode.
+	LocalCoopPlayers  int
+	OnlineCoopPlayers int
+	OnlinePvpPlayers  int
+	CrossPlay         bool
+	// ControllerSupport is the minimal required level of controller support.
+	ControllerSupport ControllerSupport
+	// Language is a canonical BCP-47 tag that must be supported in the way described by LanguageKind.
+	// Tags without a region also match all regional variants.
+	Language     string
+	LanguageKind language.Kind
+	// MaxRamMb only returns releases with minimum RAM requirement no larger than this, releases without known requirements are skipped.
+	MaxRamMb int
+}
+
+func getGameReleases(ctx context.Context, filter releaseFilter, pageIndex int, pageSize int, order SortOrder) (_ []*GameRelease, _ int, err error) {
+	defer metrics.ObserveQuery("getGameReleases", time.Now(), &err)
+	query := "select id, game_id, title_override, description, release_date, release_date_unknown, array(select grp.platform_id from game_release_platforms grp where grp.game_release_id = id), " + detailColumns + " from game_releases"
 	//todo: this should probably fallback to the original game title query if override is null? - a view of some manner would be helpful here
-	query, args := utils.AppendWhereClause(query, "title_override_normalised", "like", utils.MakeLikeQuery(strings.ToUpper(titleQuery)), utils.IsStringNotEmpty, []any{})
-	query, args = utils.AppendWhereClause(query, "game_id", "=", gameIdQuery, utils.IsUuidNotEmpty, args)
+	query, args := utils.AppendWhereClause(query, "title_override_normalised", "like", utils.MakeLikeQuery(strings.ToUpper(filter.Title)), utils.IsStringNotEmpty, []any{})
+	query, args = utils.AppendWhereClause(query, "game_id", "=", filter.GameId, utils.IsUuidNotEmpty, args)
+	query, args = utils.AppendWhereClause[any](query, "game_release_platform_ids(id)", "@>", pq.Array([]uuid.UUID{filter.PlatformId}), func(any) bool { return utils.IsUuidNotEmpty(filter.PlatformId) }, args)
+	query, args = utils.AppendWhereClause(query, "single_player", "=", filter.SinglePlayer, utils.IsTrue, args)
+	query, args = utils.AppendWhereClause(query, "local_coop_max_players", ">=", filter.LocalCoopPlayers, utils.IsPositive, args)
+	query, args = utils.AppendWhereClause(query, "online_coop_max_players", ">=", filter.OnlineCoopPlayers, utils.IsPositive, args)
+	query, args = utils.AppendWhereClause(query, "online_pvp_max_players", ">=", filter.OnlinePvpPlayers, utils.IsPositive, args)
+	query, args = utils.AppendWhereClause(query, "cross_play", "=", filter.CrossPlay, utils.IsTrue, args)
+	query, args = utils.AppendWhereClause[any](query, "array[controller_support]", "<@", pq.Array(filter.ControllerSupport.atLeast()), func(any) bool { return filter.ControllerSupport != "" }, args)
+	query, args = utils.AppendWhereClause[any](query, fmt.Sprintf("game_release_languages(id, '%s')", filter.LanguageKind.SqlColumn()), "@>", pq.Array([]string{filter.Language}), func(any) bool { return filter.Language != "" }, args)
+	query, args = utils.AppendWhereClause(query, "game_release_minimum_ram(id)", "<=", filter.MaxRamMb, utils.IsPositive, args)
 	query += fmt.Sprintf(" order by %s", order.getSqlColumnName())
 	query, countQuery, err := utils.Paginate(query, pageIndex, pageSize)
 	if err != nil {
 		return nil, 0, err
 	}
-	countResults, err := utils.ScanCountQuery(getConnector(), countQuery, args...)
+	countResults, err := utils.ScanCountQuery(ctx, getConnector(ctx), countQuery, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	scanResult, err := scanGameReleases(ctx, query, args...)
 	if err != nil {
 		return nil, 0, err
 	}
-	scanResult, err := scanGameReleases(query, args...)
-	return scanResult, countResults, err
+	return scanResult, countResults, attachRelated(ctx, getConnector(ctx), scanResult...)
 }
 
-func getGameReleaseById(id uuid.UUID) (*GameRelease, error) {
-	query := "select id, game_id, title_override, description, release_date, release_date_unknown, array(select grp.platform_id from game_release_platforms grp where grp.game_release_id = $1) from game_releases where id = $1"
-	return scanGameRelease(query, id)
+func getGameReleaseById(ctx context.Context, id uuid.UUID) (*GameRelease, error) {
+	return getGameReleaseByIdWith(ctx, getConnector(ctx), id)
 }
 
-func addGameRelease(gameRelease *GameRelease) error {
-	query := "insert into game_releases (game_id, title_override, description, release_date, release_date_unknown) VALUES  ($1, $2, $3, $4, $5) returning id"
-	transaction, err := getTransaction()
+// getGameReleaseByIdWith reads the release with the connector, which sees changes made earlier in its transaction.
+func getGameReleaseByIdWith(ctx context.Context, connector gotabase.Connector, id uuid.UUID) (_ *GameRelease, err error) {
+	defer metrics.ObserveQuery("getGameReleaseById", time.Now(), &err)
+	query := "select id, game_id, title_override, description, release_date, release_date_unknown, array(select grp.platform_id from game_release_platforms grp where grp.game_release_id = $1), " + detailColumns + " from game_releases where id = $1"
+	release, err := scanGameRelease(ctx, connector, query, id)
+	if err != nil {
+		return nil, err
+	}
+	if release.SystemRequirements, err = getSystemRequirements(ctx, connector, id); err != nil {
+		return nil, err
+	}
+	return release, attachRelated(ctx, connector, release)
+}
+
+func addGameRelease(ctx context.Context, gameRelease *GameRelease, actor audit.Actor) error {
+	transaction, err := getTransaction(ctx)
 	if err != nil {
 		return err
 	}
 	defer transaction.Rollback()
-	result, err := transaction.QueryRow(query, gameRelease.GameId, gameRelease.TitleOverride, gameRelease.Description, gameRelease.ReleaseDate, gameRelease.ReleaseDateUnknown)
+	if err = addGameReleaseWith(ctx, transaction, gameRelease, actor); err != nil {
+		return err
+	}
+	return transaction.Commit()
+}
+
+// addGameReleaseWith stores the release with the connector, which should be a transaction, so that the release isn't stored partially.
+func addGameReleaseWith(ctx context.Context, connector gotabase.Connector, gameRelease *GameRelease, actor audit.Actor) (err error) {
+	defer metrics.ObserveQuery("addGameRelease", time.Now(), &err)
+	query := "insert into game_releases (game_id, title_override, description, release_date, release_date_unknown, single_player, local_coop_max_players, online_coop_max_players, online_pvp_max_players, cross_play, controller_support) VALUES  ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) returning id, version"
+	capabilities := gameRelease.Capabilities
+	result, err := connector.QueryRow(query, gameRelease.GameId, gameRelease.TitleOverride, gameRelease.Description, gameRelease.ReleaseDate, gameRelease.ReleaseDateUnknown,
+		capabilities.SinglePlayer, capabilities.LocalCoopMaxPlayers, capabilities.OnlineCoopMaxPlayers, capabilities.OnlinePvpMaxPlayers, capabilities.CrossPlay, capabilities.ControllerSupport.orUnknown())
 	if err != nil {
 		return utils.ConvertIfNotFoundErr(err)
 	}
-	if err = result.Scan(&gameRelease.Id); err != nil {
+	if err = result.Scan(&gameRelease.Id, &gameRelease.Version); err != nil {
 		return utils.ConvertIfNotFoundErr(err)
 	}
-	if err = createGameReleasePlatforms(gameRelease, transaction); err != nil {
+	if err = createGameReleasePlatforms(ctx, gameRelease, connector); err != nil {
 		return utils.ConvertIfNotFoundErr(err)
 	}
-	return transaction.Commit()
+	if err = video.ReplaceForRelease(ctx, connector, gameRelease.Id, gameRelease.Videos); err != nil {
+		return err
+	}
+	if err = language.ReplaceForRelease(ctx, connector, gameRelease.Id, gameRelease.Languages); err != nil {
+		return err
+	}
+	if err = replaceSystemRequirements(ctx, gameRelease, connector); err != nil {
+		return err
+	}
+	if err = touchGameIfLanguagesChanged(ctx, connector, gameRelease.Id, nil); err != nil {
+		return err
+	}
+	return audit.Record(ctx, connector, audit.EntityRelease, gameRelease.Id, audit.ActionInsert, actor, nil)
 }
 
-func updateGameRelease(id uuid.UUID, updatedGameRelease *GameRelease) error {
-	query := "update game_releases set title_override = $2, description = $3, release_date = $4, release_date_unknown = $5 where id = $1"
-	transaction, err := getTransaction()
+func updateGameRelease(ctx context.Context, id uuid.UUID, updatedGameRelease *GameRelease, actor audit.Actor) error {
+	transaction, err := getTransaction(ctx)
 	if err != nil {
 		return err
 	}
 	defer transaction.Rollback()
-	result, err := transaction.Exec(query, id, updatedGameRelease.TitleOverride, updatedGameRelease.Description, updatedGameRelease.ReleaseDate, updatedGameRelease.ReleaseDateUnknown)
+	if err = updateGameReleaseWith(ctx, transaction, id, updatedGameRelease, actor); err != nil {
+		return err
+	}
+	return transaction.Commit()
+}
 
+// updateGameReleaseWith changes the release with the connector, which should be a transaction, like addGameReleaseWith.
+func updateGameReleaseWith(ctx context.Context, connector gotabase.Connector, id uuid.UUID, updatedGameRelease *GameRelease, actor audit.Actor) (err error) {
+	defer metrics.ObserveQuery("updateGameRelease", time.Now(), &err)
+	query := "update game_releases set title_override = $2, description = $3, release_date = $4, release_date_unknown = $5, " +
+		"single_player = $6, local_coop_max_players = $7, online_coop_max_players = $8, online_pvp_max_players = $9, cross_play = $10, controller_support = $11, " +
+		"version = version + 1 where id = $1 and ($12 = 0 or version = $12) returning version"
+	before, err := audit.Snapshot(ctx, connector, audit.EntityRelease, id)
 	if err != nil {
-		log.Warnf("Failed to execute update query on game releases: %s", err.Error())
 		return err
 	}
-	affected, err := result.RowsAffected()
+	capabilities := updatedGameRelease.Capabilities
+	result, err := connector.QueryRow(query, id, updatedGameRelease.TitleOverride, updatedGameRelease.Description, updatedGameRelease.ReleaseDate, updatedGameRelease.ReleaseDateUnknown,
+		capabilities.SinglePlayer, capabilities.LocalCoopMaxPlayers, capabilities.OnlineCoopMaxPlayers, capabilities.OnlinePvpMaxPlayers, capabilities.CrossPlay, capabilities.ControllerSupport.orUnknown(),
+		updatedGameRelease.Version)
 	if err != nil {
-		log.Warnf("Failed to read affected rows when running update query on game releases: %s", err.Error())
+		utils.Logger(ctx).Warnf("Failed to execute update query on game releases: %s", err.Error())
 		return err
 	}
-	if affected != 1 {
-		return utils.DataNotFoundErr
+	if err = result.Scan(&updatedGameRelease.Version); err != nil {
+		return utils.ConvertIfVersionMismatchErr(err, before != nil)
 	}
-	if err = removeAllGameReleasePlatformsForRelease(id, transaction); err != nil {
+	if err = removeAllGameReleasePlatformsForRelease(ctx, id, connector); err != nil {
 		return err
 	}
-	if err = createGameReleasePlatforms(updatedGameRelease, transaction); err != nil {
+	if err = createGameReleasePlatforms(ctx, updatedGameRelease, connector); err != nil {
 		return err
 	}
-	return transaction.Commit()
+	if err = video.ReplaceForRelease(ctx, connector, id, updatedGameRelease.Videos); err != nil {
+		return err
+	}
+	if err = language.ReplaceForRelease(ctx, connector, id, updatedGameRelease.Languages); err != nil {
+		return err
+	}
+	updatedGameRelease.Id = id
+	if err = replaceSystemRequirements(ctx, updatedGameRelease, connector); err != nil {
+		return err
+	}
+	if err = touchGameIfLanguagesChanged(ctx, connector, id, before); err != nil {
+		return err
+	}
+	return audit.Record(ctx, connector, audit.EntityRelease, id, audit.ActionUpdate, actor, before)
 }
 
-func deleteGameRelease(id uuid.UUID) error {
-	query := "delete from game_releases where id = $1"
-	transaction, err := getTransaction()
+// replaceGameReleaseLanguages only replaces language support of a release, leaving the rest of it untouched, and returns its new version.
+// A non-zero version is required to match the stored one.
+func replaceGameReleaseLanguages(ctx context.Context, id uuid.UUID, version int, languages []*language.Support, actor audit.Actor) (_ int, err error) {
+	defer metrics.ObserveQuery("replaceGameReleaseLanguages", time.Now(), &err)
+	transaction, err := getTransaction(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer transaction.Rollback()
+	before, err := audit.Snapshot(ctx, transaction, audit.EntityRelease, id)
+	if err != nil {
+		return 0, err
+	}
+	if before == nil {
+		return 0, utils.DataNotFoundErr
+	}
+	// languages are part of the release representation, so changing them has to change its version as well
+	result, err := transaction.QueryRow("update game_releases set version = version + 1 where id = $1 and ($2 = 0 or version = $2) returning version", id, version)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute update query on game releases: %s", err.Error())
+		return 0, err
+	}
+	if err = result.Scan(&version); err != nil {
+		return 0, utils.ConvertIfVersionMismatchErr(err, true)
+	}
+	if err = language.ReplaceForRelease(ctx, transaction, id, languages); err != nil {
+		return 0, err
+	}
+	if err = touchGameIfLanguagesChanged(ctx, transaction, id, before); err != nil {
+		return 0, err
+	}
+	if err = audit.Record(ctx, transaction, audit.EntityRelease, id, audit.ActionUpdate, actor, before); err != nil {
+		return 0, err
+	}
+	return version, transaction.Commit()
+}
+
+// deleteGameRelease removes the release, a non-zero version is required to match the stored one.
+func deleteGameRelease(ctx context.Context, id uuid.UUID, version int, actor audit.Actor) error {
+	transaction, err := getTransaction(ctx)
 	if err != nil {
 		return err
 	}
 	defer transaction.Rollback()
-	if err = removeAllGameReleasePlatformsForRelease(id, transaction); err != nil {
+	if err = deleteGameReleaseWith(ctx, transaction, id, version, actor); err != nil {
 		return err
 	}
-	result, err := transaction.Exec(query, id)
+	return transaction.Commit()
+}
+
+// deleteGameReleaseWith removes the release with the connector, which should be a transaction, like addGameReleaseWith.
+func deleteGameReleaseWith(ctx context.Context, connector gotabase.Connector, id uuid.UUID, version int, actor audit.Actor) (err error) {
+	defer metrics.ObserveQuery("deleteGameRelease", time.Now(), &err)
+	query := "delete from game_releases where id = $1 and ($2 = 0 or version = $2)"
+	before, err := audit.Snapshot(ctx, connector, audit.EntityRelease, id)
 	if err != nil {
-		log.Warnf("Failed to execute delete query on game releases: %s", err.Error())
+		return err
+	}
+	if err = removeAllGameReleasePlatformsForRelease(ctx, id, connector); err != nil {
+		return err
+	}
+	// group members are removed along with the release, which changes the peers of the rest of its groups
+	peersQuery := "update game_releases set version = version + 1 where id <> $1 and id in " +
+		"(select m.game_release_id from release_group_members m where m.release_group_id in (select release_group_id from release_group_members where game_release_id = $1))"
+	if _, err = connector.Exec(peersQuery, id); err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute update query on release group peers: %s", err.Error())
+		return err
+	}
+	result, err := connector.Exec(query, id, version)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute delete query on game releases: %s", err.Error())
 		return err
 	}
 	affected, err := result.RowsAffected()
 	if err != nil {
-		log.Warnf("Failed to read affected rows when running delete query on game releases: %s", err.Error())
+		utils.Logger(ctx).Warnf("Failed to read affected rows when running delete query on game releases: %s", err.Error())
 		return err
 	}
 	if affected != 1 {
+		if before != nil {
+			return utils.VersionMismatchErr
+		}
 		return utils.DataNotFoundErr
 	}
-	return transaction.Commit()
+	if err = touchGameIfLanguagesChanged(ctx, connector, id, before); err != nil {
+		return err
+	}
+	return audit.Record(ctx, connector, audit.EntityRelease, id, audit.ActionDelete, actor, before)
+}
+
+// touchGameIfLanguagesChanged increments the version of the game of a release if the languages of the release changed,
+// as the game representation aggregates languages of all of its releases.
+// Languages before the change are read from the audit snapshot of the release, nil for new releases.
+func touchGameIfLanguagesChanged(ctx context.Context, connector gotabase.Connector, releaseId uuid.UUID, before json.RawMessage) error {
+	var state any
+	if before != nil {
+		state = string(before)
+	}
+	query := "update games set version = version + 1 where id = coalesce(($2::jsonb ->> 'game_id')::uuid, (select game_id from game_releases where id = $1)) " +
+		"and coalesce($2::jsonb -> 'languages', '[]') is distinct from " +
+		"(select coalesce(jsonb_agg(to_jsonb(l) - 'game_release_id' order by l.language), '[]') from game_release_languages l where l.game_release_id = $1)"
+	if _, err := connector.Exec(query, releaseId, state); err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute update query on games: %s", err.Error())
+		return err
+	}
+	return nil
 }
 
-func scanGameReleases(sql string, args ...interface{}) ([]*GameRelease, error) {
-	result, err := getConnector().QueryRows(sql, args...)
+func scanGameReleases(ctx context.Context, sql string, args ...interface{}) ([]*GameRelease, error) {
+	result, err := getConnector(ctx).QueryRows(sql, args...)
 	if err != nil {
-		log.Warnf("Failed to run query on game releases: %s", err.Error())
+		utils.Logger(ctx).Warnf("Failed to run query on game releases: %s", err.Error())
 		return nil, err
 	}
 	defer result.Close()
@@ -137,10 +325,10 @@ func scanGameReleases(sql string, args ...interface{}) ([]*GameRelease, error) {
 	return releases, nil
 }
 
-func scanGameRelease(sql string, args ...interface{}) (*GameRelease, error) {
-	result, err := getConnector().QueryRow(sql, args...)
+func scanGameRelease(ctx context.Context, connector gotabase.Connector, sql string, args ...interface{}) (*GameRelease, error) {
+	result, err := connector.QueryRow(sql, args...)
 	if err != nil {
-		log.Warnf("Failed to run row query on game releases: %s", err.Error())
+		utils.Logger(ctx).Warnf("Failed to run row query on game releases: %s", err.Error())
 		return nil, err
 	}
 	return scanRow(result)
@@ -148,12 +336,42 @@ func scanGameRelease(sql string, args ...interface{}) (*GameRelease, error) {
 
 func scanRow(row gotabase.Row) (*GameRelease, error) {
 	release := GameRelease{}
-	if err := row.Scan(&release.Id, &release.GameId, &release.TitleOverride, &release.Description, &release.ReleaseDate, &release.ReleaseDateUnknown, pq.Array(&release.PlatformIds)); err != nil {
+	capabilities := &release.Capabilities
+	if err := row.Scan(&release.Id, &release.GameId, &release.TitleOverride, &release.Description, &release.ReleaseDate, &release.ReleaseDateUnknown, pq.Array(&release.PlatformIds),
+		&capabilities.SinglePlayer, &capabilities.LocalCoopMaxPlayers, &capabilities.OnlineCoopMaxPlayers, &capabilities.OnlinePvpMaxPlayers, &capabilities.CrossPlay, &capabilities.ControllerSupport,
+		pq.Array(&release.CrossPlayWith), pq.Array(&release.CrossSaveWith), &release.Version); err != nil {
 		return nil, utils.ConvertIfNotFoundErr(err)
 	}
 	return &release, nil
 }
 
+// attachRelated loads videos and language support of releases, which are stored in separate tables.
+func attachRelated(ctx context.Context, connector gotabase.Connector, releases ...*GameRelease) error {
+	ids := make([]uuid.UUID, len(releases))
+	for i, release := range releases {
+		ids[i] = release.Id
+	}
+	videos, err := video.GetForReleases(ctx, connector, ids)
+	if err != nil {
+		return err
+	}
+	languages, err := language.GetForReleases(ctx, connector, ids)
+	if err != nil {
+		return err
+	}
+	for _, release := range releases {
+		release.Videos = videos[release.Id]
+		if release.Videos == nil {
+			release.Videos = make([]*video.Video, 0)
+		}
+		release.Languages = languages[release.Id]
+		if release.Languages == nil {
+			release.Languages = make([]*language.Support, 0)
+		}
+	}
+	return nil
+}
+
 func (o SortOrder) getSqlColumnName() string {
 	switch o {
 	case SortById:
@@ -166,7 +384,25 @@ func (o SortOrder) getSqlColumnName() string {
 	return "id"
 }
 
-func createGameReleasePlatforms(gameRelease *GameRelease, connector gotabase.Connector) error {
+func (s ControllerSupport) orUnknown() ControllerSupport {
+	if s == "" {
+		return ControllerSupportUnknown
+	}
+	return s
+}
+
+// atLeast returns all controller support levels that satisfy s as a minimal requirement.
+func (s ControllerSupport) atLeast() []ControllerSupport {
+	switch s {
+	case ControllerSupportFull:
+		return []ControllerSupport{ControllerSupportFull}
+	case ControllerSupportPartial:
+		return []ControllerSupport{ControllerSupportPartial, ControllerSupportFull}
+	}
+	return []ControllerSupport{s}
+}
+
+func createGameReleasePlatforms(ctx context.Context, gameRelease *GameRelease, connector gotabase.Connector) error {
 	for _, platformId := range gameRelease.PlatformIds {
 		_, err := connector.Exec("insert into game_release_platforms (platform_id, game_release_id) values ($1, $2)", platformId, gameRelease.Id)
 		if err != nil {
@@ -176,7 +412,150 @@ func createGameReleasePlatforms(gameRelease *GameRelease, connector gotabase.Con
 	return nil
 }
 
-func removeAllGameReleasePlatformsForRelease(releaseId uuid.UUID, connector gotabase.Connector) error {
+func removeAllGameReleasePlatformsForRelease(ctx context.Context, releaseId uuid.UUID, connector gotabase.Connector) error {
 	_, err := connector.Exec("delete from game_release_platforms where game_release_id = $1", releaseId)
 	return err
 }
+
+func getSystemRequirements(ctx context.Context, connector gotabase.Connector, releaseId uuid.UUID) ([]*SystemRequirements, error) {
+	query := "select level, os, cpu, gpu, ram_mb, storage_mb, graphics_api, notes from game_release_system_requirements where game_release_id = $1 order by level"
+	result, err := connector.QueryRows(query, releaseId)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to run query on game release system requirements: %s", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	requirements := make([]*SystemRequirements, 0)
+	for result.Next() {
+		level := SystemRequirements{}
+		if err := result.Scan(&level.Level, &level.Os, &level.Cpu, &level.Gpu, &level.RamMb, &level.StorageMb, &level.GraphicsApi, &level.Notes); err != nil {
+			return nil, utils.ConvertIfNotFoundErr(err)
+		}
+		requirements = append(requirements, &level)
+	}
+	return requirements, nil
+}
+
+// replaceSystemRequirements stores system requirements of a release, making sure that it's available on at least one PC family platform.
+func replaceSystemRequirements(ctx context.Context, gameRelease *GameRelease, connector gotabase.Connector) error {
+	if _, err := connector.Exec("delete from game_release_system_requirements where game_release_id = $1", gameRelease.Id); err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute delete query on game release system requirements: %s", err.Error())
+		return err
+	}
+	if len(gameRelease.SystemRequirements) == 0 {
+		return nil
+	}
+	pcPlatforms, err := utils.ScanCountQuery(ctx, connector, "select count(*) from platforms where id = any($1) and family = 'pc'", pq.Array(gameRelease.PlatformIds))
+	if err != nil {
+		return err
+	}
+	if pcPlatforms == 0 {
+		return utils.InvalidDataErr
+	}
+	query := "insert into game_release_system_requirements (game_release_id, level, os, cpu, gpu, ram_mb, storage_mb, graphics_api, notes) values ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+	for _, level := range gameRelease.SystemRequirements {
+		if _, err := connector.Exec(query, gameRelease.Id, level.Level, level.Os, level.Cpu, level.Gpu, level.RamMb, level.StorageMb, level.GraphicsApi, level.Notes); err != nil {
+			utils.Logger(ctx).Warnf("Failed to execute insert query on game release system requirements: %s", err.Error())
+			return utils.ConvertIfDuplicateErr(err)
+		}
+	}
+	return nil
+}
+
+const revertedColumns = "title_override, description, release_date, release_date_unknown, single_player, local_coop_max_players, online_coop_max_players, online_pvp_max_players, cross_play, controller_support"
+
+// revertGameRelease restores the fields, platforms, languages, system requirements, and videos of a release to the state recorded by an audit revision.
+// Releases deleted since are created again, with the same id, as long as their game still exists.
+// Platforms removed since the revision can't be linked again, so they are skipped.
+func revertGameRelease(ctx context.Context, id uuid.UUID, revision int64, actor audit.Actor) (err error) {
+	defer metrics.ObserveQuery("revertGameRelease", time.Now(), &err)
+	transaction, err := getTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	defer transaction.Rollback()
+	state, err := audit.GetRevision(ctx, transaction, audit.EntityRelease, id, revision)
+	if err != nil {
+		return err
+	}
+	before, err := audit.Snapshot(ctx, transaction, audit.EntityRelease, id)
+	if err != nil {
+		return err
+	}
+	// populating the record over the current row keeps the values of columns missing in older revisions
+	query := fmt.Sprintf("update game_releases gr set version = gr.version + 1, (%[1]s) = (select %[1]s from jsonb_populate_record(gr, $2)) where gr.id = $1", revertedColumns)
+	action := audit.ActionUpdate
+	if before == nil {
+		query = fmt.Sprintf("insert into game_releases (id, game_id, %[1]s) select $1, game_id, %[1]s from jsonb_populate_record(null::game_releases, $2)", revertedColumns)
+		action = audit.ActionInsert
+	}
+	if _, err = transaction.Exec(query, id, string(state)); err != nil {
+		utils.Logger(ctx).Warnf("Failed to revert game release: %s", err.Error())
+		return utils.ConvertIfNotFoundErr(err)
+	}
+	if err = removeAllGameReleasePlatformsForRelease(ctx, id, transaction); err != nil {
+		return err
+	}
+	platformsQuery := "insert into game_release_platforms (platform_id, game_release_id) " +
+		"select p.id, $1 from platforms p where p.id::text in (select jsonb_array_elements_text(coalesce($2::jsonb -> 'platform_ids', '[]')))"
+	if _, err = transaction.Exec(platformsQuery, id, string(state)); err != nil {
+		utils.Logger(ctx).Warnf("Failed to restore platforms of game release: %s", err.Error())
+		return err
+	}
+	if err = audit.RestoreOwned(ctx, transaction, state, "languages", "game_release_languages", "game_release_id", id, "language", "interface", "audio", "subtitles"); err != nil {
+		return err
+	}
+	if err = audit.RestoreOwned(ctx, transaction, state, "system_requirements", "game_release_system_requirements", "game_release_id", id,
+		"level", "os", "cpu", "gpu", "ram_mb", "storage_mb", "graphics_api", "notes"); err != nil {
+		return err
+	}
+	if err = audit.RestoreOwned(ctx, transaction, state, "videos", "videos", "game_release_id", id, "provider", "video_id", "kind", "language", "published_at"); err != nil {
+		return err
+	}
+	if err = touchGameIfLanguagesChanged(ctx, transaction, id, before); err != nil {
+		return err
+	}
+	if err = audit.Record(ctx, transaction, audit.EntityRelease, id, action, actor, before); err != nil {
+		return err
+	}
+	return transaction.Commit()
+}
+
+// csvDetail holds values of csv exports that are stored outside the releases table.
+type csvDetail struct {
+	gameTitle      string
+	gameExternalId *string
+	// platforms are short names of the release platforms, joined with commas.
+	platforms string
+}
+
+func getCsvDetails(ctx context.Context, releases []*GameRelease) (_ map[uuid.UUID]csvDetail, err error) {
+	defer metrics.ObserveQuery("getCsvDetails", time.Now(), &err)
+	details := make(map[uuid.UUID]csvDetail, len(releases))
+	if len(releases) == 0 {
+		return details, nil
+	}
+	ids := make([]uuid.UUID, len(releases))
+	for i, release := range releases {
+		ids[i] = release.Id
+	}
+	query := "select r.id, g.title, g.external_id, coalesce(string_agg(p.short_name, ', ' order by p.short_name), '') from game_releases r " +
+		"join games g on g.id = r.game_id left join game_release_platforms rp on rp.game_release_id = r.id left join platforms p on p.id = rp.platform_id " +
+		"where r.id = any($1) group by r.id, g.title, g.external_id"
+	result, err := getConnector(ctx).QueryRows(query, pq.Array(ids))
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to run csv details query on game releases: %s", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+	for result.Next() {
+		var id uuid.UUID
+		var detail csvDetail
+		if err = result.Scan(&id, &detail.gameTitle, &detail.gameExternalId, &detail.platforms); err != nil {
+			return nil, err
+		}
+		details[id] = detail
+	}
+	return details, nil
+}
@@ -1,8 +1,12 @@
 package release
 
 import (
+	"context"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/language"
 	"github.com/Geepr/game/mocks"
 	"github.com/Geepr/game/utils"
+	"github.com/Geepr/game/video"
 	"github.com/KowalskiPiotr98/gotabase"
 	"github.com/gofrs/uuid"
 	"slices"
@@ -97,7 +101,7 @@ func TestGameReleaseRepository_GetReleases_NoParametersSet_ReturnsAllReleases(t
 	test := newGameReleaseRepoTest(t)
 	test.insertMockData()
 
-	result, resultCount, err := getGameReleases("", utils.DefaultUuid, 0, 100, SortById)
+	result, resultCount, err := getGameReleases(context.Background(), releaseFilter{}, 0, 100, SortById)
 
 	mocks.AssertDefault(t, err)
 	mocks.AssertCountEqual(t, result, 4)
@@ -118,7 +122,7 @@ func TestGameReleaseRepository_GetReleases_TitleAndGameIdQueryDefined_ReturnsMat
 	test := newGameReleaseRepoTest(t)
 	test.insertMockData()
 
-	result, resultCount, err := getGameReleases("other", test.mockData[1].GameId, 0, 100, SortById)
+	result, resultCount, err := getGameReleases(context.Background(), releaseFilter{Title: "other", GameId: test.mockData[1].GameId}, 0, 100, SortById)
 
 	mocks.AssertDefault(t, err)
 	mocks.AssertCountEqual(t, result, 1)
@@ -131,13 +135,48 @@ func TestGameReleaseRepository_GetReleases_QueryDefinedAndNotFound_ReturnsEmpty(
 	test := newGameReleaseRepoTest(t)
 	test.insertMockData()
 
-	result, resultCount, err := getGameReleases("definitely not found", utils.DefaultUuid, 0, 100, SortById)
+	result, resultCount, err := getGameReleases(context.Background(), releaseFilter{Title: "definitely not found"}, 0, 100, SortById)
 
 	mocks.AssertDefault(t, err)
 	mocks.AssertCountEqual(t, result, 0)
 	mocks.AssertEquals(t, resultCount, 0)
 }
 
+func TestGameReleaseRepository_GetReleases_CapabilitiesAndPlatformQueryDefined_ReturnsMatching(t *testing.T) {
+	test := newGameReleaseRepoTest(t)
+	test.insertMockData()
+	_, err := test.connection.Exec("update game_releases set local_coop_max_players = 4, controller_support = 'full' where id = $1 or id = $2", test.mockData[0].Id, test.mockData[1].Id)
+	mocks.PanicOnErr(err)
+	_, err = test.connection.Exec("update game_releases set local_coop_max_players = 2 where id = $1", test.mockData[2].Id)
+	mocks.PanicOnErr(err)
+
+	result, resultCount, err := getGameReleases(context.Background(), releaseFilter{PlatformId: test.mockPlatformId, LocalCoopPlayers: 4, ControllerSupport: ControllerSupportPartial}, 0, 100, SortById)
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, resultCount, 1)
+	mocks.AssertCountEqual(t, result, 1)
+	mocks.AssertEquals(t, result[0].Id, test.mockData[0].Id)
+	mocks.AssertEquals(t, *result[0].Capabilities.LocalCoopMaxPlayers, 4)
+	mocks.AssertEquals(t, result[0].Capabilities.ControllerSupport, ControllerSupportFull)
+}
+
+func TestGameReleaseRepository_GetReleases_LanguageQueryDefined_ReturnsMatching(t *testing.T) {
+	test := newGameReleaseRepoTest(t)
+	test.insertMockData()
+	_, err := replaceGameReleaseLanguages(context.Background(), test.mockData[0].Id, 0, []*language.Support{{Language: "ja-JP", Audio: true}}, audit.System)
+	mocks.PanicOnErr(err)
+	_, err = replaceGameReleaseLanguages(context.Background(), test.mockData[1].Id, 0, []*language.Support{{Language: "ja", Subtitles: true}}, audit.System)
+	mocks.PanicOnErr(err)
+
+	result, resultCount, err := getGameReleases(context.Background(), releaseFilter{Language: "ja", LanguageKind: language.KindAudio}, 0, 100, SortById)
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, resultCount, 1)
+	mocks.AssertCountEqual(t, result, 1)
+	mocks.AssertEquals(t, result[0].Id, test.mockData[0].Id)
+	mocks.AssertCountEqual(t, result[0].Languages, 1)
+}
+
 func TestGameReleaseRepository_GetGameReleaseById_ReleaseIdValid_ReleaseReturned(t *testing.T) {
 	test := newGameReleaseRepoTest(t)
 	test.insertMockData()
@@ -145,7 +184,7 @@ func TestGameReleaseRepository_GetGameReleaseById_ReleaseIdValid_ReleaseReturned
 	for _, testCaseGlobal := range test.mockData {
 		testCase := testCaseGlobal
 		t.Run(testCase.Id.String(), func(t *testing.T) {
-			result, err := getGameReleaseById(testCase.Id)
+			result, err := getGameReleaseById(context.Background(), testCase.Id)
 
 			mocks.AssertDefault(t, err)
 			mocks.AssertEquals(t, result.Id, testCase.Id)
@@ -155,12 +194,29 @@ func TestGameReleaseRepository_GetGameReleaseById_ReleaseIdValid_ReleaseReturned
 	}
 }
 
+func TestGameReleaseRepository_GetGameReleaseById_InReleaseGroup_PeersReturned(t *testing.T) {
+	test := newGameReleaseRepoTest(t)
+	test.insertMockData()
+	groupId, _ := uuid.NewV4()
+	_, err := test.connection.Exec("insert into release_groups (id, game_id, kind) values ($1, $2, 'crossplay')", groupId, test.mockData[0].GameId)
+	mocks.PanicOnErr(err)
+	_, err = test.connection.Exec("insert into release_group_members (release_group_id, game_release_id, game_id, kind) values ($1, $2, $4, 'crossplay'), ($1, $3, $4, 'crossplay')", groupId, test.mockData[0].Id, test.mockData[1].Id, test.mockData[0].GameId)
+	mocks.PanicOnErr(err)
+
+	result, err := getGameReleaseById(context.Background(), test.mockData[0].Id)
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertCountEqual(t, result.CrossPlayWith, 1)
+	mocks.AssertEquals(t, result.CrossPlayWith[0], test.mockData[1].Id)
+	mocks.AssertCountEqual(t, result.CrossSaveWith, 0)
+}
+
 func TestGameReleaseRepository_GetGameReleaseById_IdNotFound_ReturnsSpecificError(t *testing.T) {
 	test := newGameReleaseRepoTest(t)
 	test.insertMockData()
 	testId, _ := uuid.NewV4()
 
-	_, err := getGameReleaseById(testId)
+	_, err := getGameReleaseById(context.Background(), testId)
 
 	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
 }
@@ -176,11 +232,11 @@ func TestGameReleaseRepository_AddRelease_New_ReleaseAdded(t *testing.T) {
 		PlatformIds:        []uuid.UUID{test.mockPlatformId},
 	}
 
-	err := addGameRelease(&newRelease)
+	err := addGameRelease(context.Background(), &newRelease, audit.System)
 
 	mocks.AssertDefault(t, err)
 	mocks.AssertNotDefault(t, newRelease.Id)
-	databaseResult, err := getGameReleaseById(newRelease.Id)
+	databaseResult, err := getGameReleaseById(context.Background(), newRelease.Id)
 	mocks.AssertEquals(t, err, nil)
 	mocks.AssertEquals(t, len(databaseResult.PlatformIds), 1)
 	mocks.AssertEquals(t, databaseResult.PlatformIds[0], test.mockPlatformId)
@@ -195,7 +251,7 @@ func TestGameReleaseRepository_AddRelease_MissingGameId_NotFoundReturned(t *test
 		ReleaseDateUnknown: false,
 	}
 
-	err := addGameRelease(&newRelease)
+	err := addGameRelease(context.Background(), &newRelease, audit.System)
 
 	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
 }
@@ -212,7 +268,7 @@ func TestGameReleaseRepository_AddRelease_MissingPlatformId_NotFoundReturned(t *
 		PlatformIds:        []uuid.UUID{test.mockPlatformId, testId},
 	}
 
-	err := addGameRelease(&newRelease)
+	err := addGameRelease(context.Background(), &newRelease, audit.System)
 
 	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
 }
@@ -228,13 +284,15 @@ func TestGameReleaseRepository_UpdateRelease_Exists_Updates(t *testing.T) {
 	modified.ReleaseDate = nil
 	platform2Id, _ := uuid.NewV4()
 	modified.PlatformIds = []uuid.UUID{platform2Id}
+	players := 8
+	modified.Capabilities = Capabilities{SinglePlayer: true, OnlinePvpMaxPlayers: &players, CrossPlay: true, ControllerSupport: ControllerSupportPartial}
 	_, err := test.connection.Exec("insert into platforms (id, name, short_name) values ($1, 'test 2', 'tt2')", platform2Id)
 	mocks.PanicOnErr(err)
 
-	err = updateGameRelease(modified.Id, modified)
+	err = updateGameRelease(context.Background(), modified.Id, modified, audit.System)
 
 	mocks.AssertDefault(t, err)
-	loaded, _ := getGameReleaseById(modified.Id)
+	loaded, _ := getGameReleaseById(context.Background(), modified.Id)
 	mocks.AssertEquals(t, loaded.Id, modified.Id)
 	mocks.AssertEquals(t, loaded.TitleOverride, modified.TitleOverride)
 	mocks.AssertEquals(t, *loaded.Description, *modified.Description)
@@ -242,6 +300,77 @@ func TestGameReleaseRepository_UpdateRelease_Exists_Updates(t *testing.T) {
 	mocks.AssertEquals(t, loaded.ReleaseDateUnknown, modified.ReleaseDateUnknown)
 	mocks.AssertEquals(t, len(loaded.PlatformIds), 1)
 	mocks.AssertEquals(t, loaded.PlatformIds[0], platform2Id)
+	mocks.AssertEquals(t, loaded.Capabilities.SinglePlayer, true)
+	mocks.AssertEqualsNillable(t, loaded.Capabilities.OnlinePvpMaxPlayers, &players)
+	mocks.AssertEqualsNillable(t, loaded.Capabilities.LocalCoopMaxPlayers, nil)
+	mocks.AssertEquals(t, loaded.Capabilities.CrossPlay, true)
+	mocks.AssertEquals(t, loaded.Capabilities.ControllerSupport, ControllerSupportPartial)
+}
+
+func TestGameReleaseRepository_UpdateRelease_VideosChanged_VideosReplaced(t *testing.T) {
+	test := newGameReleaseRepoTest(t)
+	test.insertMockData()
+	modified := test.mockData[0]
+	modified.Videos, _ = video.FromCreateModels([]video.CreateModel{{Url: "https://youtu.be/dQw4w9WgXcQ", Kind: video.KindTrailer}})
+	mocks.PanicOnErr(updateGameRelease(context.Background(), modified.Id, modified, audit.System))
+	modified.Videos, _ = video.FromCreateModels([]video.CreateModel{{Url: "https://www.twitch.tv/videos/1234567890", Kind: video.KindReview}})
+
+	err := updateGameRelease(context.Background(), modified.Id, modified, audit.System)
+
+	mocks.AssertDefault(t, err)
+	loaded, _ := getGameReleaseById(context.Background(), modified.Id)
+	mocks.AssertCountEqual(t, loaded.Videos, 1)
+	mocks.AssertEquals(t, loaded.Videos[0].Provider, video.ProviderTwitch)
+	mocks.AssertEquals(t, loaded.Videos[0].Kind, video.KindReview)
+}
+
+func TestGameReleaseRepository_ReplaceGameReleaseLanguages_Missing_ReturnsNotFound(t *testing.T) {
+	test := newGameReleaseRepoTest(t)
+	test.insertMockData()
+	fakeId, _ := uuid.NewV4()
+
+	_, err := replaceGameReleaseLanguages(context.Background(), fakeId, 0, []*language.Support{{Language: "en", Interface: true}}, audit.System)
+
+	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
+}
+
+func TestGameReleaseRepository_UpdateRelease_RequirementsOnPcPlatform_StoredAndFilterable(t *testing.T) {
+	test := newGameReleaseRepoTest(t)
+	test.insertMockData()
+	_, err := test.connection.Exec("update platforms set family = 'pc' where id = $1", test.mockPlatformId)
+	mocks.PanicOnErr(err)
+	modified := test.mockData[0]
+	minimumRam, recommendedRam := 8192, 16384
+	gpu := "GTX 1060"
+	modified.SystemRequirements = []*SystemRequirements{
+		{Level: RequirementsMinimum, RamMb: &minimumRam},
+		{Level: RequirementsRecommended, RamMb: &recommendedRam, Gpu: &gpu},
+	}
+
+	err = updateGameRelease(context.Background(), modified.Id, modified, audit.System)
+
+	mocks.AssertDefault(t, err)
+	loaded, _ := getGameReleaseById(context.Background(), modified.Id)
+	mocks.AssertCountEqual(t, loaded.SystemRequirements, 2)
+	mocks.AssertEquals(t, loaded.SystemRequirements[0].Level, RequirementsMinimum)
+	mocks.AssertEqualsNillable(t, loaded.SystemRequirements[1].Gpu, &gpu)
+	matching, count, _ := getGameReleases(context.Background(), releaseFilter{MaxRamMb: 8192}, 0, 100, SortById)
+	mocks.AssertEquals(t, count, 1)
+	mocks.AssertEquals(t, matching[0].Id, modified.Id)
+	_, count, _ = getGameReleases(context.Background(), releaseFilter{MaxRamMb: 4096}, 0, 100, SortById)
+	mocks.AssertEquals(t, count, 0)
+}
+
+func TestGameReleaseRepository_UpdateRelease_RequirementsWithoutPcPlatform_ReturnsInvalidData(t *testing.T) {
+	test := newGameReleaseRepoTest(t)
+	test.insertMockData()
+	modified := test.mockData[0]
+	ram := 8192
+	modified.SystemRequirements = []*SystemRequirements{{Level: RequirementsMinimum, RamMb: &ram}}
+
+	err := updateGameRelease(context.Background(), modified.Id, modified, audit.System)
+
+	mocks.AssertEquals(t, err, utils.InvalidDataErr)
 }
 
 func TestGameReleaseRepository_UpdateRelease_Missing_ReturnsNotFound(t *testing.T) {
@@ -250,7 +379,7 @@ func TestGameReleaseRepository_UpdateRelease_Missing_ReturnsNotFound(t *testing.
 	fakeId, _ := uuid.NewV4()
 	modified := test.mockData[0]
 
-	err := updateGameRelease(fakeId, modified)
+	err := updateGameRelease(context.Background(), fakeId, modified, audit.System)
 
 	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
 }
@@ -260,10 +389,10 @@ func TestGameReleaseRepository_DeleteRelease_Exists_Removes(t *testing.T) {
 	test.insertMockData()
 	toDelete := test.mockData[0]
 
-	err := deleteGameRelease(toDelete.Id)
+	err := deleteGameRelease(context.Background(), toDelete.Id, 0, audit.System)
 
 	mocks.AssertDefault(t, err)
-	_, err = getGameReleaseById(toDelete.Id)
+	_, err = getGameReleaseById(context.Background(), toDelete.Id)
 	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
 }
 
@@ -272,7 +401,155 @@ func TestGameReleaseRepository_DeleteGameRelease_MissingId_ReturnsNotFound(t *te
 	test.insertMockData()
 	fakeId, _ := uuid.NewV4()
 
-	err := deleteGameRelease(fakeId)
+	err := deleteGameRelease(context.Background(), fakeId, 0, audit.System)
+
+	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
+}
+
+func (test *gameReleaseRepoTest) lastRevision() int64 {
+	row, err := test.connection.QueryRow("select max(id) from audit_log")
+	mocks.PanicOnErr(err)
+	var revision int64
+	mocks.PanicOnErr(row.Scan(&revision))
+	return revision
+}
+
+func TestGameReleaseRepository_RevertRelease_Exists_FieldsAndPlatformsRestored(t *testing.T) {
+	test := newGameReleaseRepoTest(t)
+	test.insertMockData()
+	modified := test.mockData[0]
+	title := "first"
+	modified.TitleOverride = &title
+	mocks.PanicOnErr(updateGameRelease(context.Background(), modified.Id, modified, audit.System))
+	revision := test.lastRevision()
+	otherTitle := "second"
+	modified.TitleOverride = &otherTitle
+	modified.PlatformIds = nil
+	mocks.PanicOnErr(updateGameRelease(context.Background(), modified.Id, modified, audit.System))
+
+	err := revertGameRelease(context.Background(), modified.Id, revision, audit.System)
+
+	mocks.AssertDefault(t, err)
+	loaded, _ := getGameReleaseById(context.Background(), modified.Id)
+	mocks.AssertEquals(t, *loaded.TitleOverride, title)
+	mocks.AssertCountEqual(t, loaded.PlatformIds, 1)
+	mocks.AssertEquals(t, loaded.PlatformIds[0], test.mockPlatformId)
+}
+
+func TestGameReleaseRepository_RevertRelease_Deleted_ReleaseRecreated(t *testing.T) {
+	test := newGameReleaseRepoTest(t)
+	test.insertMockData()
+	modified := test.mockData[1]
+	mocks.PanicOnErr(updateGameRelease(context.Background(), modified.Id, modified, audit.System))
+	revision := test.lastRevision()
+	mocks.PanicOnErr(deleteGameRelease(context.Background(), modified.Id, 0, audit.System))
+
+	err := revertGameRelease(context.Background(), modified.Id, revision, audit.System)
+
+	mocks.AssertDefault(t, err)
+	loaded, err := getGameReleaseById(context.Background(), modified.Id)
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, loaded.GameId, modified.GameId)
+	mocks.AssertEquals(t, *loaded.Description, *modified.Description)
+}
+
+func TestGameReleaseRepository_RevertRelease_DeletedWithLanguages_LanguagesRestored(t *testing.T) {
+	test := newGameReleaseRepoTest(t)
+	test.insertMockData()
+	released := test.mockData[1]
+	_, err := replaceGameReleaseLanguages(context.Background(), released.Id, 0, []*language.Support{{Language: "en", Interface: true}, {Language: "ja", Audio: true}}, audit.System)
+	mocks.PanicOnErr(err)
+	revision := test.lastRevision()
+	mocks.PanicOnErr(deleteGameRelease(context.Background(), released.Id, 0, audit.System))
+
+	err = revertGameRelease(context.Background(), released.Id, revision, audit.System)
+
+	mocks.AssertDefault(t, err)
+	loaded, err := getGameReleaseById(context.Background(), released.Id)
+	mocks.AssertDefault(t, err)
+	mocks.AssertCountEqual(t, loaded.Languages, 2)
+	mocks.AssertArrayContains(t, loaded.Languages, func(support *language.Support) bool { return support.Language == "en" && support.Interface })
+	mocks.AssertArrayContains(t, loaded.Languages, func(support *language.Support) bool { return support.Language == "ja" && support.Audio })
+}
+
+func TestGameReleaseRepository_RevertRelease_RevisionOfOtherRelease_ReturnsNotFound(t *testing.T) {
+	test := newGameReleaseRepoTest(t)
+	test.insertMockData()
+	mocks.PanicOnErr(updateGameRelease(context.Background(), test.mockData[1].Id, test.mockData[1], audit.System))
+	revision := test.lastRevision()
+
+	err := revertGameRelease(context.Background(), test.mockData[2].Id, revision, audit.System)
 
 	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
 }
+
+func TestGameReleaseRepository_RevertRelease_DeletionRevision_ReturnsInvalidData(t *testing.T) {
+	test := newGameReleaseRepoTest(t)
+	test.insertMockData()
+	mocks.PanicOnErr(deleteGameRelease(context.Background(), test.mockData[3].Id, 0, audit.System))
+	revision := test.lastRevision()
+
+	err := revertGameRelease(context.Background(), test.mockData[3].Id, revision, audit.System)
+
+	mocks.AssertEquals(t, err, utils.InvalidDataErr)
+}
+
+func TestGameReleaseRepository_ReplaceGameReleaseLanguages_StaleVersion_ReturnsVersionMismatch(t *testing.T) {
+	test := newGameReleaseRepoTest(t)
+	test.insertMockData()
+	version, err := replaceGameReleaseLanguages(context.Background(), test.mockData[0].Id, 1, []*language.Support{{Language: "en", Interface: true}}, audit.System)
+	mocks.PanicOnErr(err)
+	mocks.AssertEquals(t, version, 2)
+
+	_, err = replaceGameReleaseLanguages(context.Background(), test.mockData[0].Id, 1, []*language.Support{{Language: "de", Interface: true}}, audit.System)
+
+	mocks.AssertEquals(t, err, utils.VersionMismatchErr)
+}
+
+func TestGameReleaseRepository_ReplaceGameReleaseLanguages_Exists_ChangeRecorded(t *testing.T) {
+	test := newGameReleaseRepoTest(t)
+	test.insertMockData()
+	subject := "editor"
+	actor := audit.Actor{Kind: "user", Subject: &subject}
+
+	_, err := replaceGameReleaseLanguages(context.Background(), test.mockData[0].Id, 0, []*language.Support{{Language: "en", Interface: true}}, actor)
+
+	mocks.AssertDefault(t, err)
+	row, err := test.connection.QueryRow("select entity_id, action, actor_subject from audit_log where id = $1", test.lastRevision())
+	mocks.PanicOnErr(err)
+	var entityId uuid.UUID
+	var action audit.Action
+	var recordedSubject string
+	mocks.PanicOnErr(row.Scan(&entityId, &action, &recordedSubject))
+	mocks.AssertEquals(t, entityId, test.mockData[0].Id)
+	mocks.AssertEquals(t, action, audit.ActionUpdate)
+	mocks.AssertEquals(t, recordedSubject, subject)
+}
+
+func TestGameReleaseRepository_UpdateRelease_StaleVersion_ReturnsVersionMismatch(t *testing.T) {
+	test := newGameReleaseRepoTest(t)
+	test.insertMockData()
+	modified := test.mockData[1]
+	modified.Version = 3
+
+	err := updateGameRelease(context.Background(), modified.Id, modified, audit.System)
+
+	mocks.AssertEquals(t, err, utils.VersionMismatchErr)
+}
+
+func TestGameReleaseRepository_ReplaceGameReleaseLanguages_Changed_GameVersionIncrementedOnce(t *testing.T) {
+	test := newGameReleaseRepoTest(t)
+	test.insertMockData()
+	languages := []*language.Support{{Language: "en", Interface: true}}
+
+	_, err := replaceGameReleaseLanguages(context.Background(), test.mockData[0].Id, 0, languages, audit.System)
+	mocks.AssertDefault(t, err)
+	_, err = replaceGameReleaseLanguages(context.Background(), test.mockData[0].Id, 0, languages, audit.System)
+	mocks.AssertDefault(t, err)
+
+	row, err := test.connection.QueryRow("select version from games where id = $1", test.mockData[0].GameId)
+	mocks.PanicOnErr(err)
+	var version int
+	mocks.PanicOnErr(row.Scan(&version))
+	mocks.AssertEquals(t, version, 2)
+}
@@ -0,0 +1,136 @@
+package releasegroup
+
+import (
+	"fmt"
+	"github.com/Geepr/game/auth"
+	"github.com/Geepr/game/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gofrs/uuid"
+	"net/http"
+)
+
+func getRoute(c *gin.Context) {
+	var query struct {
+		GameId    string `form:"gameId" binding:"omitempty,uuid"`
+		Kind      Kind   `form:"kind" binding:"omitempty,oneof=crossplay crosssave"`
+		PageIndex int    `form:"page"`
+		PageSize  int    `form:"size"`
+	}
+	if err := c.ShouldBindWith(&query, binding.Query); err != nil {
+		utils.Logger(c).Infof("Failed to bind release group query: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+
+	groups, totalItems, err := getReleaseGroups(c, uuid.FromStringOrNil(query.GameId), query.Kind, query.PageIndex, query.PageSize)
+	if err != nil {
+		utils.AbortWithProblem(http.StatusInternalServerError, "", c)
+		return
+	}
+
+	response := struct {
+		Groups     []*ReleaseGroup `json:"groups"`
+		Page       int             `json:"page"`
+		PageSize   int             `json:"pageSize"`
+		TotalPages int             `json:"totalPages"`
+	}{
+		Groups:     groups,
+		Page:       query.PageIndex,
+		PageSize:   query.PageSize,
+		TotalPages: utils.GetPagesFromItems(totalItems, query.PageSize),
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func getByIdRoute(c *gin.Context) {
+	id, err := utils.ParseUuidFromParam(c)
+	if err != nil {
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+
+	group, err := getReleaseGroupById(c, id)
+	if err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+func createRoute(c *gin.Context) {
+	var createModel struct {
+		GameId     uuid.UUID   `json:"gameId" binding:"required"`
+		Kind       Kind        `json:"kind" binding:"required,oneof=crossplay crosssave"`
+		ReleaseIds []uuid.UUID `json:"releaseIds" binding:"required,min=2,unique"`
+	}
+	if err := c.ShouldBindWith(&createModel, binding.JSON); err != nil {
+		utils.Logger(c).Infof("Failed to parse release group creation model: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+
+	group := ReleaseGroup{
+		GameId:     createModel.GameId,
+		Kind:       createModel.Kind,
+		ReleaseIds: createModel.ReleaseIds,
+	}
+	if err := addReleaseGroup(c, &group); err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, &group)
+}
+
+func updateRoute(c *gin.Context) {
+	var updateModel struct {
+		ReleaseIds []uuid.UUID `json:"releaseIds" binding:"required,min=2,unique"`
+	}
+	if err := c.ShouldBindWith(&updateModel, binding.JSON); err != nil {
+		utils.Logger(c).Infof("Failed to parse release group update model: %s", err.Error())
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+	id, err := utils.ParseUuidFromParam(c)
+	if err != nil {
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+
+	group := ReleaseGroup{
+		ReleaseIds: updateModel.ReleaseIds,
+	}
+	if err := updateReleaseGroup(c, id, &group); err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, &group)
+}
+
+func deleteRoute(c *gin.Context) {
+	id, err := utils.ParseUuidFromParam(c)
+	if err != nil {
+		utils.AbortWithBindingError(err, c)
+		return
+	}
+
+	if err := deleteReleaseGroup(c, id); err != nil {
+		utils.AbortWithRelevantError(err, c)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func SetupRoutes(engine *gin.Engine, basePath string) {
+	baseUrl := fmt.Sprintf("%s/api/v0/release-groups", basePath)
+
+	engine.GET(baseUrl, getRoute)
+	engine.GET(baseUrl+"/:id", getByIdRoute)
+	engine.POST(baseUrl, auth.Require(auth.ResourceReleaseGroup, auth.ActionCreate), createRoute)
+	engine.PUT(baseUrl+"/:id", auth.Require(auth.ResourceReleaseGroup, auth.ActionUpdate), updateRoute)
+	engine.DELETE(baseUrl+"/:id", auth.Require(auth.ResourceReleaseGroup, auth.ActionDelete), deleteRoute)
+}
@@ -0,0 +1,22 @@
+package releasegroup
+
+import "github.com/gofrs/uuid"
+
+type Kind string
+
+const (
+	// KindCrossPlay groups releases that can play online together.
+	KindCrossPlay Kind = "crossplay"
+	// KindCrossSave groups releases that share save data or progression.
+	KindCrossSave Kind = "crosssave"
+)
+
+// ReleaseGroup is a set of releases of the same game that share some functionality across platforms.
+// A single release can only be a member of one group of each Kind.
+type ReleaseGroup struct {
+	Id     uuid.UUID `json:"id"`
+	GameId uuid.UUID `json:"gameId"`
+	Kind   Kind      `json:"kind"`
+	// ReleaseIds contains ids of all releases that are members of this group.
+	ReleaseIds []uuid.UUID `json:"releaseIds"`
+}
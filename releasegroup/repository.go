@@ -0,0 +1,183 @@
+package releasegroup
+
+import (
+	"context"
+	"github.com/Geepr/game/metrics"
+	"github.com/Geepr/game/utils"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/gofrs/uuid"
+	"github.com/lib/pq"
+	"time"
+)
+
+func getReleaseGroups(ctx context.Context, gameIdQuery uuid.UUID, kindQuery Kind, pageIndex int, pageSize int) (_ []*ReleaseGroup, _ int, err error) {
+	defer metrics.ObserveQuery("getReleaseGroups", time.Now(), &err)
+	query := "select id, game_id, kind, array(select rgm.game_release_id from release_group_members rgm where rgm.release_group_id = id) from release_groups"
+	query, args := utils.AppendWhereClause(query, "game_id", "=", gameIdQuery, utils.IsUuidNotEmpty, []any{})
+	query, args = utils.AppendWhereClause(query, "kind", "=", kindQuery, func(kind Kind) bool { return kind != "" }, args)
+	query += " order by id"
+	query, countQuery, err := utils.Paginate(query, pageIndex, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	countResults, err := utils.ScanCountQuery(ctx, getConnector(ctx), countQuery, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	groups, err := scanReleaseGroups(ctx, query, args...)
+	return groups, countResults, err
+}
+
+func getReleaseGroupById(ctx context.Context, id uuid.UUID) (_ *ReleaseGroup, err error) {
+	defer metrics.ObserveQuery("getReleaseGroupById", time.Now(), &err)
+	query := "select id, game_id, kind, array(select rgm.game_release_id from release_group_members rgm where rgm.release_group_id = $1) from release_groups where id = $1"
+	return scanReleaseGroup(ctx, query, id)
+}
+
+func addReleaseGroup(ctx context.Context, group *ReleaseGroup) (err error) {
+	defer metrics.ObserveQuery("addReleaseGroup", time.Now(), &err)
+	query := "insert into release_groups (game_id, kind) values ($1, $2) returning id"
+	transaction, err := getTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	defer transaction.Rollback()
+	result, err := transaction.QueryRow(query, group.GameId, group.Kind)
+	if err != nil {
+		return utils.ConvertIfNotFoundErr(err)
+	}
+	if err = result.Scan(&group.Id); err != nil {
+		return utils.ConvertIfNotFoundErr(err)
+	}
+	if err = createReleaseGroupMembers(ctx, group, transaction); err != nil {
+		return err
+	}
+	if err = touchMembers(ctx, transaction, group.Id, group.ReleaseIds); err != nil {
+		return err
+	}
+	return transaction.Commit()
+}
+
+// updateReleaseGroup replaces the members of a group.
+// Game and kind of the group cannot be changed, a new group should be created instead.
+func updateReleaseGroup(ctx context.Context, id uuid.UUID, updatedGroup *ReleaseGroup) (err error) {
+	defer metrics.ObserveQuery("updateReleaseGroup", time.Now(), &err)
+	transaction, err := getTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	defer transaction.Rollback()
+	result, err := transaction.QueryRow("select game_id, kind from release_groups where id = $1 for update", id)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to run query on release groups: %s", err.Error())
+		return err
+	}
+	if err = result.Scan(&updatedGroup.GameId, &updatedGroup.Kind); err != nil {
+		return utils.ConvertIfNotFoundErr(err)
+	}
+	updatedGroup.Id = id
+	if err = touchMembers(ctx, transaction, id, updatedGroup.ReleaseIds); err != nil {
+		return err
+	}
+	if _, err = transaction.Exec("delete from release_group_members where release_group_id = $1", id); err != nil {
+		utils.Logger(ctx).Warnf("Failed to remove release group members: %s", err.Error())
+		return err
+	}
+	if err = createReleaseGroupMembers(ctx, updatedGroup, transaction); err != nil {
+		return err
+	}
+	return transaction.Commit()
+}
+
+func deleteReleaseGroup(ctx context.Context, id uuid.UUID) (err error) {
+	defer metrics.ObserveQuery("deleteReleaseGroup", time.Now(), &err)
+	query := "delete from release_groups where id = $1"
+	transaction, err := getTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	defer transaction.Rollback()
+	if err = touchMembers(ctx, transaction, id, nil); err != nil {
+		return err
+	}
+	result, err := transaction.Exec(query, id)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute delete query on release groups: %s", err.Error())
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to read affected rows when running delete query on release groups: %s", err.Error())
+		return err
+	}
+	if affected != 1 {
+		return utils.DataNotFoundErr
+	}
+	return transaction.Commit()
+}
+
+// touchMembers increments versions of the current members of a group and of the releases about to join it,
+// as releases list their peers in crossplay and cross-save groups.
+func touchMembers(ctx context.Context, connector gotabase.Connector, id uuid.UUID, releaseIds []uuid.UUID) error {
+	query := "update game_releases set version = version + 1 where id in (select game_release_id from release_group_members where release_group_id = $1) or id = any($2)"
+	if _, err := connector.Exec(query, id, pq.Array(releaseIds)); err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute update query on release group members: %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// createReleaseGroupMembers verifies that all releases exist and belong to the game of the group before inserting them.
+func createReleaseGroupMembers(ctx context.Context, group *ReleaseGroup, connector gotabase.Connector) error {
+	matching, err := utils.ScanCountQuery(ctx, connector, "select count(*) from game_releases where id = any($1) and game_id = $2", pq.Array(group.ReleaseIds), group.GameId)
+	if err != nil {
+		return err
+	}
+	if matching != len(group.ReleaseIds) {
+		return utils.InvalidDataErr
+	}
+	for _, releaseId := range group.ReleaseIds {
+		_, err := connector.Exec("insert into release_group_members (release_group_id, game_release_id, game_id, kind) values ($1, $2, $3, $4)", group.Id, releaseId, group.GameId, group.Kind)
+		if err != nil {
+			return utils.ConvertIfDuplicateErr(err)
+		}
+	}
+	return nil
+}
+
+func scanReleaseGroups(ctx context.Context, sql string, args ...interface{}) ([]*ReleaseGroup, error) {
+	result, err := getConnector(ctx).QueryRows(sql, args...)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to run query on release groups: %s", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	groups := make([]*ReleaseGroup, 0)
+	for result.Next() {
+		group, err := scanRow(result)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+func scanReleaseGroup(ctx context.Context, sql string, args ...interface{}) (*ReleaseGroup, error) {
+	result, err := getConnector(ctx).QueryRow(sql, args...)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to run row query on release groups: %s", err.Error())
+		return nil, err
+	}
+	return scanRow(result)
+}
+
+func scanRow(row gotabase.Row) (*ReleaseGroup, error) {
+	group := ReleaseGroup{}
+	if err := row.Scan(&group.Id, &group.GameId, &group.Kind, pq.Array(&group.ReleaseIds)); err != nil {
+		return nil, utils.ConvertIfNotFoundErr(err)
+	}
+	return &group, nil
+}
@@ -0,0 +1,209 @@
+package releasegroup
+
+import (
+	"context"
+	"github.com/Geepr/game/mocks"
+	"github.com/Geepr/game/utils"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/gofrs/uuid"
+	"testing"
+)
+
+type releaseGroupRepoTest struct {
+	connection  gotabase.Connector
+	gameIds     []uuid.UUID
+	releaseIds  []uuid.UUID
+	mockGroupId uuid.UUID
+	dbName      string
+}
+
+func newReleaseGroupRepoTest(t *testing.T) *releaseGroupRepoTest {
+	db, name := mocks.GetDatabase()
+	test := &releaseGroupRepoTest{
+		connection: db,
+		dbName:     name,
+	}
+	getConnector = func(context.Context) gotabase.Connector { return db }
+	t.Cleanup(test.cleanup)
+	return test
+}
+
+func (test *releaseGroupRepoTest) cleanup() {
+	mocks.DropDatabase(test.dbName)
+}
+
+// insertMockData creates two games, with the first one having three releases and the second one having a single release.
+// The first two releases of the first game are put in a cross play group.
+func (test *releaseGroupRepoTest) insertMockData() {
+	game1, _ := uuid.NewV4()
+	game2, _ := uuid.NewV4()
+	release1, _ := uuid.NewV4()
+	release2, _ := uuid.NewV4()
+	release3, _ := uuid.NewV4()
+	release4, _ := uuid.NewV4()
+	groupId, _ := uuid.NewV4()
+	_, err := test.connection.Exec("insert into games (id, title, archived) values ($1, 'aaa', false), ($2, 'bbb', false)", game1, game2)
+	mocks.PanicOnErr(err)
+	_, err = test.connection.Exec("insert into game_releases (id, game_id, release_date_unknown) values ($1, $4, true), ($2, $4, true), ($3, $4, true), ($5, $6, true)", release1, release2, release3, game1, release4, game2)
+	mocks.PanicOnErr(err)
+	_, err = test.connection.Exec("insert into release_groups (id, game_id, kind) values ($1, $2, 'crossplay')", groupId, game1)
+	mocks.PanicOnErr(err)
+	_, err = test.connection.Exec("insert into release_group_members (release_group_id, game_release_id, game_id, kind) values ($1, $2, $4, 'crossplay'), ($1, $3, $4, 'crossplay')", groupId, release1, release2, game1)
+	mocks.PanicOnErr(err)
+	test.gameIds = []uuid.UUID{game1, game2}
+	test.releaseIds = []uuid.UUID{release1, release2, release3, release4}
+	test.mockGroupId = groupId
+}
+
+func TestReleaseGroupRepository_GetReleaseGroups_GameIdSet_ReturnsMatching(t *testing.T) {
+	test := newReleaseGroupRepoTest(t)
+	test.insertMockData()
+
+	result, count, err := getReleaseGroups(context.Background(), test.gameIds[0], "", 0, 100)
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, count, 1)
+	mocks.AssertCountEqual(t, result, 1)
+	mocks.AssertEquals(t, result[0].Id, test.mockGroupId)
+	mocks.AssertCountEqual(t, result[0].ReleaseIds, 2)
+}
+
+func TestReleaseGroupRepository_GetReleaseGroups_KindNotMatching_ReturnsEmpty(t *testing.T) {
+	test := newReleaseGroupRepoTest(t)
+	test.insertMockData()
+
+	result, count, err := getReleaseGroups(context.Background(), utils.DefaultUuid, KindCrossSave, 0, 100)
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, count, 0)
+	mocks.AssertCountEqual(t, result, 0)
+}
+
+func TestReleaseGroupRepository_GetReleaseGroupById_Missing_ReturnsNotFound(t *testing.T) {
+	test := newReleaseGroupRepoTest(t)
+	test.insertMockData()
+	fakeId, _ := uuid.NewV4()
+
+	_, err := getReleaseGroupById(context.Background(), fakeId)
+
+	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
+}
+
+func TestReleaseGroupRepository_AddReleaseGroup_ValidReleases_Added(t *testing.T) {
+	test := newReleaseGroupRepoTest(t)
+	test.insertMockData()
+	group := ReleaseGroup{
+		GameId:     test.gameIds[0],
+		Kind:       KindCrossSave,
+		ReleaseIds: []uuid.UUID{test.releaseIds[0], test.releaseIds[2]},
+	}
+
+	err := addReleaseGroup(context.Background(), &group)
+
+	mocks.AssertDefault(t, err)
+	loaded, err := getReleaseGroupById(context.Background(), group.Id)
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, loaded.Kind, KindCrossSave)
+	mocks.AssertCountEqual(t, loaded.ReleaseIds, 2)
+}
+
+func TestReleaseGroupRepository_AddReleaseGroup_ReleaseOfOtherGame_ReturnsInvalidData(t *testing.T) {
+	test := newReleaseGroupRepoTest(t)
+	test.insertMockData()
+	group := ReleaseGroup{
+		GameId:     test.gameIds[0],
+		Kind:       KindCrossSave,
+		ReleaseIds: []uuid.UUID{test.releaseIds[0], test.releaseIds[3]},
+	}
+
+	err := addReleaseGroup(context.Background(), &group)
+
+	mocks.AssertEquals(t, err, utils.InvalidDataErr)
+}
+
+func TestReleaseGroupRepository_AddReleaseGroup_ReleaseAlreadyInGroupOfKind_ReturnsDuplicate(t *testing.T) {
+	test := newReleaseGroupRepoTest(t)
+	test.insertMockData()
+	group := ReleaseGroup{
+		GameId:     test.gameIds[0],
+		Kind:       KindCrossPlay,
+		ReleaseIds: []uuid.UUID{test.releaseIds[1], test.releaseIds[2]},
+	}
+
+	err := addReleaseGroup(context.Background(), &group)
+
+	mocks.AssertEquals(t, err, utils.DuplicateDataErr)
+}
+
+func TestReleaseGroupRepository_UpdateReleaseGroup_Exists_MembersReplaced(t *testing.T) {
+	test := newReleaseGroupRepoTest(t)
+	test.insertMockData()
+	group := ReleaseGroup{
+		ReleaseIds: []uuid.UUID{test.releaseIds[1], test.releaseIds[2]},
+	}
+
+	err := updateReleaseGroup(context.Background(), test.mockGroupId, &group)
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, group.GameId, test.gameIds[0])
+	loaded, _ := getReleaseGroupById(context.Background(), test.mockGroupId)
+	mocks.AssertCountEqual(t, loaded.ReleaseIds, 2)
+	mocks.AssertArrayContains(t, loaded.ReleaseIds, func(value uuid.UUID) bool { return value == test.releaseIds[2] })
+}
+
+func TestReleaseGroupRepository_UpdateReleaseGroup_Missing_ReturnsNotFound(t *testing.T) {
+	test := newReleaseGroupRepoTest(t)
+	test.insertMockData()
+	fakeId, _ := uuid.NewV4()
+
+	err := updateReleaseGroup(context.Background(), fakeId, &ReleaseGroup{ReleaseIds: test.releaseIds[:2]})
+
+	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
+}
+
+func TestReleaseGroupRepository_DeleteReleaseGroup_Exists_Removes(t *testing.T) {
+	test := newReleaseGroupRepoTest(t)
+	test.insertMockData()
+
+	err := deleteReleaseGroup(context.Background(), test.mockGroupId)
+
+	mocks.AssertDefault(t, err)
+	_, err = getReleaseGroupById(context.Background(), test.mockGroupId)
+	mocks.AssertEquals(t, err, utils.DataNotFoundErr)
+}
+
+func (test *releaseGroupRepoTest) releaseVersions() []int {
+	versions := make([]int, len(test.releaseIds))
+	for i, id := range test.releaseIds {
+		row, err := test.connection.QueryRow("select version from game_releases where id = $1", id)
+		mocks.PanicOnErr(err)
+		mocks.PanicOnErr(row.Scan(&versions[i]))
+	}
+	return versions
+}
+
+func TestReleaseGroupRepository_UpdateReleaseGroup_Exists_OldAndNewMembersVersionsIncremented(t *testing.T) {
+	test := newReleaseGroupRepoTest(t)
+	test.insertMockData()
+
+	err := updateReleaseGroup(context.Background(), test.mockGroupId, &ReleaseGroup{ReleaseIds: []uuid.UUID{test.releaseIds[1], test.releaseIds[2]}})
+
+	mocks.AssertDefault(t, err)
+	versions := test.releaseVersions()
+	mocks.AssertEquals(t, versions[0], 2)
+	mocks.AssertEquals(t, versions[1], 2)
+	mocks.AssertEquals(t, versions[2], 2)
+	mocks.AssertEquals(t, versions[3], 1)
+}
+
+func TestReleaseGroupRepository_DeleteReleaseGroup_Exists_MembersVersionsIncremented(t *testing.T) {
+	test := newReleaseGroupRepoTest(t)
+	test.insertMockData()
+
+	err := deleteReleaseGroup(context.Background(), test.mockGroupId)
+
+	mocks.AssertDefault(t, err)
+	versions := test.releaseVersions()
+	mocks.AssertEquals(t, versions[0], 2)
+	mocks.AssertEquals(t, versions[2], 1)
+}
@@ -0,0 +1,55 @@
+package seed
+
+import (
+	"fmt"
+	"github.com/Geepr/game/platform"
+	"strings"
+	"time"
+)
+
+// Pack is a set of well-known entities shipped with the service, its version is increased with every change of its contents.
+type Pack struct {
+	Name      string     `json:"name" binding:"required,max=50"`
+	Version   int        `json:"version" binding:"min=1"`
+	Platforms []Platform `json:"platforms" binding:"dive"`
+}
+
+// Platform is matched with existing platforms by its short name, seeded values overwrite those of the existing platform.
+type Platform struct {
+	Name         string          `json:"name" binding:"required,max=200"`
+	ShortName    string          `json:"shortName" binding:"required,max=10"`
+	Manufacturer *string         `json:"manufacturer" binding:"omitempty,max=100"`
+	Family       platform.Family `json:"family" binding:"required,oneof=pc console handheld mobile other"`
+}
+
+// Result describes changes made by applying a pack.
+type Result struct {
+	Pack    string `json:"pack"`
+	Version int    `json:"version"`
+	// Skipped is set when the version of the pack has already been applied, nothing is changed then.
+	Skipped   bool `json:"skipped"`
+	Created   int  `json:"created"`
+	Updated   int  `json:"updated"`
+	Unchanged int  `json:"unchanged"`
+	// Conflicts describe entities left out, as they clash with existing ones in a way that can't be resolved automatically.
+	Conflicts []string `json:"conflicts"`
+}
+
+func (r Result) String() string {
+	if r.Skipped {
+		return fmt.Sprintf("seed pack %s version %d is already applied", r.Pack, r.Version)
+	}
+	summary := fmt.Sprintf("seed pack %s version %d applied: %d created, %d updated, %d unchanged", r.Pack, r.Version, r.Created, r.Updated, r.Unchanged)
+	if len(r.Conflicts) > 0 {
+		summary += fmt.Sprintf(", %d skipped: %s", len(r.Conflicts), strings.Join(r.Conflicts, "; "))
+	}
+	return summary
+}
+
+// Status compares a pack with the version applied to the database, AppliedVersion is 0 for packs that were never applied.
+type Status struct {
+	Pack           string     `json:"pack"`
+	Version        int        `json:"version"`
+	AppliedVersion int        `json:"appliedVersion"`
+	AppliedAt      *time.Time `json:"appliedAt"`
+}
@@ -0,0 +1,55 @@
+package seed
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin/binding"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+//go:embed packs/*.json
+var packFiles embed.FS
+
+// loadPacks decodes and validates all packs of the file system, sorted by name.
+func loadPacks(files fs.FS) ([]*Pack, error) {
+	names, err := fs.Glob(files, "packs/*.json")
+	if err != nil {
+		return nil, err
+	}
+	packs := make([]*Pack, 0, len(names))
+	for _, name := range names {
+		data, err := fs.ReadFile(files, name)
+		if err != nil {
+			return nil, err
+		}
+		pack := &Pack{}
+		if err = json.Unmarshal(data, pack); err != nil {
+			return nil, fmt.Errorf("seed pack %s is not valid json: %w", name, err)
+		}
+		if err = validatePack(pack); err != nil {
+			return nil, fmt.Errorf("seed pack %s is not valid: %w", name, err)
+		}
+		packs = append(packs, pack)
+	}
+	sort.Slice(packs, func(i, j int) bool { return packs[i].Name < packs[j].Name })
+	return packs, nil
+}
+
+// validatePack checks the fields of all entities, along with the uniqueness enforced by the database, which ignores case.
+func validatePack(pack *Pack) error {
+	if err := binding.Validator.ValidateStruct(pack); err != nil {
+		return err
+	}
+	names, shortNames := make(map[string]bool), make(map[string]bool)
+	for _, platform := range pack.Platforms {
+		name, shortName := strings.ToUpper(platform.Name), strings.ToUpper(platform.ShortName)
+		if names[name] || shortNames[shortName] {
+			return fmt.Errorf("platform %s is listed more than once", platform.ShortName)
+		}
+		names[name], shortNames[shortName] = true, true
+	}
+	return nil
+}
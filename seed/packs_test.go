@@ -0,0 +1,57 @@
+package seed
+
+import (
+	"github.com/Geepr/game/mocks"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadPacks_EmbeddedPacks_Valid(t *testing.T) {
+	packs, err := loadPacks(packFiles)
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertArrayContains(t, packs, func(pack *Pack) bool { return pack.Name == "platforms" && len(pack.Platforms) > 0 })
+}
+
+func TestLoadPacks_InvalidPacks_ReturnsErr(t *testing.T) {
+	testData := []struct {
+		name string
+		pack string
+	}{
+		{"not json", `platforms`},
+		{"no version", `{"name": "platforms", "platforms": []}`},
+		{"long short name", `{"name": "platforms", "version": 1, "platforms": [{"name": "PlayStation 5", "shortName": "PLAYSTATION5", "family": "console"}]}`},
+		{"unknown family", `{"name": "platforms", "version": 1, "platforms": [{"name": "PlayStation 5", "shortName": "PS5", "family": "toaster"}]}`},
+		{"duplicate short name", `{"name": "platforms", "version": 1, "platforms": [{"name": "PlayStation 5", "shortName": "PS5", "family": "console"}, {"name": "PS5 Pro", "shortName": "ps5", "family": "console"}]}`},
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(currentData.name, func(t *testing.T) {
+			files := fstest.MapFS{"packs/platforms.json": {Data: []byte(currentData.pack)}}
+
+			_, err := loadPacks(files)
+
+			mocks.AssertEquals(t, err != nil, true)
+		})
+	}
+}
+
+func TestResult_String_DescribesChanges(t *testing.T) {
+	testData := []struct {
+		result   Result
+		expected string
+	}{
+		{Result{Pack: "platforms", Version: 2, Skipped: true}, "seed pack platforms version 2 is already applied"},
+		{Result{Pack: "platforms", Version: 2, Created: 3, Updated: 1}, "seed pack platforms version 2 applied: 3 created, 1 updated, 0 unchanged"},
+		{Result{Pack: "platforms", Version: 2, Unchanged: 4, Conflicts: []string{"PS5 is already used as Ps5"}},
+			"seed pack platforms version 2 applied: 0 created, 0 updated, 4 unchanged, 1 skipped: PS5 is already used as Ps5"},
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(currentData.expected, func(t *testing.T) {
+			mocks.AssertEquals(t, currentData.result.String(), currentData.expected)
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/metrics"
+	"github.com/Geepr/game/tracing"
+	"github.com/Geepr/game/utils"
+	"github.com/gofrs/uuid"
+	"github.com/lib/pq"
+	"time"
+)
+
+// Apply applies all packs with versions newer than the applied ones, or all of them again when forced.
+// Each pack is applied in its own transaction, with changes recorded in the audit log.
+func Apply(ctx context.Context, force bool, actor audit.Actor) ([]Result, error) {
+	packs, err := loadPacks(packFiles)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(packs))
+	for _, pack := range packs {
+		result, err := applyPack(ctx, pack, force, actor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply seed pack %s: %w", pack.Name, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// GetStatus lists all packs along with the versions applied to the database.
+func GetStatus(ctx context.Context) (_ []Status, err error) {
+	defer metrics.ObserveQuery("getSeedStatus", time.Now(), &err)
+	packs, err := loadPacks(packFiles)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, len(packs))
+	for i, pack := range packs {
+		statuses[i] = Status{Pack: pack.Name, Version: pack.Version}
+		result, err := getConnector(ctx).QueryRow("select version, applied_at from seed_packs where name = $1", pack.Name)
+		if err != nil {
+			utils.Logger(ctx).Warnf("Failed to run query on seed packs: %s", err.Error())
+			return nil, err
+		}
+		if err = result.Scan(&statuses[i].AppliedVersion, &statuses[i].AppliedAt); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+	}
+	return statuses, nil
+}
+
+func applyPack(ctx context.Context, pack *Pack, force bool, actor audit.Actor) (result Result, err error) {
+	defer metrics.ObserveQuery("applySeedPack", time.Now(), &err)
+	result = Result{Pack: pack.Name, Version: pack.Version, Conflicts: make([]string, 0)}
+	transaction, err := getTransaction(ctx)
+	if err != nil {
+		return result, err
+	}
+	defer transaction.Rollback()
+	// instances starting at the same time would otherwise apply the same pack concurrently
+	if _, err = transaction.Exec("lock table seed_packs in share row exclusive mode"); err != nil {
+		utils.Logger(ctx).Warnf("Failed to lock seed packs: %s", err.Error())
+		return result, err
+	}
+	var applied int
+	row, err := transaction.QueryRow("select version from seed_packs where name = $1", pack.Name)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to run query on seed packs: %s", err.Error())
+		return result, err
+	}
+	if err = row.Scan(&applied); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return result, err
+	}
+	if applied >= pack.Version && !force {
+		result.Skipped = true
+		return result, nil
+	}
+
+	for _, platform := range pack.Platforms {
+		if err = upsertPlatform(ctx, transaction, platform, actor, &result); err != nil {
+			return result, err
+		}
+	}
+	query := "insert into seed_packs (name, version) values ($1, $2) on conflict on constraint pk_seed_packs do update set version = excluded.version, applied_at = now()"
+	if _, err = transaction.Exec(query, pack.Name, pack.Version); err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute upsert query on seed packs: %s", err.Error())
+		return result, err
+	}
+	return result, transaction.Commit()
+}
+
+// upsertPlatform creates the platform, or updates the one with the same short name if any of its fields differ.
+// Platforms clashing with existing ones on anything but the exact short name are reported as conflicts and left out.
+func upsertPlatform(ctx context.Context, transaction *tracing.Transaction, platform Platform, actor audit.Actor, result *Result) error {
+	var id uuid.UUID
+	var shortName string
+	row, err := transaction.QueryRow("select id, short_name from platforms where short_name_normalised = upper($1)", platform.ShortName)
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to run query on platforms table: %s", err.Error())
+		return err
+	}
+	if err = row.Scan(&id, &shortName); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	exists := err == nil
+	if exists && shortName != platform.ShortName {
+		result.Conflicts = append(result.Conflicts, fmt.Sprintf("%s is already used as %s", platform.ShortName, shortName))
+		return nil
+	}
+	var before json.RawMessage
+	if exists {
+		if before, err = audit.Snapshot(ctx, transaction, audit.EntityPlatform, id); err != nil {
+			return err
+		}
+	}
+
+	// the savepoint keeps the transaction usable when the name is already taken by another platform
+	if _, err = transaction.Exec("savepoint seed_platform"); err != nil {
+		return err
+	}
+	query := "insert into platforms (name, short_name, manufacturer, family) values ($1, $2, $3, $4) " +
+		"on conflict on constraint ix_platform_short_name do update set name = excluded.name, manufacturer = excluded.manufacturer, family = excluded.family, version = platforms.version + 1 " +
+		"where (platforms.name, platforms.manufacturer, platforms.family) is distinct from (excluded.name, excluded.manufacturer, excluded.family) returning id"
+	row, err = transaction.QueryRow(query, platform.Name, platform.ShortName, platform.Manufacturer, platform.Family)
+	if err == nil {
+		err = row.Scan(&id)
+	}
+	var pgErr *pq.Error
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		if _, err = transaction.Exec("rollback to savepoint seed_platform"); err != nil {
+			return err
+		}
+		result.Conflicts = append(result.Conflicts, fmt.Sprintf("%s is named %q, which is used by another platform", platform.ShortName, platform.Name))
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		result.Unchanged++
+		_, err = transaction.Exec("release savepoint seed_platform")
+		return err
+	}
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute upsert query on platforms table: %s", err.Error())
+		return err
+	}
+	if _, err = transaction.Exec("release savepoint seed_platform"); err != nil {
+		return err
+	}
+
+	action := audit.ActionInsert
+	if exists {
+		action = audit.ActionUpdate
+		result.Updated++
+	} else {
+		result.Created++
+	}
+	return audit.Record(ctx, transaction, audit.EntityPlatform, id, action, actor, before)
+}
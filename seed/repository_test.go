@@ -0,0 +1,74 @@
+package seed
+
+import (
+	"context"
+	"github.com/Geepr/game/audit"
+	"github.com/Geepr/game/mocks"
+	"github.com/KowalskiPiotr98/gotabase"
+	"testing"
+)
+
+type seedRepoTest struct {
+	connection gotabase.Connector
+	dbName     string
+}
+
+func newSeedRepoTest(t *testing.T) *seedRepoTest {
+	db, name := mocks.GetDatabase()
+	test := &seedRepoTest{
+		connection: db,
+		dbName:     name,
+	}
+	t.Cleanup(test.cleanup)
+	return test
+}
+
+func (test *seedRepoTest) cleanup() {
+	mocks.DropDatabase(test.dbName)
+}
+
+func (test *seedRepoTest) apply(force bool) Result {
+	results, err := Apply(context.Background(), force, audit.System)
+	mocks.PanicOnErr(err)
+	return results[0]
+}
+
+func (test *seedRepoTest) count(query string) int {
+	row, err := test.connection.QueryRow(query)
+	mocks.PanicOnErr(err)
+	var count int
+	mocks.PanicOnErr(row.Scan(&count))
+	return count
+}
+
+func TestSeedRepository_Apply_EmptyDatabase_PlatformsCreatedOnce(t *testing.T) {
+	test := newSeedRepoTest(t)
+	packs, err := loadPacks(packFiles)
+	mocks.PanicOnErr(err)
+	expected := len(packs[0].Platforms)
+
+	first := test.apply(false)
+	second := test.apply(false)
+	forced := test.apply(true)
+
+	mocks.AssertEquals(t, first.Created, expected)
+	mocks.AssertEquals(t, second.Skipped, true)
+	mocks.AssertEquals(t, forced.Unchanged, expected)
+	mocks.AssertEquals(t, test.count("select count(*) from platforms"), expected)
+	mocks.AssertEquals(t, test.count("select count(*) from audit_log where entity_type = 'platform'"), expected)
+	mocks.AssertEquals(t, test.count("select count(*) from platforms where short_name = 'PS5' and manufacturer = 'Sony' and family = 'console'"), 1)
+}
+
+func TestSeedRepository_Apply_ExistingPlatforms_UpdatedOrReported(t *testing.T) {
+	test := newSeedRepoTest(t)
+	_, err := test.connection.Exec("insert into platforms (name, short_name) values ('Sony PlayStation 5', 'PS5'), ('Switch', 'nsw'), ('Nintendo 64', 'N-64')")
+	mocks.PanicOnErr(err)
+
+	result := test.apply(false)
+
+	mocks.AssertEquals(t, result.Updated, 1)
+	mocks.AssertCountEqual(t, result.Conflicts, 2)
+	mocks.AssertEquals(t, test.count("select count(*) from platforms where short_name = 'PS5' and name = 'PlayStation 5' and version = 2"), 1)
+	mocks.AssertEquals(t, test.count("select count(*) from platforms where short_name_normalised = 'NSW'"), 1)
+	mocks.AssertEquals(t, test.count("select count(*) from seed_packs where name = 'platforms'"), 1)
+}
@@ -1,27 +1,46 @@
 package services
 
 import (
+	"github.com/Geepr/game/config"
+	"github.com/Geepr/game/utils"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
 	"time"
 )
 
-func GetGinLogger() gin.HandlerFunc {
+// GetGinLogger logs every finished request with the request logger, which must be assigned earlier by GetRequestIdMiddleware.
+// Requests failing with server errors are logged as warnings.
+func GetGinLogger(logConfig config.Log) gin.HandlerFunc {
+	skipPaths := make(map[string]bool, len(logConfig.SkipPaths))
+	for _, path := range logConfig.SkipPaths {
+		skipPaths[path] = true
+	}
+
 	return func(c *gin.Context) {
 		startTime := time.Now()
 		c.Next()
-		endTime := time.Now()
-		latencyTime := endTime.Sub(startTime)
-		reqMethod := c.Request.Method
-		reqUri := c.Request.RequestURI
+		if skipPaths[c.Request.URL.Path] {
+			return
+		}
 		statusCode := c.Writer.Status()
-		clientIP := c.ClientIP()
-		log.Infof("| %3d | %13v | %15s | %s | %s |",
-			statusCode,
-			latencyTime,
-			clientIP,
-			reqMethod,
-			reqUri,
-		)
+		fields := log.Fields{
+			"method":    c.Request.Method,
+			"path":      c.Request.URL.Path,
+			"route":     c.FullPath(),
+			"status":    statusCode,
+			"latencyMs": float64(time.Since(startTime).Microseconds()) / 1000,
+			"clientIp":  c.ClientIP(),
+		}
+		if len(c.Errors) > 0 {
+			fields["error"] = strings.Join(c.Errors.Errors(), "; ")
+		}
+		logger := utils.Logger(c).WithFields(fields)
+		if statusCode >= http.StatusInternalServerError {
+			logger.Warn("Request failed")
+		} else {
+			logger.Info("Request finished")
+		}
 	}
 }
@@ -0,0 +1,25 @@
+package services
+
+import (
+	"github.com/Geepr/game/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+	"regexp"
+)
+
+// requestIdRegex limits the accepted ids to what could reasonably be sent by proxies, to keep logs free of arbitrary client input.
+var requestIdRegex = regexp.MustCompile(`^[A-Za-z0-9._:/+=-]{1,128}$`)
+
+// GetRequestIdMiddleware assigns an id to every request, echoed back in the X-Request-ID header.
+// Ids sent by clients or proxies are kept, so that a request can be followed across services; new ones are generated otherwise.
+func GetRequestIdMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestId := c.GetHeader(utils.RequestIdHeader)
+		if !requestIdRegex.MatchString(requestId) {
+			requestId = uuid.Must(uuid.NewV4()).String()
+		}
+		utils.SetRequestId(c, requestId)
+		c.Header(utils.RequestIdHeader, requestId)
+		c.Next()
+	}
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"github.com/Geepr/game/mocks"
+	"github.com/Geepr/game/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetRequestIdMiddleware_HeaderValues_KeptWhenValid(t *testing.T) {
+	testData := []struct {
+		header string
+		kept   bool
+	}{
+		{"", false},
+		{"abc-123", true},
+		{"Root=1-5759e988-bd862e3fe1be46a994272793", true},
+		{"with space", false},
+		{"new\nline", false},
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(currentData.header, func(t *testing.T) {
+			engine := gin.New()
+			engine.Use(GetRequestIdMiddleware())
+			var assigned string
+			engine.GET("/", func(c *gin.Context) { assigned = utils.GetRequestId(c) })
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.Header.Set(utils.RequestIdHeader, currentData.header)
+			recorder := httptest.NewRecorder()
+
+			engine.ServeHTTP(recorder, request)
+
+			mocks.AssertEquals(t, assigned != "", true)
+			mocks.AssertEquals(t, recorder.Header().Get(utils.RequestIdHeader), assigned)
+			mocks.AssertEquals(t, assigned == currentData.header, currentData.kept)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/KowalskiPiotr98/gotabase"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+)
+
+var (
+	rowsAffectedKey = attribute.Key("db.rows_affected")
+	rowsReturnedKey = attribute.Key("db.rows_returned")
+)
+
+// connector records a span for every query, as a child of the span in the context.
+// Only the sql templates are recorded, never the values of their arguments.
+type connector struct {
+	ctx       context.Context
+	connector gotabase.Connector
+}
+
+// WrapConnector traces all queries run with the connector as a part of the context.
+func WrapConnector(ctx context.Context, wrapped gotabase.Connector) gotabase.Connector {
+	return &connector{ctx: ctx, connector: wrapped}
+}
+
+func (c *connector) QueryRow(query string, args ...interface{}) (gotabase.Row, error) {
+	span := c.startSpan(query)
+	defer span.End()
+	row, err := c.connector.QueryRow(query, args...)
+	if err == nil {
+		// rows returned by the database package only report errors on scanning, but they can be checked beforehand
+		if errorRow, ok := row.(interface{ Err() error }); ok {
+			err = errorRow.Err()
+		}
+	}
+	recordError(span, err)
+	return row, err
+}
+
+func (c *connector) QueryRows(query string, args ...interface{}) (gotabase.Rows, error) {
+	span := c.startSpan(query)
+	rows, err := c.connector.QueryRows(query, args...)
+	if err != nil {
+		recordError(span, err)
+		span.End()
+		return nil, err
+	}
+	// the span lasts until the rows are closed, so it includes reading them
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func (c *connector) Exec(query string, args ...interface{}) (gotabase.Result, error) {
+	span := c.startSpan(query)
+	defer span.End()
+	result, err := c.connector.Exec(query, args...)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	if affected, err := result.RowsAffected(); err == nil {
+		span.SetAttributes(rowsAffectedKey.Int64(affected))
+	}
+	return result, nil
+}
+
+func (c *connector) startSpan(query string) trace.Span {
+	operation, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	operation = strings.ToLower(operation)
+	_, span := tracer().Start(c.ctx, operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		semconv.DBSystemPostgreSQL,
+		semconv.DBOperationName(operation),
+		semconv.DBQueryText(query),
+	))
+	return span
+}
+
+type tracedRows struct {
+	gotabase.Rows
+	span     trace.Span
+	returned int64
+}
+
+func (r *tracedRows) Next() bool {
+	hasNext := r.Rows.Next()
+	if hasNext {
+		r.returned++
+	}
+	return hasNext
+}
+
+func (r *tracedRows) Close() error {
+	err := r.Rows.Close()
+	r.span.SetAttributes(rowsReturnedKey.Int64(r.returned))
+	recordError(r.span, err)
+	r.span.End()
+	return err
+}
+
+func recordError(span trace.Span, err error) {
+	if err == nil || errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// Transaction is a database transaction tracing all of its queries.
+type Transaction struct {
+	gotabase.Connector
+	transaction *gotabase.Transaction
+}
+
+// BeginTransaction starts a new transaction, with queries traced as a part of the context.
+func BeginTransaction(ctx context.Context) (*Transaction, error) {
+	transaction, err := gotabase.BeginTransaction()
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{Connector: WrapConnector(ctx, transaction), transaction: transaction}, nil
+}
+
+func (t *Transaction) Commit() error {
+	return t.transaction.Commit()
+}
+
+func (t *Transaction) Rollback() error {
+	return t.transaction.Rollback()
+}
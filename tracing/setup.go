@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"context"
+	"github.com/Geepr/game/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	serviceName = "game"
+	tracerName  = "github.com/Geepr/game/tracing"
+)
+
+// Setup makes spans recorded by the service go to the configured exporter.
+// The returned function flushes spans that were not exported yet, it should be called before the service exits.
+// With the none exporter, spans are not recorded at all.
+func Setup(tracingConfig config.Tracing) (func(context.Context) error, error) {
+	// trace context is taken from incoming requests even if nothing is exported, so that it can be logged
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch tracingConfig.Exporter {
+	case "otlp":
+		var options []otlptracehttp.Option
+		if tracingConfig.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(tracingConfig.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(context.Background(), options...)
+	case "stdout":
+		exporter, err = stdouttrace.New()
+	default:
+		return func(context.Context) error { return nil }, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
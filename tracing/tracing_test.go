@@ -0,0 +1,123 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/Geepr/game/mocks"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeResult struct{}
+
+func (fakeResult) LastInsertId() (int64, error) { return 0, nil }
+func (fakeResult) RowsAffected() (int64, error) { return 3, nil }
+
+type fakeRows struct {
+	remaining int
+}
+
+func (r *fakeRows) Close() error        { return nil }
+func (r *fakeRows) Scan(_ ...any) error { return nil }
+func (r *fakeRows) Next() bool          { r.remaining--; return r.remaining >= 0 }
+
+// fakeConnector returns canned results, failing queries that start with "fail".
+type fakeConnector struct{}
+
+func (fakeConnector) QueryRow(_ string, _ ...interface{}) (gotabase.Row, error) { return nil, nil }
+func (fakeConnector) QueryRows(query string, _ ...interface{}) (gotabase.Rows, error) {
+	if query == "fail" {
+		return nil, errors.New("connection refused")
+	}
+	return &fakeRows{remaining: 2}, nil
+}
+func (fakeConnector) Exec(_ string, _ ...interface{}) (gotabase.Result, error) {
+	return fakeResult{}, nil
+}
+
+func newSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func getAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestWrapConnector_Exec_RecordsStatementAndRowsAffected(t *testing.T) {
+	recorder := newSpanRecorder(t)
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+
+	_, err := WrapConnector(ctx, fakeConnector{}).Exec("delete from games where id = $1", 1)
+	parent.End()
+
+	mocks.AssertDefault(t, err)
+	spans := recorder.Ended()
+	mocks.AssertCountEqual(t, spans, 2)
+	mocks.AssertEquals(t, spans[0].Name(), "delete")
+	mocks.AssertEquals(t, spans[0].Parent().SpanID(), parent.SpanContext().SpanID())
+	mocks.AssertEquals(t, getAttribute(spans[0], "db.query.text").AsString(), "delete from games where id = $1")
+	mocks.AssertEquals(t, getAttribute(spans[0], rowsAffectedKey).AsInt64(), int64(3))
+}
+
+func TestWrapConnector_QueryRows_SpanEndsOnClose(t *testing.T) {
+	recorder := newSpanRecorder(t)
+
+	rows, err := WrapConnector(context.Background(), fakeConnector{}).QueryRows("select count(*) from games")
+	mocks.AssertDefault(t, err)
+	for rows.Next() {
+	}
+	mocks.AssertCountEqual(t, recorder.Ended(), 0)
+	mocks.PanicOnErr(rows.Close())
+
+	spans := recorder.Ended()
+	mocks.AssertCountEqual(t, spans, 1)
+	mocks.AssertEquals(t, getAttribute(spans[0], rowsReturnedKey).AsInt64(), int64(2))
+}
+
+func TestWrapConnector_QueryFails_ErrorRecorded(t *testing.T) {
+	recorder := newSpanRecorder(t)
+
+	_, err := WrapConnector(context.Background(), fakeConnector{}).QueryRows("fail")
+
+	mocks.AssertEquals(t, err != nil, true)
+	spans := recorder.Ended()
+	mocks.AssertCountEqual(t, spans, 1)
+	mocks.AssertEquals(t, spans[0].Status().Code, codes.Error)
+}
+
+func TestGetGinMiddleware_Request_SpanNamedByRoute(t *testing.T) {
+	recorder := newSpanRecorder(t)
+	engine := gin.New()
+	engine.ContextWithFallback = true
+	engine.Use(GetGinMiddleware())
+	engine.GET("/games/:id", func(c *gin.Context) {
+		_, _ = WrapConnector(c, fakeConnector{}).Exec("update games set archived = true")
+		c.Status(http.StatusInternalServerError)
+	})
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/games/1", nil))
+
+	spans := recorder.Ended()
+	mocks.AssertCountEqual(t, spans, 2)
+	mocks.AssertEquals(t, spans[1].Name(), "GET /games/:id")
+	mocks.AssertEquals(t, spans[0].Parent().SpanID(), spans[1].SpanContext().SpanID())
+	mocks.AssertEquals(t, getAttribute(spans[1], "http.response.status_code").AsInt64(), int64(500))
+	mocks.AssertEquals(t, spans[1].Status().Code, codes.Error)
+}
@@ -2,21 +2,28 @@ package utils
 
 import (
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 	"github.com/gofrs/uuid"
-	log "github.com/sirupsen/logrus"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 )
 
+// PreconditionRequiredErr is returned when a change is requested without the If-Match header.
+var PreconditionRequiredErr = errors.New("the If-Match header with the current ETag, or *, is required to change data")
+
 type id struct {
 	Id string `form:"id" uri:"id" binding:"required,uuid"`
 }
 
 func ParseUuidFromParam(c *gin.Context) (uuid.UUID, error) {
 	var id id
-	err := c.BindUri(&id)
+	err := c.ShouldBindUri(&id)
 	if err != nil {
-		log.Infof("Failed to parse uuid: %s", err.Error())
+		Logger(c).Infof("Failed to parse uuid: %s", err.Error())
 		return uuid.Nil, err
 	}
 	return uuid.FromString(id.Id)
@@ -30,14 +37,103 @@ func GetNilIfDefault[T comparable](value T) *T {
 	return &value
 }
 
+// AbortWithRelevantError responds with a problem matching the repository error.
+// Details of unexpected errors are not exposed, as they could contain internal information.
 func AbortWithRelevantError(err error, c *gin.Context) {
-	if errors.Is(err, DuplicateDataErr) {
-		c.AbortWithStatus(http.StatusBadRequest)
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		// the binding error is recorded there, so that it's logged only once
+		AbortWithBindingError(validationErrors, c)
+		return
+	}
+	_ = c.Error(err)
+	if errors.Is(err, DuplicateDataErr) || errors.Is(err, InvalidDataErr) {
+		AbortWithProblem(http.StatusBadRequest, err.Error(), c)
 	} else if errors.Is(err, DataNotFoundErr) {
-		c.AbortWithStatus(http.StatusNotFound)
+		AbortWithProblem(http.StatusNotFound, err.Error(), c)
+	} else if errors.Is(err, ConflictErr) {
+		AbortWithProblem(http.StatusConflict, err.Error(), c)
+	} else if errors.Is(err, VersionMismatchErr) {
+		AbortWithProblem(http.StatusPreconditionFailed, err.Error(), c)
+	} else if errors.Is(err, PreconditionRequiredErr) {
+		AbortWithProblem(http.StatusPreconditionRequired, err.Error(), c)
 	} else {
-		c.AbortWithStatus(http.StatusInternalServerError)
+		AbortWithProblem(http.StatusInternalServerError, "", c)
+	}
+}
+
+// SetETag sets the ETag header to the version of the returned entity.
+func SetETag(c *gin.Context, version int) {
+	c.Header("ETag", formatETag(version))
+}
+
+// AbortIfNotModified responds with 304 Not Modified if the If-None-Match header matches the version of the entity.
+func AbortIfNotModified(c *gin.Context, version int) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		// If-None-Match uses the weak comparison, so weak tags of the same version match as well
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == formatETag(version) {
+			SetETag(c, version)
+			c.AbortWithStatus(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// ParseIfMatch returns the version of the entity expected by the If-Match header, or 0 if `*` accepts any version.
+// Changes require the header, so that editors don't overwrite each other by accident, PreconditionRequiredErr is returned without it.
+// When the header lists several tags, getVersion is called to find out which of them is current, VersionMismatchErr is returned if none is.
+// Weak or malformed tags can never match, so they are skipped.
+func ParseIfMatch(c *gin.Context, getVersion func() (int, error)) (int, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return 0, PreconditionRequiredErr
+	}
+	if header == "*" {
+		return 0, nil
 	}
+	versions := make([]int, 0, 1)
+	for _, tag := range strings.Split(header, ",") {
+		if version, ok := parseETag(strings.TrimSpace(tag)); ok {
+			versions = append(versions, version)
+		}
+	}
+	if len(versions) == 0 {
+		Logger(c).Infof("Failed to parse If-Match header: %s", header)
+		return 0, VersionMismatchErr
+	}
+	if len(versions) == 1 {
+		return versions[0], nil
+	}
+	current, err := getVersion()
+	if err != nil {
+		return 0, err
+	}
+	if !slices.Contains(versions, current) {
+		return 0, VersionMismatchErr
+	}
+	// the change still requires the current version, so it fails if the entity is changed in the meantime
+	return current, nil
+}
+
+func parseETag(tag string) (int, bool) {
+	if len(tag) < 3 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
+}
+
+func formatETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
 }
 
 func GetPagesFromItems(totalItems int, pageSize int) int {
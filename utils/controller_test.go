@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"github.com/Geepr/game/mocks"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestContext(header string, value string) (*gin.Context, *httptest.ResponseRecorder) {
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	if value != "" {
+		c.Request.Header.Set(header, value)
+	}
+	return c, recorder
+}
+
+func TestParseIfMatch_HeaderValues_ParsedCorrectly(t *testing.T) {
+	// the current version is 4, it's only read when several tags are listed
+	testData := []struct {
+		header   string
+		version  int
+		expected error
+	}{
+		{"", 0, PreconditionRequiredErr},
+		{"*", 0, nil},
+		{`"3"`, 3, nil},
+		{` "12" `, 12, nil},
+		{`W/"3"`, 0, VersionMismatchErr},
+		{"3", 0, VersionMismatchErr},
+		{`"abc"`, 0, VersionMismatchErr},
+		{`"0"`, 0, VersionMismatchErr},
+		{`"3", "4"`, 4, nil},
+		{`"1","2"`, 0, VersionMismatchErr},
+		{`W/"4", "3"`, 3, nil},
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(currentData.header, func(t *testing.T) {
+			c, _ := newTestContext("If-Match", currentData.header)
+
+			version, err := ParseIfMatch(c, func() (int, error) { return 4, nil })
+
+			mocks.AssertEquals(t, version, currentData.version)
+			mocks.AssertEquals(t, err, currentData.expected)
+		})
+	}
+}
+
+func TestParseIfMatch_SeveralTagsAndEntityMissing_ReturnsNotFound(t *testing.T) {
+	c, _ := newTestContext("If-Match", `"1", "2"`)
+
+	_, err := ParseIfMatch(c, func() (int, error) { return 0, DataNotFoundErr })
+
+	mocks.AssertEquals(t, err, DataNotFoundErr)
+}
+
+func TestAbortIfNotModified_HeaderValues_RespondsWhenMatching(t *testing.T) {
+	testData := []struct {
+		header  string
+		matches bool
+	}{
+		{"", false},
+		{`"2"`, false},
+		{`"3"`, true},
+		{`W/"3"`, true},
+		{`"1", "3"`, true},
+		{"*", true},
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(currentData.header, func(t *testing.T) {
+			c, recorder := newTestContext("If-None-Match", currentData.header)
+
+			aborted := AbortIfNotModified(c, 3)
+
+			mocks.AssertEquals(t, aborted, currentData.matches)
+			if currentData.matches {
+				mocks.AssertEquals(t, recorder.Code, http.StatusNotModified)
+				mocks.AssertEquals(t, recorder.Header().Get("ETag"), `"3"`)
+			}
+		})
+	}
+}
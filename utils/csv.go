@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"net/http"
+	"strings"
+)
+
+const (
+	MIMECSV = "text/csv"
+
+	// csvPageSize is used to read all pages of an export, when no page size is requested.
+	csvPageSize = 500
+)
+
+// CsvPage returns rows of a single page of entities, along with the total count of entities.
+type CsvPage func(pageIndex int, pageSize int) (rows [][]string, totalItems int, err error)
+
+// WantsCsv checks if the client accepts csv over json, listing routes return json unless csv is preferred.
+func WantsCsv(c *gin.Context) bool {
+	return c.NegotiateFormat(binding.MIMEJSON, MIMECSV) == MIMECSV
+}
+
+// ServeCsv responds with the header and rows of the requested page, or of all pages if no page size was requested.
+// The first page is read before anything is written, so that failing queries still result in a problem response.
+func ServeCsv(c *gin.Context, filename string, header []string, pageIndex int, pageSize int, getPage CsvPage) {
+	all := pageSize < 1
+	if all {
+		pageIndex, pageSize = 1, csvPageSize
+	}
+	rows, totalItems, err := getPage(pageIndex, pageSize)
+	if err != nil {
+		AbortWithProblem(http.StatusInternalServerError, "", c)
+		return
+	}
+
+	c.Header("Content-Type", MIMECSV+"; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+	writer := csv.NewWriter(c.Writer)
+	_ = writer.Write(header)
+	for {
+		if err = writer.WriteAll(rows); err != nil {
+			Logger(c).Infof("Failed to write csv export: %s", err.Error())
+			return
+		}
+		if !all || pageIndex*pageSize >= totalItems {
+			return
+		}
+		pageIndex++
+		if rows, _, err = getPage(pageIndex, pageSize); err != nil {
+			// the status has already been sent, so the response can only be cut short
+			Logger(c).Warnf("Failed to read page %d of csv export: %s", pageIndex, err.Error())
+			_ = c.Error(err)
+			c.Abort()
+			return
+		}
+	}
+}
+
+// CsvValue formats an optional value as a csv field, with nil written as an empty field.
+// The value is escaped with CsvText.
+func CsvValue[T any](value *T) string {
+	if value == nil {
+		return ""
+	}
+	return CsvText(fmt.Sprint(*value))
+}
+
+// csvEscapedPrefixes start cells that spreadsheets run as formulas, along with the quote used to escape them.
+const csvEscapedPrefixes = "=+-@\t\r'"
+
+// CsvText escapes user-provided text written to csv exports, so that spreadsheets show it instead of running it as a formula.
+// Values starting like a formula are prefixed with a single quote, which ParseCsvText removes again.
+// Values already starting with a quote are prefixed as well, so that they are not mistaken for escaped ones.
+func CsvText(value string) string {
+	if value != "" && strings.ContainsRune(csvEscapedPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// ParseCsvText reverts CsvText, so that exported values can be imported back as they were.
+func ParseCsvText(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(csvEscapedPrefixes, rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"errors"
+	"github.com/Geepr/game/mocks"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestWantsCsv_AcceptHeader_Negotiated(t *testing.T) {
+	testData := []struct {
+		accept string
+		csv    bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"application/json", false},
+		{"text/csv", true},
+		{"text/csv, application/json", true},
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(currentData.accept, func(t *testing.T) {
+			c, _ := newTestContext("Accept", currentData.accept)
+
+			mocks.AssertEquals(t, WantsCsv(c), currentData.csv)
+		})
+	}
+}
+
+func TestServeCsv_NoPageSize_AllPagesWritten(t *testing.T) {
+	c, recorder := newTestContext("Accept", MIMECSV)
+	requested := make([]int, 0)
+	getPage := func(pageIndex int, pageSize int) ([][]string, int, error) {
+		requested = append(requested, pageIndex)
+		return [][]string{{strconv.Itoa(pageIndex), "a,b"}}, csvPageSize + 1, nil
+	}
+
+	ServeCsv(c, "test.csv", []string{"page", "value"}, 3, 0, getPage)
+
+	mocks.AssertEquals(t, recorder.Code, http.StatusOK)
+	mocks.AssertEquals(t, recorder.Body.String(), "page,value\n1,\"a,b\"\n2,\"a,b\"\n")
+	mocks.AssertCountEqual(t, requested, 2)
+}
+
+func TestServeCsv_PageSize_OnlyRequestedPageWritten(t *testing.T) {
+	c, recorder := newTestContext("Accept", MIMECSV)
+	getPage := func(pageIndex int, pageSize int) ([][]string, int, error) {
+		return [][]string{{strconv.Itoa(pageIndex)}}, 100, nil
+	}
+
+	ServeCsv(c, "test.csv", []string{"page"}, 3, 10, getPage)
+
+	mocks.AssertEquals(t, recorder.Body.String(), "page\n3\n")
+}
+
+func TestServeCsv_FirstPageFails_ProblemReturned(t *testing.T) {
+	c, recorder := newTestContext("Accept", MIMECSV)
+	getPage := func(pageIndex int, pageSize int) ([][]string, int, error) {
+		return nil, 0, errors.New("test")
+	}
+
+	ServeCsv(c, "test.csv", []string{"page"}, 1, 0, getPage)
+
+	mocks.AssertEquals(t, recorder.Code, http.StatusInternalServerError)
+	mocks.AssertEquals(t, recorder.Header().Get("Content-Type"), problemContentType)
+}
+
+func TestCsvText_FormulaPrefixes_Escaped(t *testing.T) {
+	testData := []struct {
+		value    string
+		expected string
+	}{
+		{"", ""},
+		{"Portal 2", "Portal 2"},
+		{"=HYPERLINK(\"http://example.com\")", "'=HYPERLINK(\"http://example.com\")"},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tcmd", "'\tcmd"},
+		{"\rcmd", "'\rcmd"},
+		{"a=b", "a=b"},
+		{"'quoted", "''quoted"},
+		{"'=x", "''=x"},
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(currentData.value, func(t *testing.T) {
+			escaped := CsvText(currentData.value)
+
+			mocks.AssertEquals(t, escaped, currentData.expected)
+			mocks.AssertEquals(t, ParseCsvText(escaped), currentData.value)
+		})
+	}
+}
+
+func TestCsvValue_FormulaPrefix_Escaped(t *testing.T) {
+	value := "=1+1"
+
+	mocks.AssertEquals(t, CsvValue(&value), "'=1+1")
+	mocks.AssertEquals(t, CsvValue[string](nil), "")
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// RequestIdHeader carries the id of a request, both from the client and back in the response.
+	RequestIdHeader = "X-Request-ID"
+
+	// the keys are strings, so that gin contexts return their values from context.Context.Value as well
+	requestIdContextKey = "requestId"
+	loggerContextKey    = "logger"
+)
+
+// SetRequestId assigns the id to the request, along with a logger that includes it in all messages.
+func SetRequestId(c *gin.Context, requestId string) {
+	c.Set(requestIdContextKey, requestId)
+	c.Set(loggerContextKey, log.WithField("requestId", requestId))
+}
+
+// GetRequestId returns the id assigned to the current request, or an empty string if there's none.
+func GetRequestId(c *gin.Context) string {
+	return c.GetString(requestIdContextKey)
+}
+
+// Logger returns the logger of the request that the context belongs to.
+// Contexts without a request, like those of background tasks, get the standard logger.
+func Logger(ctx context.Context) *log.Entry {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerContextKey).(*log.Entry); ok {
+			return logger
+		}
+	}
+	return log.NewEntry(log.StandardLogger())
+}
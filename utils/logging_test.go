@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"context"
+	"github.com/Geepr/game/mocks"
+	"testing"
+)
+
+func TestLogger_RequestContext_IncludesRequestId(t *testing.T) {
+	c, _ := newTestContext("", "")
+	SetRequestId(c, "abc")
+
+	logger := Logger(c)
+
+	mocks.AssertEquals(t, logger.Data["requestId"], any("abc"))
+	mocks.AssertEquals(t, GetRequestId(c), "abc")
+}
+
+func TestLogger_BackgroundContext_NoFields(t *testing.T) {
+	logger := Logger(context.Background())
+
+	mocks.AssertEquals(t, len(logger.Data), 0)
+}
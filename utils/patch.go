@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin/binding"
+	"reflect"
+)
+
+// ApplyMergePatch changes model according to an RFC 7396 merge patch and validates the result with its binding tags.
+// The model should be filled with the current state of the entity beforehand, fields removed by the patch are reset to zero values.
+// Patches that aren't json objects, contain fields unknown to the model or produce an invalid model are rejected with InvalidDataErr,
+// leaving the model unchanged.
+func ApplyMergePatch(ctx context.Context, model any, patch json.RawMessage) error {
+	var patchValue any
+	if err := decodeWithNumbers(patch, &patchValue); err != nil {
+		Logger(ctx).Infof("Failed to decode merge patch: %s", err.Error())
+		return fmt.Errorf("%w: %s", InvalidDataErr, err.Error())
+	}
+	if _, ok := patchValue.(map[string]any); !ok {
+		return fmt.Errorf("%w: merge patch must be a json object", InvalidDataErr)
+	}
+	current, err := json.Marshal(model)
+	if err != nil {
+		return err
+	}
+	var currentValue any
+	if err = decodeWithNumbers(current, &currentValue); err != nil {
+		return err
+	}
+	merged, err := json.Marshal(mergePatch(currentValue, patchValue))
+	if err != nil {
+		return err
+	}
+
+	// the result is decoded into a new value, so that the model is left untouched when the patch is rejected
+	target := reflect.ValueOf(model).Elem()
+	patched := reflect.New(target.Type())
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(patched.Interface()); err != nil {
+		Logger(ctx).Infof("Failed to apply merge patch: %s", err.Error())
+		return fmt.Errorf("%w: %s", InvalidDataErr, err.Error())
+	}
+	if err = binding.Validator.ValidateStruct(patched.Interface()); err != nil {
+		Logger(ctx).Infof("Merge patch produced invalid data: %s", err.Error())
+		return fmt.Errorf("%w: %w", InvalidDataErr, err)
+	}
+	target.Set(patched.Elem())
+	return nil
+}
+
+// mergePatch implements the MergePatch function of RFC 7396 on decoded json values.
+func mergePatch(target any, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+		} else {
+			targetObject[key] = mergePatch(targetObject[key], value)
+		}
+	}
+	return targetObject
+}
+
+// decodeWithNumbers keeps numbers as they are written, so that large integers don't lose precision on the way through float64.
+func decodeWithNumbers(data []byte, value any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(value)
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"github.com/Geepr/game/mocks"
+	"testing"
+)
+
+type patchTestNested struct {
+	Enabled bool `json:"enabled"`
+	Count   int  `json:"count" binding:"omitempty,min=2"`
+}
+
+type patchTestModel struct {
+	Title       string          `json:"title" binding:"required,max=5"`
+	Description *string         `json:"description"`
+	Tags        []string        `json:"tags"`
+	Nested      patchTestNested `json:"nested"`
+}
+
+func newPatchTestModel() patchTestModel {
+	description := "kept"
+	return patchTestModel{Title: "old", Description: &description, Tags: []string{"a", "b"}, Nested: patchTestNested{Enabled: true, Count: 3}}
+}
+
+func TestApplyMergePatch_PartialPatch_OtherFieldsKept(t *testing.T) {
+	model := newPatchTestModel()
+
+	err := ApplyMergePatch(context.Background(), &model, []byte(`{"title": "new", "nested": {"count": 5}}`))
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, model.Title, "new")
+	mocks.AssertEquals(t, *model.Description, "kept")
+	mocks.AssertCountEqual(t, model.Tags, 2)
+	mocks.AssertEquals(t, model.Nested.Enabled, true)
+	mocks.AssertEquals(t, model.Nested.Count, 5)
+}
+
+func TestApplyMergePatch_NullValue_FieldCleared(t *testing.T) {
+	model := newPatchTestModel()
+
+	err := ApplyMergePatch(context.Background(), &model, []byte(`{"description": null, "nested": {"count": null}}`))
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertEquals(t, model.Description == nil, true)
+	mocks.AssertEquals(t, model.Nested.Count, 0)
+	mocks.AssertEquals(t, model.Nested.Enabled, true)
+}
+
+func TestApplyMergePatch_Array_Replaced(t *testing.T) {
+	model := newPatchTestModel()
+
+	err := ApplyMergePatch(context.Background(), &model, []byte(`{"tags": ["c"]}`))
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertCountEqual(t, model.Tags, 1)
+	mocks.AssertEquals(t, model.Tags[0], "c")
+}
+
+func TestApplyMergePatch_InvalidPatch_ReturnsInvalidDataAndKeepsModel(t *testing.T) {
+	testData := map[string]string{
+		"unknown field":          `{"other": 1}`,
+		"wrong type":             `{"title": 1}`,
+		"validation failed":      `{"title": "too long"}`,
+		"required field removed": `{"title": null}`,
+		"nested validation":      `{"nested": {"count": 1}}`,
+		"not an object":          `[]`,
+		"not json":               `{`,
+	}
+
+	for name, patch := range testData {
+		currentPatch := patch
+		t.Run(name, func(t *testing.T) {
+			model := newPatchTestModel()
+
+			err := ApplyMergePatch(context.Background(), &model, []byte(currentPatch))
+
+			mocks.AssertEquals(t, errors.Is(err, InvalidDataErr), true)
+			mocks.AssertEquals(t, model.Title, "old")
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const (
+	// ProblemTypeDefault means that the problem has no meaning beyond its status code, as defined by RFC 7807.
+	ProblemTypeDefault = "about:blank"
+	// ProblemTypeValidation is used when the request is malformed, details of invalid fields are listed in Problem.Errors.
+	ProblemTypeValidation = "urn:geepr:problem:validation"
+
+	problemContentType = "application/problem+json"
+)
+
+// Problem is an RFC 7807 problem details response, returned by all routes on failure.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Instance is the path of the failed request.
+	Instance  string       `json:"instance,omitempty"`
+	RequestId string       `json:"requestId,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes a single field failing validation.
+type FieldError struct {
+	// Field is the path to the field as it's named in the request, like "capabilities.localCoopMaxPlayers".
+	Field string `json:"field"`
+	// Rule is the name of the failed validation rule, like "required" or "max".
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func init() {
+	// validation errors should use the names known to api users, rather than those of go struct fields
+	if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		engine.RegisterTagNameFunc(getRequestFieldName)
+	}
+}
+
+func getRequestFieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// AbortWithProblem responds with a problem of the default type, detail can be empty if the status code is descriptive enough.
+func AbortWithProblem(status int, detail string, c *gin.Context) {
+	abortWithProblem(newProblem(ProblemTypeDefault, status, detail, c), c)
+}
+
+// AbortWithBindingError responds with a validation problem, listing invalid fields if the error comes from the validator.
+// Other errors, like malformed json, are only described in the problem detail.
+func AbortWithBindingError(err error, c *gin.Context) {
+	_ = c.Error(err)
+	problem := newProblem(ProblemTypeValidation, http.StatusBadRequest, "", c)
+	if problem.Errors = FieldErrors(err); problem.Errors != nil {
+		problem.Detail = "some fields have invalid values"
+	} else {
+		problem.Detail = err.Error()
+	}
+	abortWithProblem(problem, c)
+}
+
+// FieldErrors lists the invalid fields if the error comes from the validator, and returns nil for other errors.
+func FieldErrors(err error) []FieldError {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil
+	}
+	fieldErrors := make([]FieldError, len(validationErrors))
+	for i, fieldErr := range validationErrors {
+		fieldErrors[i] = toFieldError(fieldErr)
+	}
+	return fieldErrors
+}
+
+func newProblem(problemType string, status int, detail string, c *gin.Context) *Problem {
+	return &Problem{
+		Type:      problemType,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  c.Request.URL.Path,
+		RequestId: GetRequestId(c),
+	}
+}
+
+func abortWithProblem(problem *Problem, c *gin.Context) {
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
+
+func toFieldError(err validator.FieldError) FieldError {
+	// the namespace starts with the name of the go struct, which means nothing to api users
+	// anonymous structs have no name though, so the first segment is only dropped if it's the same in both namespaces
+	field := err.Namespace()
+	root, rest, found := strings.Cut(field, ".")
+	if structRoot, _, _ := strings.Cut(err.StructNamespace(), "."); found && root == structRoot {
+		field = rest
+	}
+	return FieldError{
+		Field:   field,
+		Rule:    err.Tag(),
+		Message: describeRule(err),
+	}
+}
+
+func describeRule(err validator.FieldError) string {
+	switch err.Tag() {
+	case "required", "required_unless", "required_if":
+		return "value is required"
+	case "max":
+		return fmt.Sprintf("value must be at most %s", err.Param())
+	case "min":
+		return fmt.Sprintf("value must be at least %s", err.Param())
+	case "oneof":
+		return fmt.Sprintf("value must be one of: %s", strings.ReplaceAll(err.Param(), " ", ", "))
+	case "uuid":
+		return "value must be a valid uuid"
+	default:
+		return fmt.Sprintf("value failed the %s rule", err.Tag())
+	}
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Geepr/game/mocks"
+	"github.com/gin-gonic/gin/binding"
+	"net/http"
+	"testing"
+)
+
+func decodeProblem(t *testing.T, body []byte) Problem {
+	var problem Problem
+	if err := json.Unmarshal(body, &problem); err != nil {
+		t.Fatalf("Failed to decode problem: %s", err.Error())
+	}
+	return problem
+}
+
+func TestAbortWithBindingError_ValidationErrors_ListsRequestFieldNames(t *testing.T) {
+	c, recorder := newTestContext("", "")
+	SetRequestId(c, "abc")
+	model := struct {
+		Title   string `json:"title" binding:"required"`
+		Details struct {
+			Players int `json:"maxPlayers" binding:"max=4"`
+		} `json:"details"`
+	}{}
+	model.Details.Players = 5
+	err := binding.Validator.ValidateStruct(&model)
+
+	AbortWithBindingError(err, c)
+
+	mocks.AssertEquals(t, recorder.Code, http.StatusBadRequest)
+	mocks.AssertEquals(t, recorder.Header().Get("Content-Type"), "application/problem+json")
+	problem := decodeProblem(t, recorder.Body.Bytes())
+	mocks.AssertEquals(t, problem.Type, ProblemTypeValidation)
+	mocks.AssertEquals(t, problem.RequestId, "abc")
+	mocks.AssertEquals(t, problem.Instance, "/")
+	mocks.AssertCountEqual(t, problem.Errors, 2)
+	mocks.AssertEquals(t, problem.Errors[0].Field, "title")
+	mocks.AssertEquals(t, problem.Errors[0].Rule, "required")
+	mocks.AssertEquals(t, problem.Errors[1].Field, "details.maxPlayers")
+	mocks.AssertEquals(t, problem.Errors[1].Message, "value must be at most 4")
+}
+
+func TestAbortWithBindingError_OtherError_DescribedInDetail(t *testing.T) {
+	c, recorder := newTestContext("", "")
+
+	AbortWithBindingError(fmt.Errorf("unexpected end of JSON input"), c)
+
+	problem := decodeProblem(t, recorder.Body.Bytes())
+	mocks.AssertEquals(t, problem.Status, http.StatusBadRequest)
+	mocks.AssertEquals(t, problem.Detail, "unexpected end of JSON input")
+	mocks.AssertCountEqual(t, problem.Errors, 0)
+}
+
+func TestAbortWithRelevantError_Errors_MappedToStatus(t *testing.T) {
+	testData := []struct {
+		err    error
+		status int
+	}{
+		{DataNotFoundErr, http.StatusNotFound},
+		{DuplicateDataErr, http.StatusBadRequest},
+		{fmt.Errorf("%w: title is empty", InvalidDataErr), http.StatusBadRequest},
+		{ConflictErr, http.StatusConflict},
+		{VersionMismatchErr, http.StatusPreconditionFailed},
+		{PreconditionRequiredErr, http.StatusPreconditionRequired},
+		{fmt.Errorf("connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(currentData.err.Error(), func(t *testing.T) {
+			c, recorder := newTestContext("", "")
+
+			AbortWithRelevantError(currentData.err, c)
+
+			problem := decodeProblem(t, recorder.Body.Bytes())
+			mocks.AssertEquals(t, recorder.Code, currentData.status)
+			mocks.AssertEquals(t, problem.Status, currentData.status)
+			mocks.AssertEquals(t, problem.Type, ProblemTypeDefault)
+			mocks.AssertEquals(t, problem.Title, http.StatusText(currentData.status))
+		})
+	}
+}
+
+func TestAbortWithRelevantError_UnexpectedError_DetailHidden(t *testing.T) {
+	c, recorder := newTestContext("", "")
+
+	AbortWithRelevantError(fmt.Errorf("pq: password authentication failed"), c)
+
+	mocks.AssertEquals(t, decodeProblem(t, recorder.Body.Bytes()).Detail, "")
+}
+
+func TestAbortWithRelevantError_ValidationError_RecordedOnce(t *testing.T) {
+	c, recorder := newTestContext("", "")
+	model := struct {
+		Title string `json:"title" binding:"required"`
+	}{}
+	err := binding.Validator.ValidateStruct(&model)
+
+	AbortWithRelevantError(err, c)
+
+	mocks.AssertEquals(t, recorder.Code, http.StatusBadRequest)
+	mocks.AssertCountEqual(t, c.Errors, 1)
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/KowalskiPiotr98/gotabase"
@@ -15,6 +16,10 @@ var (
 	unorderedQueryErr = errors.New("query must contain the order by clause to be paginated correctly")
 	DataNotFoundErr   = errors.New("requested data was not found in the database")
 	DuplicateDataErr  = errors.New("this data already exists")
+	InvalidDataErr    = errors.New("provided data is not consistent with the data in the database")
+	ConflictErr       = errors.New("data has been changed in the meantime")
+	// VersionMismatchErr is returned when a versioned entity has been changed since the version expected by the caller.
+	VersionMismatchErr = errors.New("data has been modified since the expected version")
 
 	DefaultUuid uuid.UUID
 )
@@ -70,6 +75,14 @@ func IsUuidNotEmpty(value uuid.UUID) bool {
 	return value != defaultUuid
 }
 
+func IsTrue(value bool) bool {
+	return value
+}
+
+func IsPositive(value int) bool {
+	return value > 0
+}
+
 func MakeLikeQuery(value string) string {
 	return fmt.Sprintf("%%%s%%", value)
 }
@@ -86,6 +99,15 @@ func ConvertIfNotFoundErr(err error) error {
 	return err
 }
 
+// ConvertIfVersionMismatchErr converts a row not found by a versioned query to VersionMismatchErr, as long as the row itself exists.
+func ConvertIfVersionMismatchErr(err error, exists bool) error {
+	err = ConvertIfNotFoundErr(err)
+	if exists && errors.Is(err, DataNotFoundErr) {
+		return VersionMismatchErr
+	}
+	return err
+}
+
 func ConvertIfDuplicateErr(err error) error {
 	var pgErr *pq.Error
 	if err == nil || !errors.As(err, &pgErr) || pgErr.Code != "23505" {
@@ -95,10 +117,10 @@ func ConvertIfDuplicateErr(err error) error {
 	return DuplicateDataErr
 }
 
-func ScanCountQuery(connector gotabase.Connector, query string, args ...interface{}) (int, error) {
+func ScanCountQuery(ctx context.Context, connector gotabase.Connector, query string, args ...interface{}) (int, error) {
 	result, err := connector.QueryRow(query, args...)
 	if err != nil {
-		log.Warnf("Failed to run counting query: %s", err.Error())
+		Logger(ctx).Warnf("Failed to run counting query: %s", err.Error())
 		return -1, err
 	}
 	var count int
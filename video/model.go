@@ -0,0 +1,74 @@
+package video
+
+import (
+	"fmt"
+	"github.com/Geepr/game/language"
+	"github.com/Geepr/game/utils"
+	"github.com/gofrs/uuid"
+	"time"
+)
+
+type Kind string
+
+const (
+	KindTrailer  Kind = "trailer"
+	KindGameplay Kind = "gameplay"
+	KindReview   Kind = "review"
+)
+
+// Video is a link to an externally hosted video attached to either a game or a single game release.
+type Video struct {
+	Id uuid.UUID `json:"id"`
+	// Provider is the name of the hosting service, see knownProviders for supported values.
+	Provider Provider `json:"provider"`
+	// VideoId is the provider specific identifier of the video, as extracted from the original url.
+	VideoId string `json:"videoId"`
+	Kind    Kind   `json:"kind"`
+	// Language is a BCP-47 code of the spoken language of the video, if any.
+	Language    *string    `json:"language"`
+	PublishedAt *time.Time `json:"publishedAt"`
+	// Url and EmbedUrl are not stored, they are always built from Provider and VideoId.
+	Url      string `json:"url"`
+	EmbedUrl string `json:"embedUrl"`
+}
+
+// CreateModel is the shape in which videos are accepted by the create and update routes of games and releases.
+type CreateModel struct {
+	Url         string    `json:"url" binding:"required,url,max=500"`
+	Kind        Kind      `json:"kind" binding:"required,oneof=trailer gameplay review"`
+	Language    string    `json:"language" binding:"max=35"`
+	PublishedAt time.Time `json:"publishedAt"` //in format 2006-01-02T15:04:05Z07:00
+}
+
+// FromCreateModels validates the urls and languages of all passed models and converts them to videos ready to be stored.
+// Languages are stored in their canonical form.
+func FromCreateModels(models []CreateModel) ([]*Video, error) {
+	videos := make([]*Video, 0, len(models))
+	for _, model := range models {
+		provider, id, err := ParseUrl(model.Url)
+		if err != nil {
+			return nil, err
+		}
+		var tag string
+		if model.Language != "" {
+			if tag, err = language.Canonicalise(model.Language); err != nil {
+				return nil, fmt.Errorf("%w: %q", err, model.Language)
+			}
+		}
+		video := &Video{
+			Provider:    provider,
+			VideoId:     id,
+			Kind:        model.Kind,
+			Language:    utils.GetNilIfDefault(tag),
+			PublishedAt: utils.GetNilIfDefault(model.PublishedAt),
+		}
+		video.fillUrls()
+		videos = append(videos, video)
+	}
+	return videos, nil
+}
+
+func (v *Video) fillUrls() {
+	v.Url = v.Provider.canonicalUrl(v.VideoId)
+	v.EmbedUrl = v.Provider.embedUrl(v.VideoId)
+}
@@ -0,0 +1,46 @@
+package video
+
+import (
+	"errors"
+	"github.com/Geepr/game/language"
+	"github.com/Geepr/game/mocks"
+	"github.com/Geepr/game/utils"
+	"testing"
+)
+
+func TestFromCreateModels_Languages_Canonicalised(t *testing.T) {
+	testData := []struct {
+		language string
+		expected *string
+	}{
+		{"", nil},
+		{"en", utils.GetNilIfDefault("en")},
+		{"EN_us", utils.GetNilIfDefault("en-US")},
+		{" pt-br ", utils.GetNilIfDefault("pt-BR")},
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(currentData.language, func(t *testing.T) {
+			videos, err := FromCreateModels([]CreateModel{{Url: "https://youtu.be/dQw4w9WgXcQ", Kind: KindTrailer, Language: currentData.language}})
+
+			mocks.AssertDefault(t, err)
+			mocks.AssertCountEqual(t, videos, 1)
+			mocks.AssertEqualsNillable(t, videos[0].Language, currentData.expected)
+		})
+	}
+}
+
+func TestFromCreateModels_InvalidLanguage_ReturnsErr(t *testing.T) {
+	testData := []string{"english", "zz", "en--US"}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(currentData, func(t *testing.T) {
+			videos, err := FromCreateModels([]CreateModel{{Url: "https://youtu.be/dQw4w9WgXcQ", Kind: KindTrailer, Language: currentData}})
+
+			mocks.AssertEquals(t, errors.Is(err, language.InvalidLanguageErr), true)
+			mocks.AssertCountEqual(t, videos, 0)
+		})
+	}
+}
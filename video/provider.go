@@ -0,0 +1,109 @@
+package video
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+type Provider string
+
+const (
+	ProviderYouTube Provider = "youtube"
+	ProviderVimeo   Provider = "vimeo"
+	ProviderTwitch  Provider = "twitch"
+)
+
+var (
+	UnsupportedUrlErr = errors.New("video url is not valid or its provider is not supported")
+
+	youTubeIdRegex = regexp.MustCompile("^[A-Za-z0-9_-]{11}$")
+	numericIdRegex = regexp.MustCompile("^[0-9]+$")
+)
+
+// ParseUrl extracts the provider and video id from a user supplied url.
+// Only the providers listed in this file are accepted, as there is no way to build embed links for the rest.
+func ParseUrl(rawUrl string) (Provider, string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawUrl))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return "", "", UnsupportedUrlErr
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+
+	var provider Provider
+	var id string
+	switch host {
+	case "youtube.com", "youtube-nocookie.com":
+		provider = ProviderYouTube
+		if len(segments) == 1 && segments[0] == "watch" {
+			id = parsed.Query().Get("v")
+		} else if len(segments) == 2 && (segments[0] == "embed" || segments[0] == "shorts" || segments[0] == "live") {
+			id = segments[1]
+		}
+	case "youtu.be":
+		provider = ProviderYouTube
+		if len(segments) == 1 {
+			id = segments[0]
+		}
+	case "vimeo.com":
+		provider = ProviderVimeo
+		if len(segments) == 1 {
+			id = segments[0]
+		}
+	case "player.vimeo.com":
+		provider = ProviderVimeo
+		if len(segments) == 2 && segments[0] == "video" {
+			id = segments[1]
+		}
+	case "twitch.tv":
+		provider = ProviderTwitch
+		if len(segments) == 2 && segments[0] == "videos" {
+			id = segments[1]
+		}
+	}
+
+	if !provider.isValidId(id) {
+		return "", "", UnsupportedUrlErr
+	}
+	return provider, id, nil
+}
+
+// canonicalUrl returns the url under which the video can be watched on the provider website.
+func (p Provider) canonicalUrl(id string) string {
+	switch p {
+	case ProviderYouTube:
+		return fmt.Sprintf("https://www.youtube.com/watch?v=%s", id)
+	case ProviderVimeo:
+		return fmt.Sprintf("https://vimeo.com/%s", id)
+	case ProviderTwitch:
+		return fmt.Sprintf("https://www.twitch.tv/videos/%s", id)
+	}
+	return ""
+}
+
+// embedUrl returns the url that can be used as an iframe source, following what the provider returns from its oEmbed endpoint.
+func (p Provider) embedUrl(id string) string {
+	switch p {
+	case ProviderYouTube:
+		return fmt.Sprintf("https://www.youtube.com/embed/%s", id)
+	case ProviderVimeo:
+		return fmt.Sprintf("https://player.vimeo.com/video/%s", id)
+	case ProviderTwitch:
+		return fmt.Sprintf("https://player.twitch.tv/?video=%s", id)
+	}
+	return ""
+}
+
+func (p Provider) isValidId(id string) bool {
+	switch p {
+	case ProviderYouTube:
+		return youTubeIdRegex.MatchString(id)
+	case ProviderVimeo, ProviderTwitch:
+		return numericIdRegex.MatchString(id)
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package video
+
+import (
+	"github.com/Geepr/game/mocks"
+	"testing"
+)
+
+func TestParseUrl_KnownProviders_Normalised(t *testing.T) {
+	testData := []struct {
+		url      string
+		provider Provider
+		id       string
+	}{
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ", ProviderYouTube, "dQw4w9WgXcQ"},
+		{"https://youtube.com/watch?v=dQw4w9WgXcQ&t=42s", ProviderYouTube, "dQw4w9WgXcQ"},
+		{"https://m.youtube.com/watch?v=dQw4w9WgXcQ", ProviderYouTube, "dQw4w9WgXcQ"},
+		{"https://youtu.be/dQw4w9WgXcQ", ProviderYouTube, "dQw4w9WgXcQ"},
+		{"https://www.youtube.com/embed/dQw4w9WgXcQ", ProviderYouTube, "dQw4w9WgXcQ"},
+		{"https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ", ProviderYouTube, "dQw4w9WgXcQ"},
+		{"https://www.youtube.com/shorts/dQw4w9WgXcQ", ProviderYouTube, "dQw4w9WgXcQ"},
+		{"https://vimeo.com/76979871", ProviderVimeo, "76979871"},
+		{"https://player.vimeo.com/video/76979871", ProviderVimeo, "76979871"},
+		{"https://www.twitch.tv/videos/1234567890", ProviderTwitch, "1234567890"},
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(currentData.url, func(t *testing.T) {
+			provider, id, err := ParseUrl(currentData.url)
+
+			mocks.AssertDefault(t, err)
+			mocks.AssertEquals(t, provider, currentData.provider)
+			mocks.AssertEquals(t, id, currentData.id)
+		})
+	}
+}
+
+func TestParseUrl_InvalidOrUnknown_ReturnsErr(t *testing.T) {
+	testData := []string{
+		"",
+		"not a url",
+		"ftp://youtube.com/watch?v=dQw4w9WgXcQ",
+		"https://www.youtube.com/watch?v=tooShort",
+		"https://www.youtube.com/channel/dQw4w9WgXcQ",
+		"https://vimeo.com/channels/staffpicks",
+		"https://www.twitch.tv/somestreamer",
+		"https://example.com/video/123",
+	}
+
+	for _, data := range testData {
+		currentData := data
+		t.Run(currentData, func(t *testing.T) {
+			_, _, err := ParseUrl(currentData)
+
+			mocks.AssertEquals(t, err, UnsupportedUrlErr)
+		})
+	}
+}
+
+func TestFromCreateModels_ValidUrl_UrlsFilled(t *testing.T) {
+	videos, err := FromCreateModels([]CreateModel{{Url: "https://youtu.be/dQw4w9WgXcQ", Kind: KindTrailer, Language: "en"}})
+
+	mocks.AssertDefault(t, err)
+	mocks.AssertCountEqual(t, videos, 1)
+	mocks.AssertEquals(t, videos[0].Url, "https://www.youtube.com/watch?v=dQw4w9WgXcQ")
+	mocks.AssertEquals(t, videos[0].EmbedUrl, "https://www.youtube.com/embed/dQw4w9WgXcQ")
+	mocks.AssertEquals(t, *videos[0].Language, "en")
+	mocks.AssertEquals(t, videos[0].PublishedAt == nil, true)
+}
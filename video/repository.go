@@ -0,0 +1,81 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"github.com/Geepr/game/utils"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/gofrs/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	gameOwnerColumn    = "game_id"
+	releaseOwnerColumn = "game_release_id"
+)
+
+// GetForGames returns videos attached directly to any of the passed games, grouped by game id.
+func GetForGames(ctx context.Context, connector gotabase.Connector, gameIds []uuid.UUID) (map[uuid.UUID][]*Video, error) {
+	return getForOwners(ctx, connector, gameOwnerColumn, gameIds)
+}
+
+// GetForReleases returns videos attached to any of the passed game releases, grouped by release id.
+func GetForReleases(ctx context.Context, connector gotabase.Connector, releaseIds []uuid.UUID) (map[uuid.UUID][]*Video, error) {
+	return getForOwners(ctx, connector, releaseOwnerColumn, releaseIds)
+}
+
+// ReplaceForGame removes all videos of a game and stores the passed ones instead.
+// Should be run inside a transaction, together with the game update.
+func ReplaceForGame(ctx context.Context, connector gotabase.Connector, gameId uuid.UUID, videos []*Video) error {
+	return replaceForOwner(ctx, connector, gameOwnerColumn, gameId, videos)
+}
+
+// ReplaceForRelease removes all videos of a game release and stores the passed ones instead.
+// Should be run inside a transaction, together with the release update.
+func ReplaceForRelease(ctx context.Context, connector gotabase.Connector, releaseId uuid.UUID, videos []*Video) error {
+	return replaceForOwner(ctx, connector, releaseOwnerColumn, releaseId, videos)
+}
+
+func getForOwners(ctx context.Context, connector gotabase.Connector, ownerColumn string, ownerIds []uuid.UUID) (map[uuid.UUID][]*Video, error) {
+	grouped := make(map[uuid.UUID][]*Video, len(ownerIds))
+	if len(ownerIds) == 0 {
+		return grouped, nil
+	}
+	query := fmt.Sprintf("select %[1]s, id, provider, video_id, kind, language, published_at from videos where %[1]s = any($1) order by published_at nulls last, id", ownerColumn)
+	result, err := connector.QueryRows(query, pq.Array(ownerIds))
+	if err != nil {
+		utils.Logger(ctx).Warnf("Failed to run query on videos table: %s", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		var ownerId uuid.UUID
+		video := Video{}
+		if err := result.Scan(&ownerId, &video.Id, &video.Provider, &video.VideoId, &video.Kind, &video.Language, &video.PublishedAt); err != nil {
+			return nil, utils.ConvertIfNotFoundErr(err)
+		}
+		video.fillUrls()
+		grouped[ownerId] = append(grouped[ownerId], &video)
+	}
+	return grouped, nil
+}
+
+func replaceForOwner(ctx context.Context, connector gotabase.Connector, ownerColumn string, ownerId uuid.UUID, videos []*Video) error {
+	if _, err := connector.Exec(fmt.Sprintf("delete from videos where %s = $1", ownerColumn), ownerId); err != nil {
+		utils.Logger(ctx).Warnf("Failed to execute delete query on videos table: %s", err.Error())
+		return err
+	}
+	query := fmt.Sprintf("insert into videos (%s, provider, video_id, kind, language, published_at) values ($1, $2, $3, $4, $5, $6) returning id", ownerColumn)
+	for _, video := range videos {
+		result, err := connector.QueryRow(query, ownerId, video.Provider, video.VideoId, video.Kind, video.Language, video.PublishedAt)
+		if err != nil {
+			utils.Logger(ctx).Warnf("Failed to execute insert query on videos table: %s", err.Error())
+			return utils.ConvertIfNotFoundErr(err)
+		}
+		if err = result.Scan(&video.Id); err != nil {
+			return utils.ConvertIfNotFoundErr(err)
+		}
+	}
+	return nil
+}